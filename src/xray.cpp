@@ -1,3 +1,4 @@
+#include <stdexcept>
 #include <string>
 #if defined(__MINGW32__) && defined(_M_ARM64)
     // CPython 3.14t uses MSVC's __getReg(18) intrinsic to read the Windows
@@ -26,28 +27,77 @@
 namespace py = pybind11;
 
 namespace {
+    // Error classes reported by the Go side, mirroring the errorClass
+    // constants in xray-go/main/errors.go.
+    enum ErrorClass : GoInt {
+        ErrorClassNone = 0,
+        ErrorClassConfig = 1,
+        ErrorClassStart = 2,
+        ErrorClassRuntime = 3,
+    };
+
+    struct XrayError : std::runtime_error {
+        using std::runtime_error::runtime_error;
+    };
+
+    struct XrayConfigError : XrayError {
+        using XrayError::XrayError;
+    };
+
+    struct XrayStartError : XrayError {
+        using XrayError::XrayError;
+    };
+
+    struct XrayRuntimeError : XrayError {
+        using XrayError::XrayError;
+    };
+
+    void checkError(GoInt errorClass, char* errorMessage)
+    {
+        if (errorClass == ErrorClassNone) {
+            return;
+        }
+
+        std::string message{errorMessage == nullptr ? "" : errorMessage};
+
+        if (errorMessage != nullptr) {
+            freeCString(errorMessage);
+        }
+
+        switch (errorClass) {
+            case ErrorClassConfig:
+                throw XrayConfigError(message);
+            case ErrorClassStart:
+                throw XrayStartError(message);
+            default:
+                throw XrayRuntimeError(message);
+        }
+    }
+
     std::string queryStats(const std::string& apiServer, int timeout, const std::string& myPattern, bool reset)
     {
         GoString apiServerString{apiServer.data(), static_cast<ptrdiff_t>(apiServer.size())};
         GoString myPatternString{myPattern.data(), static_cast<ptrdiff_t>(myPattern.size())};
 
-        char* ptr = nullptr;
+        queryStats_return ret{};
 
         {
             py::gil_scoped_release release;
 
-            ptr = queryStats(apiServerString, static_cast<GoInt>(timeout), myPatternString, static_cast<GoUint8>(reset));
+            ret = queryStats(apiServerString, static_cast<GoInt>(timeout), myPatternString, static_cast<GoUint8>(reset));
 
             py::gil_scoped_acquire acquire;
         }
 
-        if (ptr == nullptr) {
+        checkError(ret.r1, ret.r2);
+
+        if (ret.r0 == nullptr) {
             return "";
         }
         else {
-            std::string result{ptr};
+            std::string result{ret.r0};
 
-            freeCString(ptr);
+            freeCString(ret.r0);
 
             return result;
         }
@@ -57,19 +107,348 @@ namespace {
     {
         GoString jsonString{json.data(), static_cast<ptrdiff_t>(json.size())};
 
+        startFromJSON_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = startFromJSON(jsonString);
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r0, ret.r1);
+    }
+
+    long long startInstanceFromJSON(const std::string& json)
+    {
+        GoString jsonString{json.data(), static_cast<ptrdiff_t>(json.size())};
+
+        startInstanceFromJSON_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = startInstanceFromJSON(jsonString);
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        return static_cast<long long>(ret.r0);
+    }
+
+    bool stopInstance(long long handle)
+    {
+        stopInstance_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::stopInstance(static_cast<GoInt64>(handle));
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        return ret.r0 != 0;
+    }
+
+    void reloadInstanceFromJSON(long long handle, const std::string& json)
+    {
+        GoString jsonString{json.data(), static_cast<ptrdiff_t>(json.size())};
+
+        reloadInstanceFromJSON_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::reloadInstanceFromJSON(static_cast<GoInt64>(handle), jsonString);
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r0, ret.r1);
+    }
+
+    bool isRunning(long long handle)
+    {
+        return ::isRunning(static_cast<GoInt64>(handle)) != 0;
+    }
+
+    std::string queryInstanceStats(long long handle, const std::string& myPattern, bool reset)
+    {
+        GoString myPatternString{myPattern.data(), static_cast<ptrdiff_t>(myPattern.size())};
+
+        queryInstanceStats_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::queryInstanceStats(static_cast<GoInt64>(handle), myPatternString, static_cast<GoUint8>(reset));
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        if (ret.r0 == nullptr) {
+            return "";
+        }
+        else {
+            std::string result{ret.r0};
+
+            freeCString(ret.r0);
+
+            return result;
+        }
+    }
+
+    std::string callAPI(long long handle, const std::string& method, const std::string& request)
+    {
+        GoString methodString{method.data(), static_cast<ptrdiff_t>(method.size())};
+        GoString requestString{request.data(), static_cast<ptrdiff_t>(request.size())};
+
+        callAPI_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::callAPI(static_cast<GoInt64>(handle), methodString, requestString);
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        if (ret.r0 == nullptr) {
+            return "";
+        }
+        else {
+            std::string result{ret.r0};
+
+            freeCString(ret.r0);
+
+            return result;
+        }
+    }
+
+    void enableLogEvents(int capacity)
+    {
+        ::enableLogEvents(static_cast<GoInt>(capacity));
+    }
+
+    std::string pollLogEvents(int max)
+    {
+        pollLogEvents_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::pollLogEvents(static_cast<GoInt>(max));
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        if (ret.r0 == nullptr) {
+            return "";
+        }
+        else {
+            std::string result{ret.r0};
+
+            freeCString(ret.r0);
+
+            return result;
+        }
+    }
+
+    std::string outboundFromLink(const std::string& link)
+    {
+        GoString linkString{link.data(), static_cast<ptrdiff_t>(link.size())};
+
+        outboundFromLink_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::outboundFromLink(linkString);
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        if (ret.r0 == nullptr) {
+            return "";
+        }
+        else {
+            std::string result{ret.r0};
+
+            freeCString(ret.r0);
+
+            return result;
+        }
+    }
+
+    std::string outboundsToLinks(const std::string& json)
+    {
+        GoString jsonString{json.data(), static_cast<ptrdiff_t>(json.size())};
+
+        outboundsToLinks_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::outboundsToLinks(jsonString);
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        if (ret.r0 == nullptr) {
+            return "";
+        }
+        else {
+            std::string result{ret.r0};
+
+            freeCString(ret.r0);
+
+            return result;
+        }
+    }
+
+    std::string probeOutboundsFromJSON(const std::string& json, const std::string& probeURL, int timeout)
+    {
+        GoString jsonString{json.data(), static_cast<ptrdiff_t>(json.size())};
+        GoString probeURLString{probeURL.data(), static_cast<ptrdiff_t>(probeURL.size())};
+
+        probeOutboundsFromJSON_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::probeOutboundsFromJSON(jsonString, probeURLString, static_cast<GoInt>(timeout));
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        if (ret.r0 == nullptr) {
+            return "";
+        }
+        else {
+            std::string result{ret.r0};
+
+            freeCString(ret.r0);
+
+            return result;
+        }
+    }
+
+    long long subscribeRoutingEvents(long long handle, int capacity, bool dropOldest)
+    {
+        subscribeRoutingEvents_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::subscribeRoutingEvents(static_cast<GoInt64>(handle), static_cast<GoInt>(capacity), static_cast<GoUint8>(dropOldest));
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        return static_cast<long long>(ret.r0);
+    }
+
+    std::string pollRoutingEvents(long long subscription, int max)
+    {
+        pollRoutingEvents_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::pollRoutingEvents(static_cast<GoInt64>(subscription), static_cast<GoInt>(max));
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        if (ret.r0 == nullptr) {
+            return "";
+        }
+        else {
+            std::string result{ret.r0};
+
+            freeCString(ret.r0);
+
+            return result;
+        }
+    }
+
+    bool unsubscribeRoutingEvents(long long subscription)
+    {
+        unsubscribeRoutingEvents_return ret{};
+
+        {
+            py::gil_scoped_release release;
+
+            ret = ::unsubscribeRoutingEvents(static_cast<GoInt64>(subscription));
+
+            py::gil_scoped_acquire acquire;
+        }
+
+        checkError(ret.r1, ret.r2);
+
+        return ret.r0 != 0;
+    }
+
+    std::string validateConfigJSON(const std::string& json)
+    {
+        GoString jsonString{json.data(), static_cast<ptrdiff_t>(json.size())};
+
+        validateConfigJSON_return ret{};
+
         {
             py::gil_scoped_release release;
 
-            startFromJSON(jsonString);
+            ret = ::validateConfigJSON(jsonString);
 
             py::gil_scoped_acquire acquire;
         }
+
+        checkError(ret.r1, ret.r2);
+
+        if (ret.r0 == nullptr) {
+            return "";
+        }
+        else {
+            std::string result{ret.r0};
+
+            freeCString(ret.r0);
+
+            return result;
+        }
     }
 
     // TODO: After auditing and testing the C++ and Go paths for free-threaded
     // safety, use PYBIND11_MODULE(xray, m, py::mod_gil_not_used()) so importing
     // this extension does not cause free-threaded CPython to enable the GIL.
     PYBIND11_MODULE(xray, m) {
+        auto xrayError = py::register_exception<XrayError>(m, "XrayError");
+
+        py::register_exception<XrayConfigError>(m, "XrayConfigError", xrayError.ptr());
+        py::register_exception<XrayStartError>(m, "XrayStartError", xrayError.ptr());
+        py::register_exception<XrayRuntimeError>(m, "XrayRuntimeError", xrayError.ptr());
+
         m.def("queryStats",
             &queryStats,
             "Query statistics from Xray",
@@ -80,6 +459,81 @@ namespace {
             "Start Xray client with JSON string",
             py::arg("json"));
 
+        m.def("startInstanceFromJSON",
+            &startInstanceFromJSON,
+            "Start Xray instance with JSON string and return its handle without blocking",
+            py::arg("json"));
+
+        m.def("stopInstance",
+            &stopInstance,
+            "Stop Xray instance by handle",
+            py::arg("handle"));
+
+        m.def("reloadInstanceFromJSON",
+            &reloadInstanceFromJSON,
+            "Reload Xray instance by handle with JSON string, replacing only what changed",
+            py::arg("handle"), py::arg("json"));
+
+        m.def("isRunning",
+            &isRunning,
+            "Check whether Xray instance by handle is running",
+            py::arg("handle"));
+
+        m.def("queryInstanceStats",
+            &queryInstanceStats,
+            "Query statistics and online users from Xray instance by handle without an API inbound",
+            py::arg("handle"), py::arg("myPattern"), py::arg("reset"));
+
+        m.def("callAPI",
+            &callAPI,
+            "Call management API method on Xray instance by handle with JSON request and return JSON response",
+            py::arg("handle"), py::arg("method"), py::arg("request") = "");
+
+        m.def("enableLogEvents",
+            &enableLogEvents,
+            "Buffer up to capacity messages of logs configured as \"event\", or stop buffering if capacity is 0",
+            py::arg("capacity"));
+
+        m.def("pollLogEvents",
+            &pollLogEvents,
+            "Remove and return up to max buffered log events as a JSON array, or all of them if max is 0",
+            py::arg("max") = 0);
+
+        m.def("outboundFromLink",
+            &outboundFromLink,
+            "Convert a share link such as vless:// to an outbound JSON object",
+            py::arg("link"));
+
+        m.def("outboundsToLinks",
+            &outboundsToLinks,
+            "Convert an outbound JSON object, an array of them, or a config with outbounds to share links as a JSON array",
+            py::arg("json"));
+
+        m.def("probeOutboundsFromJSON",
+            &probeOutboundsFromJSON,
+            "Test outbound JSON objects in a temporary Xray instance with an HTTP request and return delay, TLS handshake time and error reason of each as a JSON array",
+            py::arg("json"), py::arg("probeURL") = "", py::arg("timeout") = 0);
+
+        m.def("subscribeRoutingEvents",
+            &subscribeRoutingEvents,
+            "Queue up to capacity routing decisions of Xray instance by handle, dropping the oldest or the newest one when full, and return the subscription",
+            py::arg("handle"), py::arg("capacity"), py::arg("dropOldest") = true);
+
+        m.def("pollRoutingEvents",
+            &pollRoutingEvents,
+            "Remove and return up to max queued routing events of subscription as a JSON array, or all of them if max is 0",
+            py::arg("subscription"), py::arg("max") = 0);
+
+        m.def("unsubscribeRoutingEvents",
+            &unsubscribeRoutingEvents,
+            "Stop queueing routing events of subscription",
+            py::arg("subscription"));
+
+        m.def("validateConfigJSON",
+            &validateConfigJSON,
+            "Check JSON config for all problems and return them as a JSON array of path, severity and message",
+            py::arg("json"));
+
         m.attr("__version__") = "1.8.26.9";
     }
 }
@@ -0,0 +1,108 @@
+package dispatcher
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/log"
+)
+
+type accessKey int
+
+const accessTrafficKey accessKey = 0
+
+// sessionCounter is a stats.Counter of a single session.
+type sessionCounter struct {
+	value atomic.Int64
+}
+
+func (c *sessionCounter) Value() int64 {
+	return c.value.Load()
+}
+
+func (c *sessionCounter) Set(newValue int64) int64 {
+	return c.value.Swap(newValue)
+}
+
+func (c *sessionCounter) Add(delta int64) int64 {
+	return c.value.Add(delta)
+}
+
+// accessTraffic is the traffic of a session, recorded in its access log when
+// it closes.
+type accessTraffic struct {
+	start    time.Time
+	uplink   sessionCounter
+	downlink sessionCounter
+}
+
+// contextWithAccessTraffic starts counting the traffic of the session in ctx,
+// if it has an access message to be recorded when it closes.
+func contextWithAccessTraffic(ctx context.Context) context.Context {
+	if log.AccessMessageFromContext(ctx) == nil || !log.RecordsAccessClose() {
+		return ctx
+	}
+	return context.WithValue(ctx, accessTrafficKey, &accessTraffic{start: time.Now()})
+}
+
+func accessTrafficFromContext(ctx context.Context) *accessTraffic {
+	if t, ok := ctx.Value(accessTrafficKey).(*accessTraffic); ok {
+		return t
+	}
+	return nil
+}
+
+// recordAccessClose records the access message of a closed session, with its
+// traffic and duration, if its traffic is counted.
+func recordAccessClose(ctx context.Context, msg *log.AccessMessage) {
+	t := accessTrafficFromContext(ctx)
+	if t == nil {
+		return
+	}
+	closed := *msg
+	closed.Phase = log.AccessClose
+	closed.Uplink = t.uplink.Value()
+	closed.Downlink = t.downlink.Value()
+	closed.Duration = time.Since(t.start)
+	log.Record(&closed)
+}
+
+// sizeStatReader is a buf.Reader counting the size of buffers read.
+type sizeStatReader struct {
+	counter *sessionCounter
+	reader  buf.Reader
+}
+
+func (r *sizeStatReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb, err := r.reader.ReadMultiBuffer()
+	r.counter.Add(int64(mb.Len()))
+	return mb, err
+}
+
+func (r *sizeStatReader) Interrupt() {
+	common.Interrupt(r.reader)
+}
+
+// sizeStatWriter is a buf.Writer counting the size of buffers written. It is
+// kept inside the user stats writers, as splice copy only adds to the outermost
+// SizeStatWriter of a link.
+type sizeStatWriter struct {
+	counter *sessionCounter
+	writer  buf.Writer
+}
+
+func (w *sizeStatWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	w.counter.Add(int64(mb.Len()))
+	return w.writer.WriteMultiBuffer(mb)
+}
+
+func (w *sizeStatWriter) Close() error {
+	return common.Close(w.writer)
+}
+
+func (w *sizeStatWriter) Interrupt() {
+	common.Interrupt(w.writer)
+}
@@ -0,0 +1,93 @@
+package dispatcher_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/testing/mocks"
+	"github.com/xtls/xray-core/transport"
+)
+
+// accessCloseHandler is a log handler recording access messages of closed
+// sessions.
+type accessCloseHandler struct {
+	closed chan *log.AccessMessage
+}
+
+func (h *accessCloseHandler) Handle(msg log.Message) {
+	if msg, ok := msg.(*log.AccessMessage); ok && msg.Phase == log.AccessClose {
+		h.closed <- msg
+	}
+}
+
+func (h *accessCloseHandler) RecordsAccessClose() bool {
+	return true
+}
+
+// spliceHandler is an outbound handler copying payload to its link with
+// splice copy allowed.
+type spliceHandler struct {
+	payload []byte
+}
+
+func (h *spliceHandler) Start() error                         { return nil }
+func (h *spliceHandler) Close() error                         { return nil }
+func (h *spliceHandler) Tag() string                          { return "splice" }
+func (h *spliceHandler) SenderSettings() *serial.TypedMessage { return nil }
+func (h *spliceHandler) ProxySettings() *serial.TypedMessage  { return nil }
+
+func (h *spliceHandler) Dispatch(ctx context.Context, link *transport.Link) {
+	outbounds := session.OutboundsFromContext(ctx)
+	outbounds[len(outbounds)-1].CanSpliceCopy = 1
+	common.Must(copyRawConn(ctx, h.payload, link.Writer))
+	common.Close(link.Writer)
+}
+
+func TestAccessTrafficSpliceCopy(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	ohm := mocks.NewOutboundManager(mockCtl)
+	ohm.EXPECT().GetDefaultHandler().Return(&spliceHandler{payload: []byte("abcdefgh")})
+
+	pm, err := policy.New(context.Background(), &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			0: {Stats: &policy.Policy_Stats{UserDownlink: true}},
+		},
+	})
+	common.Must(err)
+	sm, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+	d := new(DefaultDispatcher)
+	common.Must(d.Init(&Config{}, ohm, nil, pm, sm))
+
+	handler := &accessCloseHandler{closed: make(chan *log.AccessMessage, 1)}
+	log.RegisterHandler(handler)
+
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+		User:          &protocol.MemoryUser{Email: "test"},
+		CanSpliceCopy: 1,
+	})
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{Status: log.AccessAccepted})
+	common.Must2(d.Dispatch(ctx, net.TCPDestination(net.LocalHostIP, 80)))
+
+	select {
+	case <-handler.closed:
+	case <-time.After(time.Second):
+		t.Fatal("session not closed")
+	}
+	if c := sm.GetCounter("user>>>test>>>traffic>>>downlink"); c == nil || c.Value() != 8 {
+		t.Error("unexpected downlink counter ", c)
+	}
+}
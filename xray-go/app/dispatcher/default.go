@@ -15,6 +15,7 @@ import (
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/extension"
 	"github.com/xtls/xray-core/features/outbound"
 	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
@@ -98,6 +99,9 @@ type DefaultDispatcher struct {
 	policy policy.Manager
 	stats  stats.Manager
 	fdns   dns.FakeDNSEngine
+	tracer extension.Tracer
+
+	sessions userSessions
 }
 
 func init() {
@@ -107,6 +111,9 @@ func init() {
 			core.OptionalFeatures(ctx, func(fdns dns.FakeDNSEngine) {
 				d.fdns = fdns
 			})
+			core.OptionalFeatures(ctx, func(tracer extension.Tracer) {
+				d.tracer = tracer
+			})
 			return d.Init(config.(*Config), om, router, pm, sm)
 		}); err != nil {
 			return nil, err
@@ -152,6 +159,17 @@ func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *tran
 		Writer: downlinkWriter,
 	}
 
+	if t := accessTrafficFromContext(ctx); t != nil {
+		inboundLink.Writer = &sizeStatWriter{
+			counter: &t.uplink,
+			writer:  inboundLink.Writer,
+		}
+		outboundLink.Writer = &sizeStatWriter{
+			counter: &t.downlink,
+			writer:  outboundLink.Writer,
+		}
+	}
+
 	sessionInbound := session.InboundFromContext(ctx)
 	var user *protocol.MemoryUser
 	if sessionInbound != nil {
@@ -184,6 +202,20 @@ func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *tran
 		}
 	}
 
+	uplink, downlink := newLimiters(ctx, d.policy, d.stats)
+	if uplink != nil {
+		inboundLink.Writer = &limitWriter{
+			limiter: uplink,
+			writer:  inboundLink.Writer,
+		}
+	}
+	if downlink != nil {
+		outboundLink.Writer = &limitWriter{
+			limiter: downlink,
+			writer:  outboundLink.Writer,
+		}
+	}
+
 	return inboundLink, outboundLink
 }
 
@@ -194,6 +226,30 @@ func WrapLink(ctx context.Context, policyManager policy.Manager, statsManager st
 		user = sessionInbound.User
 	}
 
+	uplink, downlink := newLimiters(ctx, policyManager, statsManager)
+	if uplink != nil {
+		link.Reader = &limitReader{
+			limiter: uplink,
+			reader:  link.Reader,
+		}
+	}
+	if downlink != nil {
+		link.Writer = &limitWriter{
+			limiter: downlink,
+			writer:  link.Writer,
+		}
+	}
+	if t := accessTrafficFromContext(ctx); t != nil {
+		link.Reader = &sizeStatReader{
+			counter: &t.uplink,
+			reader:  link.Reader,
+		}
+		link.Writer = &sizeStatWriter{
+			counter: &t.downlink,
+			writer:  link.Writer,
+		}
+	}
+
 	link.Reader = &buf.TimeoutWrapperReader{Reader: link.Reader}
 
 	if user != nil && len(user.Email) > 0 {
@@ -263,6 +319,44 @@ func (d *DefaultDispatcher) shouldOverride(ctx context.Context, result SniffResu
 	return false
 }
 
+// startTrace starts the root span of the session in ctx if tracing is enabled
+// and the session is not traced yet.
+func (d *DefaultDispatcher) startTrace(ctx context.Context, destination net.Destination) (context.Context, session.Span) {
+	if d.tracer == nil || session.SpanFromContext(ctx) != nil {
+		return session.StartSpan(ctx, "dispatch")
+	}
+	span := d.tracer.StartTrace("session")
+	span.SetAttribute("destination", destination.String())
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		span.SetAttribute("inbound", inbound.Tag)
+		if inbound.User != nil && inbound.User.Email != "" {
+			span.SetAttribute("user", inbound.User.Email)
+		}
+		if inbound.Source.IsValid() {
+			span.SetAttribute("source", inbound.Source.String())
+		}
+	}
+	return session.ContextWithSpan(ctx, span), span
+}
+
+// sniffSession sniffs the session, recording the result in its span and its
+// access message.
+func sniffSession(ctx context.Context, cReader *cachedReader, metadataOnly bool, network net.Network) (SniffResult, error) {
+	_, span := session.StartSpan(ctx, "sniff")
+	result, err := sniffer(ctx, cReader, metadataOnly, network)
+	if err == nil {
+		span.SetAttribute("protocol", result.Protocol())
+		if domain := result.Domain(); domain != "" {
+			span.SetAttribute("domain", domain)
+			if accessMessage := log.AccessMessageFromContext(ctx); accessMessage != nil {
+				accessMessage.Domain = domain
+			}
+		}
+	}
+	span.End(err)
+	return result, err
+}
+
 // Dispatch implements routing.Dispatcher.
 func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destination) (*transport.Link, error) {
 	if !destination.IsValid() {
@@ -282,17 +376,24 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 		ctx = session.ContextWithContent(ctx, content)
 	}
 
+	ctx, span := d.startTrace(ctx, destination)
+	ctx = contextWithAccessTraffic(ctx)
+
 	sniffingRequest := content.SniffingRequest
 	inbound, outbound := d.getLink(ctx)
 	if !sniffingRequest.Enabled {
-		go d.routedDispatch(ctx, outbound, destination)
+		go func() {
+			d.routedDispatch(ctx, outbound, destination)
+			span.End(nil)
+		}()
 	} else {
 		go func() {
+			defer span.End(nil)
 			cReader := &cachedReader{
 				reader: outbound.Reader.(*pipe.Reader),
 			}
 			outbound.Reader = cReader
-			result, err := sniffer(ctx, cReader, sniffingRequest.MetadataOnly, destination.Network)
+			result, err := sniffSession(ctx, cReader, sniffingRequest.MetadataOnly, destination.Network)
 			if err == nil {
 				content.Protocol = result.Protocol()
 			}
@@ -338,6 +439,10 @@ func (d *DefaultDispatcher) DispatchLink(ctx context.Context, destination net.De
 		content = new(session.Content)
 		ctx = session.ContextWithContent(ctx, content)
 	}
+	ctx, span := d.startTrace(ctx, destination)
+	defer span.End(nil)
+	ctx = contextWithAccessTraffic(ctx)
+
 	outbound = WrapLink(ctx, d.policy, d.stats, outbound)
 	sniffingRequest := content.SniffingRequest
 	if !sniffingRequest.Enabled {
@@ -347,7 +452,7 @@ func (d *DefaultDispatcher) DispatchLink(ctx context.Context, destination net.De
 			reader: outbound.Reader.(buf.TimeoutReader),
 		}
 		outbound.Reader = cReader
-		result, err := sniffer(ctx, cReader, sniffingRequest.MetadataOnly, destination.Network)
+		result, err := sniffSession(ctx, cReader, sniffingRequest.MetadataOnly, destination.Network)
 		if err == nil {
 			content.Protocol = result.Protocol()
 		}
@@ -436,6 +541,7 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 	ob := outbounds[len(outbounds)-1]
 
 	var handler outbound.Handler
+	var route routing.Route
 
 	routingLink := routing_session.AsRoutingContext(ctx)
 	inTag := routingLink.GetInboundTag()
@@ -453,7 +559,21 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 			return
 		}
 	} else if d.router != nil {
-		if route, err := d.router.PickRoute(routingLink); err == nil {
+		_, span := session.StartSpan(ctx, "route")
+		r, err := d.router.PickRoute(routingLink)
+		if err == nil {
+			span.SetAttribute("outbound", r.GetOutboundTag())
+			if r.GetRuleTag() != "" {
+				span.SetAttribute("rule", r.GetRuleTag())
+			}
+		}
+		if err == common.ErrNoClue {
+			span.End(nil)
+		} else {
+			span.End(err)
+		}
+		if err == nil {
+			route = r
 			outTag := route.GetOutboundTag()
 			if h := d.ohm.GetHandler(outTag); h != nil {
 				isPickRoute = 2
@@ -486,7 +606,12 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 	}
 
 	ob.Tag = handler.Tag()
-	if accessMessage := log.AccessMessageFromContext(ctx); accessMessage != nil {
+	if route == nil {
+		route = &dispatchedRoute{Context: routingLink, outboundTag: handler.Tag()}
+	}
+	publishRoute(d.stats, route)
+	accessMessage := log.AccessMessageFromContext(ctx)
+	if accessMessage != nil {
 		if tag := handler.Tag(); tag != "" {
 			if inTag == "" {
 				accessMessage.Detour = tag
@@ -498,8 +623,19 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 				accessMessage.Detour = inTag + " >> " + tag
 			}
 		}
+		accessMessage.InboundTag = inTag
+		accessMessage.OutboundTag = handler.Tag()
+		accessMessage.Phase = log.AccessOpen
 		log.Record(accessMessage)
 	}
 
+	if sessionInbound := session.InboundFromContext(ctx); sessionInbound != nil && sessionInbound.User != nil && len(sessionInbound.User.Email) > 0 {
+		defer d.sessions.add(sessionInbound.User.Email, link)()
+	}
+
 	handler.Dispatch(ctx, link)
+
+	if accessMessage != nil {
+		recordAccessClose(ctx, accessMessage)
+	}
 }
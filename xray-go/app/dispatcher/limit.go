@@ -0,0 +1,143 @@
+package dispatcher
+
+import (
+	"context"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/policy"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// trafficLimit is a limit of a user or an inbound on one direction of traffic.
+type trafficLimit struct {
+	name  string
+	rate  policy.RateLimiter
+	quota int64
+	used  stats.Counter
+}
+
+// limiter enforces the limits of a connection on one direction of traffic.
+type limiter struct {
+	ctx    context.Context
+	limits []trafficLimit
+}
+
+// take accounts n bytes, and blocks until they may pass.
+func (l *limiter) take(n int32) error {
+	for _, limit := range l.limits {
+		if limit.used != nil {
+			limit.used.Add(int64(n))
+			if limit.used.Value() > limit.quota {
+				return errors.New("traffic quota of ", limit.name, " is exceeded")
+			}
+		}
+		if limit.rate != nil {
+			if err := limit.rate.WaitN(l.ctx, int(n)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// newLimiters returns the limiters of uplink and downlink traffic for the user
+// and the inbound of the connection in ctx, which are nil without limits.
+// Rates are shared by all connections of the user or the inbound, and quotas
+// are counted by stats counters named like "user>>>[email]>>>quota>>>used".
+// Splice copy is disabled for limited connections, as it bypasses both.
+func newLimiters(ctx context.Context, pm policy.Manager, sm stats.Manager) (*limiter, *limiter) {
+	lm, ok := pm.(policy.RateLimiterManager)
+	sessionInbound := session.InboundFromContext(ctx)
+	if !ok || sessionInbound == nil {
+		return nil, nil
+	}
+
+	uplink := &limiter{ctx: ctx}
+	downlink := &limiter{ctx: ctx}
+	add := func(name string, limit policy.Limit) {
+		var used stats.Counter
+		if limit.Quota > 0 && sm != nil {
+			used, _ = sm.GetOrRegisterCounter(name + ">>>quota>>>used")
+		}
+		if limit.UplinkRate > 0 || used != nil {
+			l := trafficLimit{name: name, quota: int64(limit.Quota), used: used}
+			if limit.UplinkRate > 0 {
+				l.rate = lm.GetOrRegisterRateLimiter(name+">>>uplink", limit.UplinkRate)
+			}
+			uplink.limits = append(uplink.limits, l)
+		}
+		if limit.DownlinkRate > 0 || used != nil {
+			l := trafficLimit{name: name, quota: int64(limit.Quota), used: used}
+			if limit.DownlinkRate > 0 {
+				l.rate = lm.GetOrRegisterRateLimiter(name+">>>downlink", limit.DownlinkRate)
+			}
+			downlink.limits = append(downlink.limits, l)
+		}
+	}
+
+	if user := sessionInbound.User; user != nil && len(user.Email) > 0 {
+		add("user>>>"+user.Email, pm.ForLevel(user.Level).Limit)
+	}
+	if len(sessionInbound.Tag) > 0 {
+		if limit, found := pm.ForSystem().InboundLimits[sessionInbound.Tag]; found {
+			add("inbound>>>"+sessionInbound.Tag, limit)
+		}
+	}
+
+	if len(uplink.limits) == 0 {
+		uplink = nil
+	}
+	if len(downlink.limits) == 0 {
+		downlink = nil
+	}
+	if uplink != nil || downlink != nil {
+		sessionInbound.CanSpliceCopy = 3
+	}
+	return uplink, downlink
+}
+
+// limitWriter is a buf.Writer enforcing traffic limits.
+type limitWriter struct {
+	limiter *limiter
+	writer  buf.Writer
+}
+
+func (w *limitWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if err := w.limiter.take(mb.Len()); err != nil {
+		buf.ReleaseMulti(mb)
+		return err
+	}
+	return w.writer.WriteMultiBuffer(mb)
+}
+
+func (w *limitWriter) Close() error {
+	return common.Close(w.writer)
+}
+
+func (w *limitWriter) Interrupt() {
+	common.Interrupt(w.writer)
+}
+
+// limitReader is a buf.Reader enforcing traffic limits.
+type limitReader struct {
+	limiter *limiter
+	reader  buf.Reader
+}
+
+func (r *limitReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb, err := r.reader.ReadMultiBuffer()
+	if !mb.IsEmpty() {
+		if err := r.limiter.take(mb.Len()); err != nil {
+			buf.ReleaseMulti(mb)
+			return nil, err
+		}
+	}
+	return mb, err
+}
+
+func (r *limitReader) Interrupt() {
+	common.Interrupt(r.reader)
+}
@@ -0,0 +1,144 @@
+package dispatcher_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal"
+	"github.com/xtls/xray-core/proxy"
+	"github.com/xtls/xray-core/transport"
+)
+
+func TestLimitQuota(t *testing.T) {
+	pm, err := policy.New(context.Background(), &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			0: {Limit: &policy.Policy_Limit{Quota: 10}},
+		},
+	})
+	common.Must(err)
+	sm, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+		User: &protocol.MemoryUser{Email: "test"},
+	})
+	link := WrapLink(ctx, pm, sm, &transport.Link{Reader: buf.NewReader(strings.NewReader("")), Writer: buf.Discard})
+
+	common.Must(link.Writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("abcdefgh"))))
+	if err := link.Writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("abcdefgh"))); err == nil {
+		t.Error("expected error when quota is exceeded")
+	}
+	if c := sm.GetCounter("user>>>test>>>quota>>>used"); c == nil || c.Value() != 16 {
+		t.Error("unexpected quota counter ", c)
+	}
+
+	// Resetting the counter, as through the stats service, restores the quota.
+	sm.GetCounter("user>>>test>>>quota>>>used").Set(0)
+	common.Must(link.Writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("abcdefgh"))))
+}
+
+func TestLimitInboundRate(t *testing.T) {
+	pm, err := policy.New(context.Background(), &policy.Config{
+		System: &policy.SystemPolicy{
+			InboundLimit: map[string]*policy.Policy_Limit{
+				"in": {DownlinkRate: 64 * 1024},
+			},
+		},
+	})
+	common.Must(err)
+
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{Tag: "in"})
+	payload := make([]byte, 32*1024)
+	start := time.Now()
+	// The rate is shared by both connections of the inbound.
+	for i := 0; i < 2; i++ {
+		link := WrapLink(ctx, pm, nil, &transport.Link{Reader: buf.NewReader(strings.NewReader("")), Writer: buf.Discard})
+		for j := 0; j < 2; j++ {
+			common.Must(link.Writer.WriteMultiBuffer(buf.MergeBytes(nil, payload)))
+		}
+	}
+	// The first second is allowed as a burst, and the rest takes time.
+	if elapsed := time.Since(start); elapsed < 800*time.Millisecond {
+		t.Error("expected 128KiB at 64KiB/s to take about a second, but took ", elapsed)
+	}
+
+	// Other inbounds are not limited.
+	ctx = session.ContextWithInbound(context.Background(), &session.Inbound{Tag: "other"})
+	link := WrapLink(ctx, pm, nil, &transport.Link{Reader: buf.NewReader(strings.NewReader("")), Writer: buf.Discard})
+	start = time.Now()
+	common.Must(link.Writer.WriteMultiBuffer(buf.MergeBytes(nil, payload)))
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Error("expected no limit on other inbounds, but took ", elapsed)
+	}
+}
+
+func TestLimitSpliceCopy(t *testing.T) {
+	pm, err := policy.New(context.Background(), &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			0: {
+				Stats: &policy.Policy_Stats{UserDownlink: true},
+				Limit: &policy.Policy_Limit{Quota: 1024},
+			},
+		},
+	})
+	common.Must(err)
+	sm, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+
+	inbound := &session.Inbound{
+		User:          &protocol.MemoryUser{Email: "test"},
+		CanSpliceCopy: 1,
+	}
+	ctx := session.ContextWithInbound(context.Background(), inbound)
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{CanSpliceCopy: 1}})
+	link := WrapLink(ctx, pm, sm, &transport.Link{Reader: buf.NewReader(strings.NewReader("")), Writer: buf.Discard})
+	if inbound.CanSpliceCopy != 3 {
+		t.Error("expected splice copy to be disabled, but got ", inbound.CanSpliceCopy)
+	}
+
+	common.Must(copyRawConn(ctx, []byte("abcdefgh"), link.Writer))
+
+	for _, name := range []string{"user>>>test>>>traffic>>>downlink", "user>>>test>>>quota>>>used"} {
+		if c := sm.GetCounter(name); c == nil || c.Value() != 8 {
+			t.Error("unexpected counter ", name, ": ", c)
+		}
+	}
+}
+
+// copyRawConn copies payload from a TCP connection to another one with
+// proxy.CopyRawConnIfExist, which splices them if ctx allows.
+func copyRawConn(ctx context.Context, payload []byte, writer buf.Writer) error {
+	tcpPair := func() (net.Conn, net.Conn) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		common.Must(err)
+		defer l.Close()
+		client, err := net.Dial("tcp", l.Addr().String())
+		common.Must(err)
+		server, err := l.Accept()
+		common.Must(err)
+		return client, server
+	}
+	source, readerConn := tcpPair()
+	defer readerConn.Close()
+	writerConn, sink := tcpPair()
+	defer writerConn.Close()
+	defer sink.Close()
+
+	common.Must2(source.Write(payload))
+	common.Must(source.Close())
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timer := signal.CancelAfterInactivity(ctx, cancel, time.Minute)
+	return proxy.CopyRawConnIfExist(ctx, readerConn, writerConn, writer, timer, nil)
+}
@@ -0,0 +1,61 @@
+package dispatcher
+
+import (
+	"sync"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/transport"
+)
+
+// userSessions keeps the links of the sessions dispatched for users, so that
+// they can be closed when a user is suspended.
+type userSessions struct {
+	sync.Mutex
+	links map[string]map[*transport.Link]struct{}
+}
+
+// add records link as a session of the user, until the returned function is
+// called.
+func (s *userSessions) add(email string, link *transport.Link) func() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.links == nil {
+		s.links = make(map[string]map[*transport.Link]struct{})
+	}
+	if s.links[email] == nil {
+		s.links[email] = make(map[*transport.Link]struct{})
+	}
+	s.links[email][link] = struct{}{}
+
+	return func() {
+		s.Lock()
+		defer s.Unlock()
+
+		delete(s.links[email], link)
+		if len(s.links[email]) == 0 {
+			delete(s.links, email)
+		}
+	}
+}
+
+// close interrupts the sessions of the user, and returns their number.
+func (s *userSessions) close(email string) int {
+	s.Lock()
+	links := make([]*transport.Link, 0, len(s.links[email]))
+	for link := range s.links[email] {
+		links = append(links, link)
+	}
+	s.Unlock()
+
+	for _, link := range links {
+		common.Interrupt(link.Reader)
+		common.Interrupt(link.Writer)
+	}
+	return len(links)
+}
+
+// CloseUserSessions implements routing.UserSessionCloser.
+func (d *DefaultDispatcher) CloseUserSessions(email string) int {
+	return d.sessions.close(email)
+}
@@ -0,0 +1,96 @@
+package dispatcher_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/testing/mocks"
+	"github.com/xtls/xray-core/transport"
+)
+
+// blockingHandler is an outbound handler that reads its link until it fails.
+type blockingHandler struct {
+	started chan struct{}
+	done    chan struct{}
+}
+
+func (h *blockingHandler) Start() error                         { return nil }
+func (h *blockingHandler) Close() error                         { return nil }
+func (h *blockingHandler) Tag() string                          { return "blocking" }
+func (h *blockingHandler) SenderSettings() *serial.TypedMessage { return nil }
+func (h *blockingHandler) ProxySettings() *serial.TypedMessage  { return nil }
+
+func (h *blockingHandler) Dispatch(ctx context.Context, link *transport.Link) {
+	defer close(h.done)
+	close(h.started)
+	for {
+		mb, err := link.Reader.ReadMultiBuffer()
+		buf.ReleaseMulti(mb)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func TestCloseUserSessions(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	handler := &blockingHandler{
+		started: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	ohm := mocks.NewOutboundManager(mockCtl)
+	ohm.EXPECT().GetDefaultHandler().Return(handler)
+
+	pm, err := policy.New(context.Background(), &policy.Config{})
+	common.Must(err)
+	sm, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+	d := new(DefaultDispatcher)
+	common.Must(d.Init(&Config{}, ohm, nil, pm, sm))
+
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+		User: &protocol.MemoryUser{Email: "test"},
+	})
+	link, err := d.Dispatch(ctx, net.TCPDestination(net.LocalHostIP, 80))
+	common.Must(err)
+	<-handler.started
+
+	if n := d.CloseUserSessions("other"); n != 0 {
+		t.Error("expected no session of other user, but got ", n)
+	}
+	if n := d.CloseUserSessions("test"); n != 1 {
+		t.Error("expected 1 session, but got ", n)
+	}
+	select {
+	case <-handler.done:
+	case <-time.After(time.Second):
+		t.Fatal("session not closed")
+	}
+	b := buf.New()
+	b.WriteString("test")
+	if err := link.Writer.WriteMultiBuffer(buf.MultiBuffer{b}); err == nil {
+		t.Error("expected inbound link closed")
+	}
+
+	// The session is forgotten once it ends.
+	for range 10 {
+		if d.CloseUserSessions("test") == 0 {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Error("expected no session")
+}
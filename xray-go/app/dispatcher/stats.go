@@ -1,8 +1,11 @@
 package dispatcher
 
 import (
+	"context"
+
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/features/routing"
 	"github.com/xtls/xray-core/features/stats"
 )
 
@@ -23,3 +26,46 @@ func (w *SizeStatWriter) Close() error {
 func (w *SizeStatWriter) Interrupt() {
 	common.Interrupt(w.Writer)
 }
+
+// dispatchedRoute is the route of a connection that was not routed by the
+// router, such as one taking the default outbound.
+type dispatchedRoute struct {
+	routing.Context
+	outboundTag string
+}
+
+// GetOutboundGroupTags implements routing.Route.
+func (r *dispatchedRoute) GetOutboundGroupTags() []string {
+	return nil
+}
+
+// GetOutboundTag implements routing.Route.
+func (r *dispatchedRoute) GetOutboundTag() string {
+	return r.outboundTag
+}
+
+// GetRuleTag implements routing.Route.
+func (r *dispatchedRoute) GetRuleTag() string {
+	return ""
+}
+
+// doneContext is already canceled, so that a route published to a full stats
+// channel is dropped rather than holding up the connection.
+var doneContext = func() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}()
+
+// publishRoute publishes route to the routing stats channel, if anyone is
+// subscribed to it.
+func publishRoute(sm stats.Manager, route routing.Route) {
+	if sm == nil {
+		return
+	}
+	c := sm.GetChannel(routing.StatsChannel)
+	if c == nil || len(c.Subscribers()) == 0 {
+		return
+	}
+	c.Publish(doneContext, route)
+}
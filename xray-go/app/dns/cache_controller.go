@@ -5,6 +5,7 @@ import (
 	go_errors "errors"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xtls/xray-core/common"
@@ -32,12 +33,17 @@ type CacheController struct {
 
 	ips      map[string]*record
 	dirtyips map[string]*record
+	answers  map[answersKey]*answersRecord
 
 	sync.RWMutex
-	pub           *pubsub.Service
-	cacheCleanup  *task.Periodic
-	highWatermark int
-	requestGroup  singleflight.Group
+	pub            *pubsub.Service
+	cacheCleanup   *task.Periodic
+	answersCleanup *task.Periodic
+	highWatermark  int
+	requestGroup   singleflight.Group
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
 }
 
 func NewCacheController(name string, disableCache bool, serveStale bool, serveExpiredTTL uint32) *CacheController {
@@ -47,6 +53,7 @@ func NewCacheController(name string, disableCache bool, serveStale bool, serveEx
 		serveStale:      serveStale,
 		serveExpiredTTL: -int32(serveExpiredTTL),
 		ips:             make(map[string]*record),
+		answers:         make(map[answersKey]*answersRecord),
 		pub:             pubsub.NewService(),
 	}
 
@@ -54,9 +61,18 @@ func NewCacheController(name string, disableCache bool, serveStale bool, serveEx
 		Interval: 300 * time.Second,
 		Execute:  c.CacheCleanup,
 	}
+	c.answersCleanup = &task.Periodic{
+		Interval: 300 * time.Second,
+		Execute:  c.cleanupAnswers,
+	}
 	return c
 }
 
+// Close implements common.Closable. It stops clearing expired items.
+func (c *CacheController) Close() error {
+	return errors.Combine(c.cacheCleanup.Close(), c.answersCleanup.Close())
+}
+
 // CacheCleanup clears expired items from cache
 func (c *CacheController) CacheCleanup() error {
 	expiredKeys, err := c.collectExpiredKeys()
@@ -0,0 +1,223 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+	"github.com/xtls/xray-core/common/task"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const defaultCacheSaveInterval = 300 * time.Second
+
+// cachedIPRecord is an IPRecord saved in the cache file.
+type cachedIPRecord struct {
+	IP     []net.IP         `json:"ip"`
+	Expire time.Time        `json:"expire"`
+	RCode  dnsmessage.RCode `json:"rcode"`
+}
+
+// cachedRecord is a record saved in the cache file.
+type cachedRecord struct {
+	A    *cachedIPRecord `json:"a,omitempty"`
+	AAAA *cachedIPRecord `json:"aaaa,omitempty"`
+}
+
+// cacheFile saves the caches of name servers to a JSON file, by the names of
+// the servers.
+type cacheFile struct {
+	file     string
+	interval time.Duration
+	saver    *task.Periodic
+
+	access sync.Mutex
+	saved  []byte
+}
+
+func newCacheFile(file string, interval uint32) *cacheFile {
+	f := &cacheFile{
+		file:     file,
+		interval: time.Duration(interval) * time.Second,
+	}
+	if f.interval <= 0 {
+		f.interval = defaultCacheSaveInterval
+	}
+	return f
+}
+
+// start saves the caches of the name servers of s periodically, until close
+// is called.
+func (f *cacheFile) start(s *DNS) error {
+	f.saver = &task.Periodic{
+		Interval: f.interval,
+		Execute: func() error {
+			if err := f.save(s.snapshot().clients); err != nil {
+				errors.LogWarningInner(context.Background(), err, "failed to save DNS cache to ", f.file)
+			}
+			return nil
+		},
+	}
+	return f.saver.Start()
+}
+
+func (f *cacheFile) close() {
+	if f.saver != nil {
+		f.saver.Close()
+	}
+}
+
+func cacheControllers(clients []*Client) []*CacheController {
+	var caches []*CacheController
+	for _, client := range clients {
+		cached, ok := client.server.(CachedNameserver)
+		if !ok {
+			continue
+		}
+		if cache := cached.getCacheController(); !cache.disableCache {
+			caches = append(caches, cache)
+		}
+	}
+	return caches
+}
+
+// restore puts the records saved in the file into the caches of clients.
+func (f *cacheFile) restore(clients []*Client) error {
+	b, err := os.ReadFile(f.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var servers map[string]map[string]*cachedRecord
+	if err := json.Unmarshal(b, &servers); err != nil {
+		return err
+	}
+	for _, cache := range cacheControllers(clients) {
+		if records, found := servers[cache.name]; found {
+			cache.restore(records)
+		}
+	}
+
+	f.access.Lock()
+	f.saved = b
+	f.access.Unlock()
+	return nil
+}
+
+// save writes the records in the caches of clients to the file.
+func (f *cacheFile) save(clients []*Client) error {
+	f.access.Lock()
+	defer f.access.Unlock()
+
+	servers := make(map[string]map[string]*cachedRecord)
+	for _, cache := range cacheControllers(clients) {
+		records := servers[cache.name]
+		if records == nil {
+			records = make(map[string]*cachedRecord)
+			servers[cache.name] = records
+		}
+		cache.snapshot(records)
+	}
+	b, err := json.Marshal(servers)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(b, f.saved) {
+		return nil
+	}
+
+	if err := filesystem.WriteFileAtomic(f.file, b); err != nil {
+		return err
+	}
+	f.saved = b
+	return nil
+}
+
+// servable returns whether r can be served at now, fresh or stale.
+func (c *CacheController) servable(r *IPRecord, now time.Time) bool {
+	if r == nil {
+		return false
+	}
+	if r.Expire.After(now) {
+		return true
+	}
+	if !c.serveStale {
+		return false
+	}
+	return c.serveExpiredTTL == 0 || r.Expire.After(now.Add(time.Duration(c.serveExpiredTTL)*time.Second))
+}
+
+// snapshot adds the servable records in the cache to records.
+func (c *CacheController) snapshot(records map[string]*cachedRecord) {
+	c.RLock()
+	defer c.RUnlock()
+
+	now := time.Now()
+	add := func(ips map[string]*record) {
+		for domain, rec := range ips {
+			saved := records[domain]
+			if saved == nil {
+				saved = &cachedRecord{}
+			}
+			if saved.A == nil && c.servable(rec.A, now) {
+				saved.A = &cachedIPRecord{IP: rec.A.IP, Expire: rec.A.Expire, RCode: rec.A.RCode}
+			}
+			if saved.AAAA == nil && c.servable(rec.AAAA, now) {
+				saved.AAAA = &cachedIPRecord{IP: rec.AAAA.IP, Expire: rec.AAAA.Expire, RCode: rec.AAAA.RCode}
+			}
+			if saved.A != nil || saved.AAAA != nil {
+				records[domain] = saved
+			}
+		}
+	}
+	add(c.ips)
+	if c.dirtyips != nil {
+		add(c.dirtyips)
+	}
+}
+
+// restore puts the servable records into the cache, unless it has records of
+// their domains already.
+func (c *CacheController) restore(records map[string]*cachedRecord) {
+	now := time.Now()
+	toIPRecord := func(r *cachedIPRecord) *IPRecord {
+		if r == nil {
+			return nil
+		}
+		rec := &IPRecord{IP: r.IP, Expire: r.Expire, RCode: r.RCode}
+		if !c.servable(rec, now) {
+			return nil
+		}
+		return rec
+	}
+
+	c.Lock()
+	restored := 0
+	for domain, saved := range records {
+		if c.ips[domain] != nil {
+			continue
+		}
+		rec := &record{A: toIPRecord(saved.A), AAAA: toIPRecord(saved.AAAA)}
+		if rec.A == nil && rec.AAAA == nil {
+			continue
+		}
+		c.ips[domain] = rec
+		restored++
+	}
+	c.Unlock()
+
+	errors.LogDebug(context.Background(), c.name, " restored ", restored, " records to cache")
+	if restored > 0 && (!c.serveStale || c.serveExpiredTTL != 0) {
+		common.Must(c.cacheCleanup.Start())
+	}
+}
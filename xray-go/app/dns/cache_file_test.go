@@ -0,0 +1,152 @@
+package dns_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	"github.com/xtls/xray-core/app/dispatcher"
+	. "github.com/xtls/xray-core/app/dns"
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/proxyman"
+	_ "github.com/xtls/xray-core/app/proxyman/inbound"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	feature_dns "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/testing/servers/udp"
+)
+
+func newCacheFileConfig(port net.Port, cacheFile string) *core.Config {
+	return &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{127, 0, 0, 1},
+								},
+							},
+							Port: uint32(port),
+						},
+						TimeoutMs: 1000,
+					},
+				},
+				CacheFile: cacheFile,
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{
+					FinalRules: []*freedom.FinalRuleConfig{{Action: freedom.RuleAction_Allow}},
+				}),
+			},
+		},
+	}
+}
+
+func newCacheFileInstance(port net.Port, cacheFile string) *core.Instance {
+	v, err := core.New(newCacheFileConfig(port, cacheFile))
+	common.Must(err)
+	common.Must(v.Start())
+	return v
+}
+
+func TestCacheFile(t *testing.T) {
+	port := udp.PickPort()
+	cacheFile := filepath.Join(t.TempDir(), "dns_cache.json")
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	v := newCacheFileInstance(port, cacheFile)
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+	ips, _, err := client.LookupIP("google.com", feature_dns.IPOption{
+		IPv4Enable: true,
+	})
+	common.Must(err)
+	if r := cmp.Diff(ips, []net.IP{{8, 8, 8, 8}}); r != "" {
+		t.Fatal(r)
+	}
+	common.Must(v.Close())
+
+	b, err := os.ReadFile(cacheFile)
+	common.Must(err)
+	if !strings.Contains(string(b), `"google.com."`) {
+		t.Fatal("expected google.com. in cache file, but actually ", string(b))
+	}
+
+	// Without the name server, records are only found in the restored cache.
+	common.Must(dnsServer.Shutdown())
+
+	v = newCacheFileInstance(port, cacheFile)
+	defer v.Close()
+	client = v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+	ips, ttl, err := client.LookupIP("google.com", feature_dns.IPOption{
+		IPv4Enable: true,
+	})
+	common.Must(err)
+	if r := cmp.Diff(ips, []net.IP{{8, 8, 8, 8}}); r != "" {
+		t.Fatal(r)
+	}
+	if ttl == 0 || ttl > 3600 {
+		t.Error("unexpected remaining TTL: ", ttl)
+	}
+	if _, _, err := client.LookupIP("facebook.com", feature_dns.IPOption{
+		IPv4Enable: true,
+	}); err == nil {
+		t.Error("expected error of facebook.com not in cache")
+	}
+}
+
+func TestCacheFileReload(t *testing.T) {
+	port := udp.PickPort()
+	cacheFile := filepath.Join(t.TempDir(), "dns_cache.json")
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+	defer dnsServer.Shutdown()
+
+	v := newCacheFileInstance(port, "")
+	defer v.Close()
+
+	// The cache file added by the reload is saved on close.
+	common.Must(v.Reload(newCacheFileConfig(port, cacheFile)))
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+	_, _, err := client.LookupIP("google.com", feature_dns.IPOption{
+		IPv4Enable: true,
+	})
+	common.Must(err)
+	common.Must(v.Close())
+
+	b, err := os.ReadFile(cacheFile)
+	common.Must(err)
+	if !strings.Contains(string(b), `"google.com."`) {
+		t.Fatal("expected google.com. in cache file, but actually ", string(b))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	go_errors "errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -16,11 +17,13 @@ import (
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/common/utils"
 	"github.com/xtls/xray-core/features/dns"
+	"golang.org/x/net/dns/dnsmessage"
+	"google.golang.org/protobuf/proto"
 )
 
 // DNS is a DNS rely server.
 type DNS struct {
-	sync.Mutex
+	sync.RWMutex
 	disableFallback        bool
 	disableFallbackIfMatch bool
 	enableParallelQuery    bool
@@ -31,6 +34,7 @@ type DNS struct {
 	domainMatcher          geodata.DomainMatcher
 	matcherInfos           []*DomainMatcherInfo
 	checkSystem            bool
+	cacheFile              *cacheFile
 }
 
 // DomainMatcherInfo contains information attached to index returned by Server.domainMatcher.
@@ -169,7 +173,7 @@ func New(ctx context.Context, config *Config) (*DNS, error) {
 		clients = append(clients, NewLocalDNSClient(ipOption))
 	}
 
-	return &DNS{
+	s := &DNS{
 		hosts:                  hosts,
 		ipOption:               &ipOption,
 		clients:                clients,
@@ -180,7 +184,11 @@ func New(ctx context.Context, config *Config) (*DNS, error) {
 		disableFallbackIfMatch: config.DisableFallbackIfMatch,
 		enableParallelQuery:    config.EnableParallelQuery,
 		checkSystem:            checkSystem,
-	}, nil
+	}
+	if config.CacheFile != "" {
+		s.cacheFile = newCacheFile(config.CacheFile, config.CacheSaveInterval)
+	}
+	return s, nil
 }
 
 // Type implements common.HasType.
@@ -190,16 +198,108 @@ func (*DNS) Type() interface{} {
 
 // Start implements common.Runnable.
 func (s *DNS) Start() error {
-	return nil
+	if s.cacheFile == nil {
+		return nil
+	}
+	if err := s.cacheFile.restore(s.snapshot().clients); err != nil {
+		errors.LogWarningInner(s.ctx, err, "failed to restore DNS cache from ", s.cacheFile.file)
+	}
+	return s.cacheFile.start(s)
 }
 
 // Close implements common.Closable.
 func (s *DNS) Close() error {
+	state := s.snapshot()
+	var errs []error
+	if state.cacheFile != nil {
+		state.cacheFile.close()
+		if err := state.cacheFile.save(state.clients); err != nil {
+			errs = append(errs, errors.New("failed to save DNS cache to ", state.cacheFile.file).Base(err))
+		}
+	}
+	for _, client := range state.clients {
+		errs = append(errs, client.Close())
+	}
+	return errors.Combine(errs...)
+}
+
+// Reload implements features.Reloadable. It replaces the name servers, hosts
+// and query options with those of config.
+func (s *DNS) Reload(config proto.Message) error {
+	c, ok := config.(*Config)
+	if !ok {
+		return errors.New("Reload: config type error")
+	}
+	n, err := New(s.ctx, c)
+	if err != nil {
+		return err
+	}
+
+	// The caches of the new name servers start with the records of the old
+	// ones, through the cache file.
+	old := s.snapshot()
+	if old.cacheFile != nil {
+		if err := old.cacheFile.save(old.clients); err != nil {
+			errors.LogWarningInner(s.ctx, err, "failed to save DNS cache to ", old.cacheFile.file)
+		}
+	}
+	if n.cacheFile != nil {
+		if err := n.cacheFile.restore(n.clients); err != nil {
+			errors.LogWarningInner(s.ctx, err, "failed to restore DNS cache from ", n.cacheFile.file)
+		}
+	}
+
+	s.Lock()
+	s.setState(n)
+	s.Unlock()
+
+	if old.cacheFile != nil {
+		old.cacheFile.close()
+	}
+	if n.cacheFile != nil {
+		if err := n.cacheFile.start(s); err != nil {
+			errors.LogWarningInner(n.ctx, err, "failed to save DNS cache to ", n.cacheFile.file)
+		}
+	}
+	for _, client := range old.clients {
+		if err := client.Close(); err != nil {
+			errors.LogWarningInner(n.ctx, err, "failed to close name server ", client.Name())
+		}
+	}
 	return nil
 }
 
+// setState copies the fields that are replaced by Reload from n.
+func (s *DNS) setState(n *DNS) {
+	s.disableFallback = n.disableFallback
+	s.disableFallbackIfMatch = n.disableFallbackIfMatch
+	s.enableParallelQuery = n.enableParallelQuery
+	s.ipOption = n.ipOption
+	s.hosts = n.hosts
+	s.clients = n.clients
+	s.ctx = n.ctx
+	s.domainMatcher = n.domainMatcher
+	s.matcherInfos = n.matcherInfos
+	s.checkSystem = n.checkSystem
+	s.cacheFile = n.cacheFile
+}
+
+// snapshot returns a copy of s that is not affected by a later Reload, so
+// that a lookup in progress does not need to hold the lock.
+func (s *DNS) snapshot() *DNS {
+	s.RLock()
+	defer s.RUnlock()
+
+	n := new(DNS)
+	n.setState(s)
+	return n
+}
+
 // IsOwnLink implements proxy.dns.ownLinkVerifier
 func (s *DNS) IsOwnLink(ctx context.Context) bool {
+	s.RLock()
+	defer s.RUnlock()
+
 	inbound := session.InboundFromContext(ctx)
 	if inbound == nil {
 		return false
@@ -212,8 +312,44 @@ func (s *DNS) IsOwnLink(ctx context.Context) bool {
 	return false
 }
 
+// CacheStats is the statistics of the caches of name servers with a name.
+type CacheStats struct {
+	Server string
+	Hits   uint64
+	Misses uint64
+}
+
+// CacheStats returns the statistics of the caches of all name servers.
+func (s *DNS) CacheStats() []CacheStats {
+	s.RLock()
+	defer s.RUnlock()
+
+	var stats []CacheStats
+	index := make(map[string]int)
+	for _, client := range s.clients {
+		cached, ok := client.server.(CachedNameserver)
+		if !ok {
+			continue
+		}
+		cache := cached.getCacheController()
+		i, found := index[cache.name]
+		if !found {
+			i = len(stats)
+			index[cache.name] = i
+			stats = append(stats, CacheStats{Server: cache.name})
+		}
+		stats[i].Hits += cache.hits.Load()
+		stats[i].Misses += cache.misses.Load()
+	}
+	return stats
+}
+
 // LookupIP implements dns.Client.
 func (s *DNS) LookupIP(domain string, option dns.IPOption) ([]net.IP, uint32, error) {
+	return s.snapshot().lookupIP(domain, option)
+}
+
+func (s *DNS) lookupIP(domain string, option dns.IPOption) ([]net.IP, uint32, error) {
 	// Normalize the FQDN form query
 	domain = strings.TrimSuffix(domain, ".")
 	if domain == "" {
@@ -264,6 +400,140 @@ func (s *DNS) LookupIP(domain string, option dns.IPOption) ([]net.IP, uint32, er
 	}
 }
 
+// LookupRecords implements dns.RecordClient.
+func (s *DNS) LookupRecords(domain string, qType dnsmessage.Type) ([]dnsmessage.Resource, uint32, error) {
+	return s.snapshot().lookupRecords(domain, qType)
+}
+
+func (s *DNS) lookupRecords(domain string, qType dnsmessage.Type) ([]dnsmessage.Resource, uint32, error) {
+	// Normalize the FQDN form query
+	domain = strings.TrimSuffix(domain, ".")
+	if domain == "" {
+		return nil, 0, errors.New("empty domain name")
+	}
+
+	// A and AAAA records are made of the IPs looked up, for the static hosts and query strategy
+	switch qType {
+	case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+		ips, ttl, err := s.lookupIP(domain, dns.IPOption{
+			IPv4Enable: qType == dnsmessage.TypeA,
+			IPv6Enable: qType == dnsmessage.TypeAAAA,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		answers, err := ipRecords(domain, qType, ips, ttl)
+		if err != nil {
+			return nil, 0, err
+		}
+		return answers, ttl, nil
+	}
+
+	// Static host domain replacement, answered with a CNAME record
+	var cname *dnsmessage.Resource
+	if addrs, _ := s.hosts.Lookup(domain, dns.IPOption{IPv4Enable: true, IPv6Enable: true}); len(addrs) == 1 && addrs[0].Family().IsDomain() {
+		errors.LogInfo(s.ctx, "domain replaced: ", domain, " -> ", addrs[0].Domain())
+		name, err := dnsmessage.NewName(Fqdn(domain))
+		if err != nil {
+			return nil, 0, err
+		}
+		target, err := dnsmessage.NewName(Fqdn(addrs[0].Domain()))
+		if err != nil {
+			return nil, 0, err
+		}
+		cname = &dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET, TTL: 10},
+			Body:   &dnsmessage.CNAMEResource{CNAME: target},
+		}
+		domain = addrs[0].Domain()
+	}
+
+	// Name servers lookup, in serial query mode only. If FakeDNS comes before
+	// the name server answering, the domain is resolved to fake IPs, and the IP
+	// hints must not lead clients to the real ones.
+	var errs []error
+	fake := false
+	for _, client := range s.sortClients(domain) {
+		if strings.EqualFold(client.Name(), "FakeDNS") {
+			fake = true
+		}
+		if _, ok := client.server.(RecordServer); !ok {
+			errors.LogDebug(s.ctx, "skip ", qType, " query for domain ", domain, " at server ", client.Name())
+			continue
+		}
+
+		answers, ttl, err := client.QueryRecords(s.ctx, domain, qType)
+
+		if len(answers) > 0 {
+			if fake {
+				answers = withoutIPHints(answers)
+			}
+			if cname != nil {
+				answers = append([]dnsmessage.Resource{*cname}, answers...)
+			}
+			return answers, ttl, nil
+		}
+
+		errors.LogInfoInner(s.ctx, err, "failed to lookup ", qType, " records for domain ", domain, " at server ", client.Name())
+		if err == nil {
+			err = dns.ErrEmptyResponse
+		}
+		errs = append(errs, err)
+	}
+	return nil, 0, mergeQueryErrors(domain, errs)
+}
+
+// ipRecords returns the records of qType, A or AAAA, of ips for domain.
+func ipRecords(domain string, qType dnsmessage.Type, ips []net.IP, ttl uint32) ([]dnsmessage.Resource, error) {
+	name, err := dnsmessage.NewName(Fqdn(domain))
+	if err != nil {
+		return nil, err
+	}
+	header := dnsmessage.ResourceHeader{Name: name, Type: qType, Class: dnsmessage.ClassINET, TTL: ttl}
+	answers := make([]dnsmessage.Resource, 0, len(ips))
+	for _, ip := range ips {
+		switch qType {
+		case dnsmessage.TypeA:
+			if ip = ip.To4(); len(ip) == net.IPv4len {
+				var r dnsmessage.AResource
+				copy(r.A[:], ip)
+				answers = append(answers, dnsmessage.Resource{Header: header, Body: &r})
+			}
+		case dnsmessage.TypeAAAA:
+			if ip = ip.To16(); len(ip) == net.IPv6len {
+				var r dnsmessage.AAAAResource
+				copy(r.AAAA[:], ip)
+				answers = append(answers, dnsmessage.Resource{Header: header, Body: &r})
+			}
+		}
+	}
+	return answers, nil
+}
+
+// withoutIPHints returns a copy of answers, with the ipv4hint and ipv6hint of
+// HTTPS and SVCB records removed.
+func withoutIPHints(answers []dnsmessage.Resource) []dnsmessage.Resource {
+	stripHints := func(r dnsmessage.SVCBResource) dnsmessage.SVCBResource {
+		r.Params = slices.Clone(r.Params)
+		r.DeleteParam(dnsmessage.SVCParamIPv4Hint)
+		r.DeleteParam(dnsmessage.SVCParamIPv6Hint)
+		return r
+	}
+
+	stripped := make([]dnsmessage.Resource, 0, len(answers))
+	for _, ans := range answers {
+		switch body := ans.Body.(type) {
+		case *dnsmessage.HTTPSResource:
+			ans.Body = &dnsmessage.HTTPSResource{SVCBResource: stripHints(body.SVCBResource)}
+		case *dnsmessage.SVCBResource:
+			svcb := stripHints(*body)
+			ans.Body = &svcb
+		}
+		stripped = append(stripped, ans)
+	}
+	return stripped
+}
+
 func (s *DNS) sortClients(domain string) []*Client {
 	clients := make([]*Client, 0, len(s.clients))
 	clientUsed := make([]bool, len(s.clients))
@@ -69,8 +69,10 @@ type dnsRequest struct {
 	msg     *dnsmessage.Message
 }
 
-func genEDNS0Options(clientIP net.IP, padding int) *dnsmessage.Resource {
-	if len(clientIP) == 0 && padding == 0 {
+// genEDNS0Options returns the OPT record of a query, or nil if no options are
+// needed. It is always returned if dnssec, for the DO bit.
+func genEDNS0Options(clientIP net.IP, padding int, dnssec bool) *dnsmessage.Resource {
+	if len(clientIP) == 0 && padding == 0 && !dnssec {
 		return nil
 	}
 
@@ -0,0 +1,722 @@
+package dns
+
+import (
+	"cmp"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/xtls/xray-core/common/errors"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// rootTrustAnchors are the DS records of the root zone KSKs published by IANA.
+var rootTrustAnchors = []string{
+	". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+	". IN DS 38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+// maxCachedZones is the number of cached zones over which expired ones are
+// removed.
+const maxCachedZones = 1024
+
+// dnssecNameServer is a name server whose responses can be validated with
+// DNSSEC.
+type dnssecNameServer interface {
+	Server
+
+	newReqID() uint16
+	// exchange sends the query and returns its raw response.
+	exchange(ctx context.Context, msg *dnsmessage.Message) ([]byte, error)
+	setDNSSECValidator(v *dnssecValidator)
+}
+
+// dnssecValidator validates the responses of a name server, following the
+// chain of trust from the trust anchors with DS and DNSKEY queries to the same
+// server.
+type dnssecValidator struct {
+	server  dnssecNameServer
+	anchors map[string][]*dns.DS
+	now     func() time.Time
+
+	access sync.Mutex
+	zones  map[string]*zoneTrust
+}
+
+// zoneTrust is the validated trust of a zone, which is insecure if it has no
+// keys.
+type zoneTrust struct {
+	keys   []*dns.DNSKEY
+	expire time.Time
+}
+
+// dsResult is the result of a DS lookup.
+type dsResult int
+
+const (
+	// dsUnknown is that neither DS records nor the proof of their absence are
+	// validated.
+	dsUnknown dsResult = iota
+	// dsSecure is a zone cut with validated DS records.
+	dsSecure
+	// dsInsecure is a zone cut provably without DS records, or under an
+	// insecure zone.
+	dsInsecure
+	// dsNoCut is a name provably not a zone cut.
+	dsNoCut
+)
+
+func newDNSSECValidator(server dnssecNameServer, trustAnchors []string) (*dnssecValidator, error) {
+	if len(trustAnchors) == 0 {
+		trustAnchors = rootTrustAnchors
+	}
+	v := &dnssecValidator{
+		server:  server,
+		anchors: make(map[string][]*dns.DS),
+		now:     time.Now,
+		zones:   make(map[string]*zoneTrust),
+	}
+	for _, s := range trustAnchors {
+		ds, err := ParseTrustAnchor(s)
+		if err != nil {
+			return nil, err
+		}
+		zone := dns.CanonicalName(ds.Hdr.Name)
+		v.anchors[zone] = append(v.anchors[zone], ds)
+	}
+	return v, nil
+}
+
+// ParseTrustAnchor parses a trust anchor, which is a DS record in the zone
+// file format.
+func ParseTrustAnchor(s string) (*dns.DS, error) {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		return nil, errors.New("invalid trust anchor: ", s).Base(err)
+	}
+	ds, ok := rr.(*dns.DS)
+	if !ok {
+		return nil, errors.New("trust anchor is not a DS record: ", s)
+	}
+	return ds, nil
+}
+
+// validate returns an error if the response is bogus. Responses for names
+// without trust anchors are not validated.
+func (v *dnssecValidator) validate(ctx context.Context, resp []byte) error {
+	if v == nil {
+		return nil
+	}
+
+	msg := new(dns.Msg)
+	if err := msg.Unpack(resp); err != nil {
+		return errors.New("failed to parse response to validate").Base(err)
+	}
+	if len(msg.Question) != 1 {
+		return errors.New("DNSSEC: unexpected questions in response")
+	}
+	if msg.Rcode != dns.RcodeSuccess && msg.Rcode != dns.RcodeNameError {
+		return nil
+	}
+	q := msg.Question[0]
+	name := dns.CanonicalName(q.Name)
+	if v.anchorOf(name) == "" {
+		return nil
+	}
+
+	for _, set := range rrsets(msg.Answer) {
+		if _, err := v.verifyRRset(ctx, set); err != nil {
+			return err
+		}
+		if set.wildcard != nil {
+			if err := v.verifyWildcard(ctx, msg.Ns, set.name, set.wildcard.Labels); err != nil {
+				return err
+			}
+		}
+	}
+
+	target := name
+	for range msg.Answer {
+		cname := ""
+		for _, rr := range msg.Answer {
+			if rr, ok := rr.(*dns.CNAME); ok && dns.CanonicalName(rr.Hdr.Name) == target {
+				cname = dns.CanonicalName(rr.Target)
+			}
+		}
+		if cname == "" {
+			break
+		}
+		target = cname
+	}
+	if msg.Rcode == dns.RcodeSuccess {
+		for _, rr := range msg.Answer {
+			if h := rr.Header(); h.Rrtype == q.Qtype && dns.CanonicalName(h.Name) == target {
+				return nil
+			}
+		}
+	}
+	return v.verifyDenial(ctx, msg.Ns, target, q.Qtype, msg.Rcode == dns.RcodeNameError)
+}
+
+// verifyRRset returns whether set is validated secure, or an error if it is
+// bogus.
+func (v *dnssecValidator) verifyRRset(ctx context.Context, set *rrset) (bool, error) {
+	if v.anchorOf(set.name) == "" {
+		return false, nil
+	}
+	if len(set.sigs) == 0 {
+		return false, v.proveInsecure(ctx, set.name)
+	}
+	return v.verifySigned(ctx, set)
+}
+
+// verifySigned verifies the signatures of set with the keys of their signers.
+func (v *dnssecValidator) verifySigned(ctx context.Context, set *rrset) (bool, error) {
+	var err error = errors.New("DNSSEC: no valid signature of ", set.name, " ", dns.TypeToString[set.rrtype])
+	now := v.now()
+	for _, sig := range set.sigs {
+		signer := dns.CanonicalName(sig.SignerName)
+		if !dns.IsSubDomain(signer, set.name) || v.anchorOf(signer) == "" || !sig.ValidityPeriod(now) {
+			continue
+		}
+		keys, keysErr := v.zoneKeys(ctx, signer)
+		if keysErr != nil {
+			err = keysErr
+			continue
+		}
+		if keys == nil {
+			return false, nil
+		}
+		for _, key := range keys {
+			if key.KeyTag() == sig.KeyTag && key.Algorithm == sig.Algorithm && sig.Verify(key, set.rrs) == nil {
+				if int(sig.Labels) < ownerLabels(set.name) {
+					set.wildcard = sig
+				}
+				return true, nil
+			}
+		}
+	}
+	return false, err
+}
+
+// zoneKeys returns the validated keys of zone, or nil if it is provably
+// insecure.
+func (v *dnssecValidator) zoneKeys(ctx context.Context, zone string) ([]*dns.DNSKEY, error) {
+	if trust := v.cachedTrust(zone); trust != nil {
+		return trust.keys, nil
+	}
+
+	dsSet, found := v.anchors[zone]
+	if !found {
+		result, ds, err := v.lookupDS(ctx, zone)
+		if err != nil {
+			return nil, err
+		}
+		switch result {
+		case dsSecure:
+			dsSet = ds
+		case dsInsecure:
+			return nil, nil
+		default:
+			return nil, errors.New("DNSSEC: no DS of signer zone ", zone)
+		}
+	}
+
+	msg, err := v.query(ctx, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return nil, err
+	}
+	var keys []*dns.DNSKEY
+	var keyRRs []dns.RR
+	var sigs []*dns.RRSIG
+	for _, rr := range msg.Answer {
+		if dns.CanonicalName(rr.Header().Name) != zone {
+			continue
+		}
+		switch rr := rr.(type) {
+		case *dns.DNSKEY:
+			keys = append(keys, rr)
+			keyRRs = append(keyRRs, rr)
+		case *dns.RRSIG:
+			if rr.TypeCovered == dns.TypeDNSKEY {
+				sigs = append(sigs, rr)
+			}
+		}
+	}
+
+	now := v.now()
+	for _, sig := range sigs {
+		if !sig.ValidityPeriod(now) {
+			continue
+		}
+		for _, key := range keys {
+			if key.KeyTag() != sig.KeyTag || key.Algorithm != sig.Algorithm || !matchDS(key, dsSet) {
+				continue
+			}
+			if sig.Verify(key, keyRRs) != nil {
+				continue
+			}
+			var zoneKeys []*dns.DNSKEY
+			for _, key := range keys {
+				if key.Flags&dns.ZONE != 0 {
+					zoneKeys = append(zoneKeys, key)
+				}
+			}
+			v.cacheTrust(zone, zoneKeys, min(keyRRs[0].Header().Ttl, sig.OrigTtl))
+			return zoneKeys, nil
+		}
+	}
+	return nil, errors.New("DNSSEC: no valid DNSKEY of ", zone)
+}
+
+// lookupDS queries the DS records of name, validated with the keys of zones
+// above it.
+func (v *dnssecValidator) lookupDS(ctx context.Context, name string) (dsResult, []*dns.DS, error) {
+	msg, err := v.query(ctx, name, dns.TypeDS)
+	if err != nil {
+		return dsUnknown, nil, err
+	}
+
+	for _, set := range rrsets(msg.Answer) {
+		if set.name != name || set.rrtype != dns.TypeDS {
+			continue
+		}
+		set = set.signedAbove(name)
+		if len(set.sigs) == 0 {
+			return dsUnknown, nil, nil
+		}
+		secure, err := v.verifySigned(ctx, set)
+		if err != nil {
+			return dsUnknown, nil, err
+		}
+		if !secure {
+			v.cacheTrust(name, nil, set.ttl())
+			return dsInsecure, nil, nil
+		}
+		if set.wildcard != nil {
+			return dsUnknown, nil, errors.New("DNSSEC: DS of ", name, " expanded from wildcard")
+		}
+		var ds []*dns.DS
+		for _, rr := range set.rrs {
+			ds = append(ds, rr.(*dns.DS))
+		}
+		return dsSecure, ds, nil
+	}
+
+	for _, set := range rrsets(msg.Ns) {
+		if set.rrtype != dns.TypeNSEC && set.rrtype != dns.TypeNSEC3 {
+			continue
+		}
+		set = set.signedAbove(name)
+		if len(set.sigs) == 0 {
+			continue
+		}
+		secure, err := v.verifySigned(ctx, set)
+		if err != nil {
+			return dsUnknown, nil, err
+		}
+		if set.wildcard != nil {
+			continue
+		}
+		result := dsInsecure
+		if secure {
+			result = denyDS(set.rrs, name)
+		}
+		if result == dsInsecure {
+			v.cacheTrust(name, nil, set.ttl())
+		}
+		if result != dsUnknown {
+			return result, nil, nil
+		}
+	}
+	return dsUnknown, nil, nil
+}
+
+// denyDS returns what NSEC or NSEC3 records prove about the DS records of name.
+func denyDS(rrs []dns.RR, name string) dsResult {
+	cut := func(bitmap []uint16) dsResult {
+		switch {
+		case hasType(bitmap, dns.TypeDS):
+			return dsUnknown
+		case hasType(bitmap, dns.TypeNS) && !hasType(bitmap, dns.TypeSOA):
+			return dsInsecure
+		default:
+			return dsNoCut
+		}
+	}
+	for _, rr := range rrs {
+		switch rr := rr.(type) {
+		case *dns.NSEC:
+			if dns.CanonicalName(rr.Hdr.Name) == name {
+				return cut(rr.TypeBitMap)
+			}
+			if nsecCovers(rr, name) {
+				return dsNoCut
+			}
+		case *dns.NSEC3:
+			if rr.Match(name) {
+				return cut(rr.TypeBitMap)
+			}
+			if rr.Cover(name) {
+				// An opt-out NSEC3 may cover insecure delegations.
+				if rr.Flags&1 != 0 {
+					return dsInsecure
+				}
+				return dsNoCut
+			}
+		}
+	}
+	return dsUnknown
+}
+
+// proveInsecure returns nil if name is in a provably insecure zone under its
+// trust anchor.
+func (v *dnssecValidator) proveInsecure(ctx context.Context, name string) error {
+	anchor := v.anchorOf(name)
+
+	// No zones under an insecure one are secure, so a cached insecure zone
+	// proves it without queries, unless a secure zone is cached closer.
+	for n := name; n != ""; n = parentName(n) {
+		if trust := v.cachedTrust(n); trust != nil {
+			if trust.keys == nil {
+				return nil
+			}
+			break
+		}
+		if n == anchor {
+			break
+		}
+	}
+
+	for n := name; n != anchor && n != ""; n = parentName(n) {
+		if trust := v.cachedTrust(n); trust != nil {
+			if trust.keys == nil {
+				return nil
+			}
+			return errors.New("DNSSEC: unsigned records of ", name, " in signed zone ", n)
+		}
+		result, _, err := v.lookupDS(ctx, n)
+		if err != nil {
+			return err
+		}
+		switch result {
+		case dsInsecure:
+			return nil
+		case dsSecure:
+			return errors.New("DNSSEC: unsigned records of ", name, " in signed zone ", n)
+		}
+	}
+	return errors.New("DNSSEC: unsigned records of ", name, " under trust anchor ", anchor)
+}
+
+// verifyDenial returns nil if the authority records prove that name has no
+// records of rrtype, or no records at all if nxdomain, or that it is insecure.
+func (v *dnssecValidator) verifyDenial(ctx context.Context, authority []dns.RR, name string, rrtype uint16, nxdomain bool) error {
+	if v.anchorOf(name) == "" {
+		return nil
+	}
+
+	var nsecs []*dns.NSEC
+	var nsec3s []*dns.NSEC3
+	for _, set := range rrsets(authority) {
+		secure, err := v.verifyRRset(ctx, set)
+		if err != nil {
+			return err
+		}
+		if !secure || set.wildcard != nil {
+			continue
+		}
+		for _, rr := range set.rrs {
+			switch rr := rr.(type) {
+			case *dns.NSEC:
+				nsecs = append(nsecs, rr)
+			case *dns.NSEC3:
+				nsec3s = append(nsec3s, rr)
+			}
+		}
+	}
+	if len(nsecs) == 0 && len(nsec3s) == 0 {
+		return v.proveInsecure(ctx, name)
+	}
+
+	if nxdomain {
+		for _, nsec := range nsecs {
+			if nsecCovers(nsec, name) {
+				return nil
+			}
+		}
+		// The closest encloser of name matches an NSEC3, and the next closer
+		// name is covered by one.
+		for next, ce := name, parentName(name); ce != ""; next, ce = ce, parentName(ce) {
+			matched := false
+			for _, nsec3 := range nsec3s {
+				matched = matched || nsec3.Match(ce)
+			}
+			if !matched {
+				continue
+			}
+			for _, nsec3 := range nsec3s {
+				if nsec3.Cover(next) {
+					return nil
+				}
+			}
+			break
+		}
+	} else {
+		noData := func(bitmap []uint16) bool {
+			return !hasType(bitmap, rrtype) && !hasType(bitmap, dns.TypeCNAME)
+		}
+		for _, nsec := range nsecs {
+			if dns.CanonicalName(nsec.Hdr.Name) == name && noData(nsec.TypeBitMap) {
+				return nil
+			}
+		}
+		for _, nsec3 := range nsec3s {
+			if nsec3.Match(name) && noData(nsec3.TypeBitMap) {
+				return nil
+			}
+		}
+	}
+	return errors.New("DNSSEC: no proof of nonexistence of ", name, " ", dns.TypeToString[rrtype])
+}
+
+// verifyWildcard returns nil if the authority records prove that name, an
+// answer expanded from a wildcard of the given labels, does not exist itself,
+// so the wildcard is rightly expanded. The proof is an NSEC covering name, or
+// an NSEC3 covering the next closer name of the wildcard (RFC 5155 8.8).
+func (v *dnssecValidator) verifyWildcard(ctx context.Context, authority []dns.RR, name string, labels uint8) error {
+	indexes := dns.Split(name)
+	nextCloser := name[indexes[len(indexes)-int(labels)-1]:]
+	for _, set := range rrsets(authority) {
+		if set.rrtype != dns.TypeNSEC && set.rrtype != dns.TypeNSEC3 {
+			continue
+		}
+		secure, err := v.verifyRRset(ctx, set)
+		if err != nil {
+			return err
+		}
+		if !secure || set.wildcard != nil {
+			continue
+		}
+		for _, rr := range set.rrs {
+			switch rr := rr.(type) {
+			case *dns.NSEC:
+				if nsecCovers(rr, name) {
+					return nil
+				}
+			case *dns.NSEC3:
+				if rr.Cover(nextCloser) {
+					return nil
+				}
+			}
+		}
+	}
+	return errors.New("DNSSEC: no proof of nonexistence of ", name, " expanded from wildcard")
+}
+
+// query sends a query with the DO bit for the records of name and qtype.
+func (v *dnssecValidator) query(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+	req := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               v.server.newReqID(),
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{{
+			Name:  qname,
+			Type:  dnsmessage.Type(qtype),
+			Class: dnsmessage.ClassINET,
+		}},
+		Additionals: []dnsmessage.Resource{*genEDNS0Options(nil, 0, true)},
+	}
+
+	resp, err := v.server.exchange(ctx, req)
+	if err != nil {
+		return nil, errors.New("failed to query ", dns.TypeToString[qtype], " of ", name).Base(err)
+	}
+	msg := new(dns.Msg)
+	if err := msg.Unpack(resp); err != nil {
+		return nil, errors.New("failed to parse ", dns.TypeToString[qtype], " response of ", name).Base(err)
+	}
+	if len(msg.Question) != 1 || dns.CanonicalName(msg.Question[0].Name) != name || msg.Question[0].Qtype != qtype {
+		return nil, errors.New("mismatched ", dns.TypeToString[qtype], " response of ", name)
+	}
+	if msg.Rcode != dns.RcodeSuccess && msg.Rcode != dns.RcodeNameError {
+		return nil, errors.New("failed to query ", dns.TypeToString[qtype], " of ", name, ": ", dns.RcodeToString[msg.Rcode])
+	}
+	return msg, nil
+}
+
+// anchorOf returns the closest zone with trust anchors containing name, or ""
+// if there is none.
+func (v *dnssecValidator) anchorOf(name string) string {
+	for n := name; n != ""; n = parentName(n) {
+		if _, found := v.anchors[n]; found {
+			return n
+		}
+	}
+	return ""
+}
+
+func (v *dnssecValidator) cachedTrust(zone string) *zoneTrust {
+	v.access.Lock()
+	defer v.access.Unlock()
+
+	trust := v.zones[zone]
+	if trust == nil || trust.expire.Before(v.now()) {
+		return nil
+	}
+	return trust
+}
+
+func (v *dnssecValidator) cacheTrust(zone string, keys []*dns.DNSKEY, ttl uint32) {
+	v.access.Lock()
+	defer v.access.Unlock()
+
+	now := v.now()
+	if len(v.zones) >= maxCachedZones {
+		for z, trust := range v.zones {
+			if trust.expire.Before(now) {
+				delete(v.zones, z)
+			}
+		}
+	}
+	v.zones[zone] = &zoneTrust{
+		keys:   keys,
+		expire: now.Add(time.Duration(ttl) * time.Second),
+	}
+}
+
+// rrset is the records of a name and type, with the signatures covering them.
+type rrset struct {
+	name   string
+	rrtype uint16
+	rrs    []dns.RR
+	sigs   []*dns.RRSIG
+	// wildcard is the signature verifying the records, if they are expanded
+	// from a wildcard.
+	wildcard *dns.RRSIG
+}
+
+// rrsets groups the records into RRsets, in the order they appear.
+func rrsets(rrs []dns.RR) []*rrset {
+	type key struct {
+		name   string
+		rrtype uint16
+	}
+	var sets []*rrset
+	index := make(map[key]*rrset)
+	for _, rr := range rrs {
+		k := key{dns.CanonicalName(rr.Header().Name), rr.Header().Rrtype}
+		sig, isSig := rr.(*dns.RRSIG)
+		if isSig {
+			k.rrtype = sig.TypeCovered
+		}
+		set := index[k]
+		if set == nil {
+			set = &rrset{name: k.name, rrtype: k.rrtype}
+			index[k] = set
+			sets = append(sets, set)
+		}
+		if isSig {
+			set.sigs = append(set.sigs, sig)
+		} else {
+			set.rrs = append(set.rrs, rr)
+		}
+	}
+
+	n := 0
+	for _, set := range sets {
+		if len(set.rrs) > 0 {
+			sets[n] = set
+			n++
+		}
+	}
+	return sets[:n]
+}
+
+// signedAbove returns the set with only the signatures of zones above name.
+func (s *rrset) signedAbove(name string) *rrset {
+	above := &rrset{name: s.name, rrtype: s.rrtype, rrs: s.rrs}
+	for _, sig := range s.sigs {
+		if signer := dns.CanonicalName(sig.SignerName); signer != name && dns.IsSubDomain(signer, name) {
+			above.sigs = append(above.sigs, sig)
+		}
+	}
+	return above
+}
+
+func (s *rrset) ttl() uint32 {
+	return s.rrs[0].Header().Ttl
+}
+
+// ownerLabels returns the labels of name counted in the RRSIG of its records,
+// which are without the leading wildcard label.
+func ownerLabels(name string) int {
+	labels := dns.CountLabel(name)
+	if strings.HasPrefix(name, "*.") {
+		labels--
+	}
+	return labels
+}
+
+func matchDS(key *dns.DNSKEY, dsSet []*dns.DS) bool {
+	for _, ds := range dsSet {
+		if ds.KeyTag != key.KeyTag() || ds.Algorithm != key.Algorithm {
+			continue
+		}
+		if keyDS := key.ToDS(ds.DigestType); keyDS != nil && strings.EqualFold(keyDS.Digest, ds.Digest) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasType(bitmap []uint16, rrtype uint16) bool {
+	for _, t := range bitmap {
+		if t == rrtype {
+			return true
+		}
+	}
+	return false
+}
+
+// nsecCovers returns whether name is between the owner and the next name of
+// nsec, which proves it does not exist.
+func nsecCovers(nsec *dns.NSEC, name string) bool {
+	owner, next := nsec.Hdr.Name, nsec.NextDomain
+	if canonicalCompare(owner, next) < 0 {
+		return canonicalCompare(owner, name) < 0 && canonicalCompare(name, next) < 0
+	}
+	// The last NSEC of a zone has the apex as the next name.
+	return canonicalCompare(owner, name) < 0 && dns.IsSubDomain(next, name)
+}
+
+// canonicalCompare compares names in the canonical order of RFC 4034.
+func canonicalCompare(a, b string) int {
+	la := dns.SplitDomainName(strings.ToLower(a))
+	lb := dns.SplitDomainName(strings.ToLower(b))
+	for i, j := len(la)-1, len(lb)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if c := strings.Compare(la[i], lb[j]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(la), len(lb))
+}
+
+// parentName returns the name without its first label, or "" for the root.
+func parentName(name string) string {
+	if name == "." || name == "" {
+		return ""
+	}
+	i, end := dns.NextLabel(name, 0)
+	if end {
+		return "."
+	}
+	return name[i:]
+}
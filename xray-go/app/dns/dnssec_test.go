@@ -0,0 +1,334 @@
+package dns_test
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	"github.com/xtls/xray-core/app/dispatcher"
+	. "github.com/xtls/xray-core/app/dns"
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	feature_dns "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/testing/servers/udp"
+)
+
+type signedQuestion struct {
+	name  string
+	qtype uint16
+}
+
+type signedResponse struct {
+	answer []dns.RR
+	ns     []dns.RR
+}
+
+// signedZoneHandler serves the records of a locally signed root zone and
+// "example." zone.
+type signedZoneHandler struct {
+	anchor    string
+	responses map[signedQuestion]*signedResponse
+}
+
+func newZoneKey(zone string) (*dns.DNSKEY, crypto.Signer) {
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: zone, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     257,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	priv, err := key.Generate(256)
+	common.Must(err)
+	return key, priv.(crypto.Signer)
+}
+
+func signRRset(key *dns.DNSKEY, priv crypto.Signer, rrs ...dns.RR) *dns.RRSIG {
+	sig := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Ttl: 3600},
+		KeyTag:     key.KeyTag(),
+		SignerName: key.Hdr.Name,
+		Algorithm:  key.Algorithm,
+		Inception:  uint32(time.Now().Add(-time.Hour).Unix()),
+		Expiration: uint32(time.Now().Add(time.Hour).Unix()),
+	}
+	common.Must(sig.Sign(priv, rrs))
+	return sig
+}
+
+func newRR(s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	common.Must(err)
+	return rr
+}
+
+// expandWildcard returns the record of name expanded from the wildcard one,
+// with its signature.
+func expandWildcard(name string, wildcard dns.RR, sig *dns.RRSIG) (dns.RR, *dns.RRSIG) {
+	rr := dns.Copy(wildcard)
+	rr.Header().Name = name
+	sig.Hdr.Name = name
+	return rr, sig
+}
+
+func newSignedZoneHandler() *signedZoneHandler {
+	rootKey, rootPriv := newZoneKey(".")
+	exampleKey, examplePriv := newZoneKey("example.")
+	exampleDS := exampleKey.ToDS(dns.SHA256)
+
+	secure := newRR("secure.example. 3600 IN A 1.1.1.1")
+	tampered := newRR("bogus.example. 3600 IN A 1.1.1.2")
+	bogus := newRR("bogus.example. 3600 IN A 6.6.6.6")
+	unsigned := newRR("unsigned.example. 3600 IN A 7.7.7.7")
+	insecure := newRR("host.insecure.example. 3600 IN A 9.9.9.9")
+	insecureNSEC := newRR("insecure.example. 3600 IN NSEC secure.example. NS RRSIG NSEC")
+	wildcard := newRR("*.wild.example. 3600 IN A 5.5.5.5")
+	wildcardNSEC := newRR("*.wild.example. 3600 IN NSEC zzz.example. A RRSIG NSEC")
+	expanded, expandedSig := expandWildcard("host.wild.example.", wildcard, signRRset(exampleKey, examplePriv, wildcard))
+	unproven, unprovenSig := expandWildcard("other.wild.example.", wildcard, signRRset(exampleKey, examplePriv, wildcard))
+
+	return &signedZoneHandler{
+		anchor: rootKey.ToDS(dns.SHA256).String(),
+		responses: map[signedQuestion]*signedResponse{
+			{".", dns.TypeDNSKEY}: {
+				answer: []dns.RR{rootKey, signRRset(rootKey, rootPriv, rootKey)},
+			},
+			{"example.", dns.TypeDS}: {
+				answer: []dns.RR{exampleDS, signRRset(rootKey, rootPriv, exampleDS)},
+			},
+			{"example.", dns.TypeDNSKEY}: {
+				answer: []dns.RR{exampleKey, signRRset(exampleKey, examplePriv, exampleKey)},
+			},
+			{"secure.example.", dns.TypeA}: {
+				answer: []dns.RR{secure, signRRset(exampleKey, examplePriv, secure)},
+			},
+			{"bogus.example.", dns.TypeA}: {
+				answer: []dns.RR{bogus, signRRset(exampleKey, examplePriv, tampered)},
+			},
+			{"unsigned.example.", dns.TypeA}: {
+				answer: []dns.RR{unsigned},
+			},
+			{"insecure.example.", dns.TypeDS}: {
+				ns: []dns.RR{insecureNSEC, signRRset(exampleKey, examplePriv, insecureNSEC)},
+			},
+			{"host.insecure.example.", dns.TypeA}: {
+				answer: []dns.RR{insecure},
+			},
+			{"host.wild.example.", dns.TypeA}: {
+				answer: []dns.RR{expanded, expandedSig},
+				ns:     []dns.RR{wildcardNSEC, signRRset(exampleKey, examplePriv, wildcardNSEC)},
+			},
+			{"other.wild.example.", dns.TypeA}: {
+				answer: []dns.RR{unproven, unprovenSig},
+			},
+		},
+	}
+}
+
+// truncatingHandler answers queries of the type truncated without records,
+// to be retried over TCP.
+type truncatingHandler struct {
+	dns.Handler
+	qtype uint16
+}
+
+func (h *truncatingHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	if r.Question[0].Qtype != h.qtype {
+		h.Handler.ServeDNS(w, r)
+		return
+	}
+	ans := new(dns.Msg)
+	ans.SetReply(r)
+	ans.Truncated = true
+	w.WriteMsg(ans)
+}
+
+func (h *signedZoneHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	ans := new(dns.Msg)
+	ans.SetReply(r)
+	q := r.Question[0]
+	if resp, found := h.responses[signedQuestion{q.Name, q.Qtype}]; found {
+		ans.Answer = resp.answer
+		ans.Ns = resp.ns
+	}
+	w.WriteMsg(ans)
+}
+
+func newDNSSECInstance(port net.Port, nameServers ...*NameServer) *core.Instance {
+	for _, ns := range nameServers {
+		ns.Address = &net.Endpoint{
+			Network: net.Network_UDP,
+			Address: &net.IPOrDomain{
+				Address: &net.IPOrDomain_Ip{
+					Ip: []byte{127, 0, 0, 1},
+				},
+			},
+			Port: uint32(port),
+		}
+		ns.TimeoutMs = 2000
+	}
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: nameServers,
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{
+					FinalRules: []*freedom.FinalRuleConfig{{Action: freedom.RuleAction_Allow}},
+				}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	return v
+}
+
+func TestDNSSEC(t *testing.T) {
+	port := udp.PickPort()
+	handler := newSignedZoneHandler()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: handler,
+		UDPSize: 1200,
+	}
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+	defer dnsServer.Shutdown()
+
+	v := newDNSSECInstance(port, &NameServer{
+		Dnssec:      true,
+		TrustAnchor: []string{handler.anchor},
+	})
+	defer v.Close()
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+	option := feature_dns.IPOption{IPv4Enable: true}
+
+	for domain, expected := range map[string]net.IP{
+		"secure.example":        {1, 1, 1, 1},
+		"host.insecure.example": {9, 9, 9, 9},
+		"host.wild.example":     {5, 5, 5, 5},
+	} {
+		ips, _, err := client.LookupIP(domain, option)
+		if err != nil {
+			t.Fatal(domain, ": ", err)
+		}
+		if r := cmp.Diff(ips, []net.IP{expected}); r != "" {
+			t.Error(domain, ": ", r)
+		}
+	}
+
+	for _, domain := range []string{"bogus.example", "unsigned.example", "other.wild.example"} {
+		if ips, _, err := client.LookupIP(domain, option); err == nil {
+			t.Error("expected error of bogus ", domain, ", but got ", ips)
+		}
+	}
+}
+
+func TestDNSSECFallback(t *testing.T) {
+	port := udp.PickPort()
+	handler := newSignedZoneHandler()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: handler,
+		UDPSize: 1200,
+	}
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+	defer dnsServer.Shutdown()
+
+	// The same server without validation answers after the bogus response.
+	v := newDNSSECInstance(port, &NameServer{
+		Dnssec:      true,
+		TrustAnchor: []string{handler.anchor},
+	}, &NameServer{})
+	defer v.Close()
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+
+	ips, _, err := client.LookupIP("bogus.example", feature_dns.IPOption{IPv4Enable: true})
+	common.Must(err)
+	if r := cmp.Diff(ips, []net.IP{{6, 6, 6, 6}}); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestDNSSECTruncated(t *testing.T) {
+	port := udp.PickPort()
+	handler := newSignedZoneHandler()
+
+	// The DNSKEY responses are complete over TCP only.
+	udpServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &truncatingHandler{Handler: handler, qtype: dns.TypeDNSKEY},
+		UDPSize: 1200,
+	}
+	tcpServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "tcp",
+		Handler: handler,
+	}
+	go udpServer.ListenAndServe()
+	go tcpServer.ListenAndServe()
+	time.Sleep(time.Second)
+	defer udpServer.Shutdown()
+	defer tcpServer.Shutdown()
+
+	v := newDNSSECInstance(port, &NameServer{
+		Dnssec:      true,
+		TrustAnchor: []string{handler.anchor},
+	})
+	defer v.Close()
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+
+	ips, _, err := client.LookupIP("secure.example", feature_dns.IPOption{IPv4Enable: true})
+	common.Must(err)
+	if r := cmp.Diff(ips, []net.IP{{1, 1, 1, 1}}); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestDNSSECInvalidTrustAnchor(t *testing.T) {
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{{
+					Address: &net.Endpoint{
+						Network: net.Network_UDP,
+						Address: &net.IPOrDomain{
+							Address: &net.IPOrDomain_Ip{
+								Ip: []byte{127, 0, 0, 1},
+							},
+						},
+						Port: 53,
+					},
+					Dnssec:      true,
+					TrustAnchor: []string{"example. IN A 1.1.1.1"},
+				}},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+	}
+	if _, err := core.New(config); err == nil {
+		t.Error("expected error of a trust anchor not a DS record")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/xtls/xray-core/common/cache"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/task"
 	"github.com/xtls/xray-core/features/dns"
 )
 
@@ -20,6 +21,10 @@ type Holder struct {
 	mu         sync.Mutex
 
 	config *FakeDnsPool
+
+	stateSaver  *task.Periodic
+	stateAccess sync.Mutex
+	savedState  []byte
 }
 
 func (fkdns *Holder) IsIPInIPPool(ip net.Address) bool {
@@ -43,13 +48,29 @@ func (*Holder) Type() interface{} {
 
 func (fkdns *Holder) Start() error {
 	if fkdns.config != nil && fkdns.config.IpPool != "" && fkdns.config.LruSize != 0 {
-		return fkdns.initializeFromConfig()
+		if err := fkdns.initializeFromConfig(); err != nil {
+			return err
+		}
+		if fkdns.config.StateFile == "" {
+			return nil
+		}
+		if err := fkdns.restoreState(); err != nil {
+			errors.LogWarningInner(context.Background(), err, "failed to restore fake DNS state from ", fkdns.config.StateFile)
+		}
+		return fkdns.startStateSaver()
 	}
 	return errors.New("invalid fakeDNS setting")
 }
 
 func (fkdns *Holder) Close() error {
-	// nothing to do for now, just wait GC
+	if fkdns.stateSaver == nil {
+		// nothing to do for now, just wait GC
+		return nil
+	}
+	fkdns.stateSaver.Close()
+	if err := fkdns.saveState(); err != nil {
+		return errors.New("failed to save fake DNS state to ", fkdns.config.StateFile).Base(err)
+	}
 	return nil
 }
 
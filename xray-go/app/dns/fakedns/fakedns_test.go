@@ -1,6 +1,7 @@
 package fakedns
 
 import (
+	"path/filepath"
 	"strconv"
 	"testing"
 
@@ -204,3 +205,45 @@ func TestFakeDNSMulti(t *testing.T) {
 		})
 	})
 }
+
+func TestFakeDNSStateFile(t *testing.T) {
+	dir := t.TempDir()
+	newMulti := func(ipv4Pool string) *HolderMulti {
+		fakeMulti, err := NewFakeDNSHolderMulti(
+			&FakeDnsPoolMulti{
+				Pools: []*FakeDnsPool{{
+					IpPool:    ipv4Pool,
+					LruSize:   2,
+					StateFile: filepath.Join(dir, "fakedns4.json"),
+				}, {
+					IpPool:    "fddd:c5b4:ff5f:f4f0::/64",
+					LruSize:   256,
+					StateFile: filepath.Join(dir, "fakedns6.json"),
+				}},
+			},
+		)
+		common.Must(err)
+		common.Must(fakeMulti.Start())
+		return fakeMulti
+	}
+
+	fakeMulti := newMulti("240.0.0.0/12")
+	evicted := fakeMulti.GetFakeIPForDomain("evicted.example.com")
+	address := fakeMulti.GetFakeIPForDomain("fakednstest.example.com")
+	address2 := fakeMulti.GetFakeIPForDomain("fakednstest2.example.com")
+	common.Must(fakeMulti.Close())
+
+	fakeMulti = newMulti("240.0.0.0/12")
+	for _, addr := range append(address, address2...) {
+		assert.NotEqual(t, "", fakeMulti.GetDomainFromFakeDNS(addr), "should resolve restored ", addr)
+	}
+	assert.Equal(t, "", fakeMulti.GetDomainFromFakeDNS(evicted[0]), "should not resolve IP evicted before restart")
+	assert.Equal(t, "evicted.example.com", fakeMulti.GetDomainFromFakeDNS(evicted[1]))
+	assert.Equal(t, address, fakeMulti.GetFakeIPForDomain("fakednstest.example.com"))
+	common.Must(fakeMulti.Close())
+
+	fakeMulti = newMulti("241.0.0.0/12")
+	defer fakeMulti.Close()
+	assert.Equal(t, "", fakeMulti.GetDomainFromFakeDNS(address[0]), "should not restore state of another pool")
+	assert.Equal(t, "fakednstest.example.com", fakeMulti.GetDomainFromFakeDNS(address[1]))
+}
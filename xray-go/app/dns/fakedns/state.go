@@ -0,0 +1,109 @@
+package fakedns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+	"github.com/xtls/xray-core/common/task"
+)
+
+const stateSaveInterval = time.Minute
+
+// poolState is the state of a Holder saved in its state file. The allocation
+// of fake IPs starts from the current time, so the mappings are enough to
+// avoid allocating IPs in use again after a restart.
+type poolState struct {
+	IPPool string `json:"ipPool"`
+	// Mappings are from the least recently used.
+	Mappings []mappingState `json:"mappings"`
+}
+
+type mappingState struct {
+	Domain string `json:"domain"`
+	IP     string `json:"ip"`
+}
+
+func (fkdns *Holder) startStateSaver() error {
+	fkdns.stateSaver = &task.Periodic{
+		Interval: stateSaveInterval,
+		Execute: func() error {
+			if err := fkdns.saveState(); err != nil {
+				errors.LogWarningInner(context.Background(), err, "failed to save fake DNS state to ", fkdns.config.StateFile)
+			}
+			return nil
+		},
+	}
+	return fkdns.stateSaver.Start()
+}
+
+// restoreState puts the mappings saved in the state file into the pool, if it
+// has the same CIDR.
+func (fkdns *Holder) restoreState() error {
+	b, err := os.ReadFile(fkdns.config.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var state poolState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return err
+	}
+	if state.IPPool != fkdns.ipRange.String() {
+		errors.LogInfo(context.Background(), "ignored fake DNS state of a different pool ", state.IPPool)
+		return nil
+	}
+
+	fkdns.mu.Lock()
+	defer fkdns.mu.Unlock()
+	for _, m := range state.Mappings {
+		ip := net.ParseAddress(m.IP)
+		if m.Domain == "" || !ip.Family().IsIP() || !fkdns.ipRange.Contains(ip.IP()) {
+			continue
+		}
+		fkdns.domainToIP.Put(m.Domain, ip)
+	}
+
+	fkdns.stateAccess.Lock()
+	fkdns.savedState = b
+	fkdns.stateAccess.Unlock()
+	return nil
+}
+
+// saveState writes the mappings of the pool to the state file.
+func (fkdns *Holder) saveState() error {
+	fkdns.stateAccess.Lock()
+	defer fkdns.stateAccess.Unlock()
+
+	state := poolState{
+		IPPool:   fkdns.ipRange.String(),
+		Mappings: []mappingState{},
+	}
+	fkdns.domainToIP.Range(func(key, value interface{}) bool {
+		state.Mappings = append(state.Mappings, mappingState{
+			Domain: key.(string),
+			IP:     value.(net.Address).String(),
+		})
+		return true
+	})
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(b, fkdns.savedState) {
+		return nil
+	}
+
+	if err := filesystem.WriteFileAtomic(fkdns.config.StateFile, b); err != nil {
+		return err
+	}
+	fkdns.savedState = b
+	return nil
+}
@@ -3,9 +3,11 @@ package dns
 import (
 	"context"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/geodata"
 	"github.com/xtls/xray-core/common/net"
@@ -14,6 +16,7 @@ import (
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/dns"
 	"github.com/xtls/xray-core/features/routing"
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 // Server is the interface for Name Server.
@@ -67,6 +70,10 @@ func NewServer(ctx context.Context, dest net.Destination, dispatcher routing.Dis
 			return NewTCPNameServer(u, dispatcher, disableCache, serveStale, serveExpiredTTL, clientIP)
 		case strings.EqualFold(u.Scheme, "tcp+local"): // DNS-over-TCP Local mode
 			return NewTCPLocalNameServer(u, disableCache, serveStale, serveExpiredTTL, clientIP)
+		case strings.EqualFold(u.Scheme, "tls"): // DNS-over-TLS Remote mode
+			return NewTLSNameServer(u, dispatcher, disableCache, serveStale, serveExpiredTTL, clientIP)
+		case strings.EqualFold(u.Scheme, "tls+local"): // DNS-over-TLS Local mode
+			return NewTLSLocalNameServer(u, disableCache, serveStale, serveExpiredTTL, clientIP)
 		case strings.EqualFold(u.String(), "fakedns"):
 			var fd dns.FakeDNSEngine
 			err = core.RequireFeatures(ctx, func(fdns dns.FakeDNSEngine) {
@@ -108,6 +115,18 @@ func NewClient(
 		_, isLocalDNS := server.(*LocalNameServer)
 		updateRules(isLocalDNS)
 
+		if ns.Dnssec {
+			dnssecServer, ok := server.(dnssecNameServer)
+			if !ok {
+				return errors.New("DNSSEC validation is not supported by nameserver ", server.Name()).AtWarning()
+			}
+			validator, err := newDNSSECValidator(dnssecServer, ns.TrustAnchor)
+			if err != nil {
+				return errors.New("failed to create DNSSEC validator").Base(err).AtWarning()
+			}
+			dnssecServer.setDNSSECValidator(validator)
+		}
+
 		// Establish expected IPs
 		var expectedMatcher geodata.IPMatcher
 		if len(ns.ExpectedIp) > 0 {
@@ -164,6 +183,17 @@ func (c *Client) Name() string {
 	return c.server.Name()
 }
 
+// Close implements common.Closable. It stops the cache of the server, and
+// closes the server if it is closable.
+func (c *Client) Close() error {
+	var errs []error
+	if cached, ok := c.server.(CachedNameserver); ok {
+		errs = append(errs, cached.getCacheController().Close())
+	}
+	errs = append(errs, common.Close(c.server))
+	return errors.Combine(errs...)
+}
+
 // QueryIP sends DNS query to the name server with the client's IP.
 func (c *Client) QueryIP(ctx context.Context, domain string, option dns.IPOption) ([]net.IP, uint32, error) {
 	if c.checkSystem {
@@ -192,11 +222,20 @@ func (c *Client) QueryIP(ctx context.Context, domain string, option dns.IPOption
 		return nil, 0, dns.ErrEmptyResponse
 	}
 
+	if ips = c.filterIPs(domain, ips); len(ips) == 0 {
+		return nil, 0, dns.ErrEmptyResponse
+	}
+
+	return ips, ttl, nil
+}
+
+// filterIPs applies the expected and unexpected IPs of the client to ips.
+func (c *Client) filterIPs(domain string, ips []net.IP) []net.IP {
 	if c.expectedIPs != nil && !c.actPrior {
 		ips, _ = c.expectedIPs.FilterIPs(ips)
 		errors.LogDebug(context.Background(), "domain ", domain, " expectedIPs ", ips, " matched at server ", c.Name())
 		if len(ips) == 0 {
-			return nil, 0, dns.ErrEmptyResponse
+			return nil
 		}
 	}
 
@@ -204,7 +243,7 @@ func (c *Client) QueryIP(ctx context.Context, domain string, option dns.IPOption
 		_, ips = c.unexpectedIPs.FilterIPs(ips)
 		errors.LogDebug(context.Background(), "domain ", domain, " unexpectedIPs ", ips, " matched at server ", c.Name())
 		if len(ips) == 0 {
-			return nil, 0, dns.ErrEmptyResponse
+			return nil
 		}
 	}
 
@@ -224,7 +263,136 @@ func (c *Client) QueryIP(ctx context.Context, domain string, option dns.IPOption
 		}
 	}
 
-	return ips, ttl, nil
+	return ips
+}
+
+// QueryRecords sends a query of qType to the name server with the client's IP.
+func (c *Client) QueryRecords(ctx context.Context, domain string, qType dnsmessage.Type) ([]dnsmessage.Resource, uint32, error) {
+	server, ok := c.server.(RecordServer)
+	if !ok {
+		return nil, 0, errors.New("querying ", qType, " records is not supported by nameserver ", c.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeoutMs)
+	ctx = session.ContextWithInbound(ctx, &session.Inbound{Tag: c.tag})
+	answers, ttl, err := server.QueryRecords(ctx, domain, qType)
+	cancel()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if answers = c.filterRecords(domain, answers); len(answers) == 0 {
+		return nil, 0, dns.ErrEmptyResponse
+	}
+
+	return answers, ttl, nil
+}
+
+// filterRecords applies the query strategy, and the expected and unexpected
+// IPs of the client to the A and AAAA records in answers, and to the address
+// hints of HTTPS and SVCB records. It returns nil if all the A and AAAA
+// records are filtered out. The cached answers are not modified.
+func (c *Client) filterRecords(domain string, answers []dnsmessage.Resource) []dnsmessage.Resource {
+	ipv4Enable, ipv6Enable := c.ipOption.IPv4Enable, c.ipOption.IPv6Enable
+	if c.checkSystem {
+		ipv4Enable, ipv6Enable = utils.CheckRoutes()
+	}
+
+	var ips []net.IP
+	hasIPs := false
+	for _, ans := range answers {
+		switch body := ans.Body.(type) {
+		case *dnsmessage.AResource:
+			hasIPs = true
+			if ipv4Enable {
+				ips = append(ips, net.IPAddress(body.A[:]).IP())
+			}
+		case *dnsmessage.AAAAResource:
+			hasIPs = true
+			if ipv6Enable {
+				ips = append(ips, net.IPAddress(body.AAAA[:]).IP())
+			}
+		}
+	}
+	if len(ips) > 0 {
+		ips = c.filterIPs(domain, ips)
+	}
+	if hasIPs && len(ips) == 0 {
+		return nil
+	}
+
+	filtered := make([]dnsmessage.Resource, 0, len(answers))
+	for _, ans := range answers {
+		switch body := ans.Body.(type) {
+		case *dnsmessage.AResource:
+			if !containsIP(ips, body.A[:]) {
+				continue
+			}
+		case *dnsmessage.AAAAResource:
+			if !containsIP(ips, body.AAAA[:]) {
+				continue
+			}
+		case *dnsmessage.HTTPSResource:
+			ans.Body = &dnsmessage.HTTPSResource{SVCBResource: c.filterHints(domain, body.SVCBResource, ipv4Enable, ipv6Enable)}
+		case *dnsmessage.SVCBResource:
+			svcb := c.filterHints(domain, *body, ipv4Enable, ipv6Enable)
+			ans.Body = &svcb
+		}
+		filtered = append(filtered, ans)
+	}
+	return filtered
+}
+
+// filterHints returns a copy of r, with the IPs of its ipv4hint and ipv6hint
+// filtered as A and AAAA records. A hint is removed if none of its IPs is
+// left.
+func (c *Client) filterHints(domain string, r dnsmessage.SVCBResource, ipv4Enable, ipv6Enable bool) dnsmessage.SVCBResource {
+	r.Params = slices.Clone(r.Params)
+	for _, hint := range []struct {
+		key    dnsmessage.SVCParamKey
+		size   int
+		enable bool
+	}{
+		{dnsmessage.SVCParamIPv4Hint, net.IPv4len, ipv4Enable},
+		{dnsmessage.SVCParamIPv6Hint, net.IPv6len, ipv6Enable},
+	} {
+		value, found := r.GetParam(hint.key)
+		if !found {
+			continue
+		}
+		var ips []net.IP
+		if hint.enable {
+			for i := 0; i+hint.size <= len(value); i += hint.size {
+				ips = append(ips, net.IPAddress(value[i:i+hint.size]).IP())
+			}
+			ips = c.filterIPs(domain, ips)
+		}
+		if len(ips) == 0 {
+			r.DeleteParam(hint.key)
+			continue
+		}
+		b := make([]byte, 0, len(ips)*hint.size)
+		for _, ip := range ips {
+			if hint.size == net.IPv4len {
+				ip = ip.To4()
+			} else {
+				ip = ip.To16()
+			}
+			b = append(b, ip...)
+		}
+		r.SetParam(hint.key, b)
+	}
+	return r
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, v := range ips {
+		if v.Equal(ip) {
+			return true
+		}
+	}
+	return false
 }
 
 func ResolveIpOptionOverride(queryStrategy QueryStrategy, ipOption dns.IPOption) dns.IPOption {
@@ -23,6 +23,7 @@ import (
 	dns_feature "github.com/xtls/xray-core/features/dns"
 	"github.com/xtls/xray-core/features/routing"
 	"github.com/xtls/xray-core/transport/internet"
+	"golang.org/x/net/dns/dnsmessage"
 	"golang.org/x/net/http2"
 )
 
@@ -34,6 +35,7 @@ type DoHNameServer struct {
 	httpClient      *http.Client
 	dohURL          string
 	clientIP        net.IP
+	dnssec          *dnssecValidator
 }
 
 // NewDoHNameServer creates DOH/DOHL client object for remote/local resolving.
@@ -130,6 +132,21 @@ func (s *DoHNameServer) getCacheController() *CacheController {
 	return s.cacheController
 }
 
+// setDNSSECValidator implements dnssecNameServer.
+func (s *DoHNameServer) setDNSSECValidator(v *dnssecValidator) {
+	s.dnssec = v
+}
+
+// exchange implements dnssecNameServer.
+func (s *DoHNameServer) exchange(ctx context.Context, msg *dnsmessage.Message) ([]byte, error) {
+	b, err := dns.PackMessage(msg)
+	if err != nil {
+		return nil, errors.New("failed to pack dns query").Base(err)
+	}
+	defer b.Release()
+	return s.dohHTTPSContext(ctx, b.Bytes())
+}
+
 // sendQuery implements CachedNameserver.
 func (s *DoHNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<- error, fqdn string, option dns_feature.IPOption) {
 	errors.LogInfo(ctx, s.Name(), " querying: ", fqdn)
@@ -150,7 +167,7 @@ func (s *DoHNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<- er
 
 	// As we don't want our traffic pattern looks like DoH, we use Random-Length Padding instead of Block-Length Padding recommended in RFC 8467
 	// Although DoH server like 1.1.1.1 will pad the response to Block-Length 468, at least it is better than no padding for response at all
-	reqs, err := buildReqMsgs(fqdn, option, s.newReqID, genEDNS0Options(s.clientIP, int(crypto.RandBetween(100, 300))))
+	reqs, err := buildReqMsgs(fqdn, option, s.newReqID, genEDNS0Options(s.clientIP, int(crypto.RandBetween(100, 300)), s.dnssec != nil))
 	if err != nil {
 		errors.LogErrorInner(ctx, err, "failed to build dns query for ", fqdn)
 		if noResponseErrCh != nil {
@@ -194,17 +211,16 @@ func (s *DoHNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<- er
 			dnsCtx, cancel = context.WithDeadline(dnsCtx, deadline)
 			defer cancel()
 
-			b, err := dns.PackMessage(r.msg)
+			resp, err := s.exchange(dnsCtx, r.msg)
 			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to pack dns query for ", fqdn)
+				errors.LogErrorInner(ctx, err, "failed to retrieve response for ", fqdn)
 				if noResponseErrCh != nil {
 					noResponseErrCh <- err
 				}
 				return
 			}
-			resp, err := s.dohHTTPSContext(dnsCtx, b.Bytes())
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to retrieve response for ", fqdn)
+			if err := s.dnssec.validate(dnsCtx, resp); err != nil {
+				errors.LogWarningInner(ctx, err, s.Name(), " got bogus response for ", fqdn)
 				if noResponseErrCh != nil {
 					noResponseErrCh <- err
 				}
@@ -255,3 +271,12 @@ func (s *DoHNameServer) dohHTTPSContext(ctx context.Context, b []byte) ([]byte,
 func (s *DoHNameServer) QueryIP(ctx context.Context, domain string, option dns_feature.IPOption) ([]net.IP, uint32, error) {
 	return queryIP(ctx, s, domain, option)
 }
+
+// QueryRecords implements RecordServer.
+func (s *DoHNameServer) QueryRecords(ctx context.Context, domain string, qType dnsmessage.Type) ([]dnsmessage.Resource, uint32, error) {
+	ctx = session.ContextWithContent(ctx, &session.Content{
+		Protocol:       "https",
+		SkipDNSResolve: true,
+	})
+	return queryRecords(ctx, s, domain, qType, genEDNS0Options(s.clientIP, int(crypto.RandBetween(100, 300)), s.dnssec != nil), s.dnssec)
+}
@@ -1,15 +1,12 @@
 package dns
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
 	"net/url"
 	"sync"
 	"time"
 
 	"github.com/apernet/quic-go"
-	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/log"
 	"github.com/xtls/xray-core/common/net"
@@ -17,6 +14,7 @@ import (
 	"github.com/xtls/xray-core/common/session"
 	dns_feature "github.com/xtls/xray-core/features/dns"
 	"github.com/xtls/xray-core/transport/internet/tls"
+	"golang.org/x/net/dns/dnsmessage"
 	"golang.org/x/net/http2"
 )
 
@@ -33,6 +31,7 @@ type QUICNameServer struct {
 	destination     *net.Destination
 	connection      *quic.Conn
 	clientIP        net.IP
+	dnssec          *dnssecValidator
 }
 
 // NewQUICNameServer creates DNS-over-QUIC client object for local resolving
@@ -74,11 +73,36 @@ func (s *QUICNameServer) newReqID() uint16 {
 // getCacheController implements CachedNameServer.
 func (s *QUICNameServer) getCacheController() *CacheController { return s.cacheController }
 
+// setDNSSECValidator implements dnssecNameServer.
+func (s *QUICNameServer) setDNSSECValidator(v *dnssecValidator) {
+	s.dnssec = v
+}
+
+// exchange implements dnssecNameServer.
+func (s *QUICNameServer) exchange(ctx context.Context, msg *dnsmessage.Message) ([]byte, error) {
+	b, err := dns.PackMessage(msg)
+	if err != nil {
+		return nil, errors.New("failed to pack dns query").Base(err)
+	}
+	defer b.Release()
+
+	stream, err := s.openStream(ctx)
+	if err != nil {
+		return nil, errors.New("failed to open quic connection").Base(err)
+	}
+	if err := writeTCPMessage(stream, b.Bytes()); err != nil {
+		return nil, errors.New("failed to send query").Base(err)
+	}
+	_ = stream.Close()
+
+	return readTCPMessage(stream)
+}
+
 // sendQuery implements CachedNameServer.
 func (s *QUICNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<- error, fqdn string, option dns_feature.IPOption) {
 	errors.LogInfo(ctx, s.Name(), " querying: ", fqdn)
 
-	reqs, err := buildReqMsgs(fqdn, option, s.newReqID, genEDNS0Options(s.clientIP, 0))
+	reqs, err := buildReqMsgs(fqdn, option, s.newReqID, genEDNS0Options(s.clientIP, 0, s.dnssec != nil))
 	if err != nil {
 		errors.LogErrorInner(ctx, err, "failed to build dns query for ", fqdn)
 		if noResponseErrCh != nil {
@@ -119,84 +143,24 @@ func (s *QUICNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<- e
 			dnsCtx, cancel = context.WithDeadline(dnsCtx, deadline)
 			defer cancel()
 
-			b, err := dns.PackMessage(r.msg)
+			resp, err := s.exchange(dnsCtx, r.msg)
 			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to pack dns query")
+				errors.LogErrorInner(ctx, err, "failed to exchange query")
 				if noResponseErrCh != nil {
 					noResponseErrCh <- err
 				}
 				return
 			}
 
-			dnsReqBuf := buf.New()
-			err = binary.Write(dnsReqBuf, binary.BigEndian, uint16(b.Len()))
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "binary write failed")
+			if err := s.dnssec.validate(dnsCtx, resp); err != nil {
+				errors.LogWarningInner(ctx, err, s.Name(), " got bogus response for ", fqdn)
 				if noResponseErrCh != nil {
 					noResponseErrCh <- err
 				}
 				return
 			}
-			_, err = dnsReqBuf.Write(b.Bytes())
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "buffer write failed")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-			b.Release()
 
-			conn, err := s.openStream(dnsCtx)
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to open quic connection")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-
-			_, err = conn.Write(dnsReqBuf.Bytes())
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to send query")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-
-			_ = conn.Close()
-
-			respBuf := buf.New()
-			defer respBuf.Release()
-			n, err := respBuf.ReadFullFrom(conn, 2)
-			if err != nil && n == 0 {
-				errors.LogErrorInner(ctx, err, "failed to read response length")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-			var length uint16
-			err = binary.Read(bytes.NewReader(respBuf.Bytes()), binary.BigEndian, &length)
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to parse response length")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-			respBuf.Clear()
-			n, err = respBuf.ReadFullFrom(conn, int32(length))
-			if err != nil && n == 0 {
-				errors.LogErrorInner(ctx, err, "failed to read response length")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-
-			rec, err := parseResponse(respBuf.Bytes())
+			rec, err := parseResponse(resp)
 			if err != nil {
 				errors.LogErrorInner(ctx, err, "failed to handle response")
 				if noResponseErrCh != nil {
@@ -214,6 +178,15 @@ func (s *QUICNameServer) QueryIP(ctx context.Context, domain string, option dns_
 	return queryIP(ctx, s, domain, option)
 }
 
+// QueryRecords implements RecordServer.
+func (s *QUICNameServer) QueryRecords(ctx context.Context, domain string, qType dnsmessage.Type) ([]dnsmessage.Resource, uint32, error) {
+	ctx = session.ContextWithContent(ctx, &session.Content{
+		Protocol:       "quic",
+		SkipDNSResolve: true,
+	})
+	return queryRecords(ctx, s, domain, qType, genEDNS0Options(s.clientIP, 0, s.dnssec != nil), s.dnssec)
+}
+
 func isActive(s *quic.Conn) bool {
 	select {
 	case <-s.Context().Done():
@@ -0,0 +1,237 @@
+package dns
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	dns_feature "github.com/xtls/xray-core/features/dns"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// RecordServer is a Server that also queries records of any type.
+type RecordServer interface {
+	Server
+
+	// QueryRecords sends a query of qType to its configured server, and
+	// returns the answer records.
+	QueryRecords(ctx context.Context, domain string, qType dnsmessage.Type) ([]dnsmessage.Resource, uint32, error)
+}
+
+// recordNameServer is a CachedNameserver exchanging raw messages with its
+// server, so that it can query records of any type.
+type recordNameServer interface {
+	CachedNameserver
+
+	newReqID() uint16
+	exchange(ctx context.Context, msg *dnsmessage.Message) ([]byte, error)
+}
+
+type answersKey struct {
+	domain string
+	qType  dnsmessage.Type
+}
+
+// answersRecord is a cacheable answer to a query of any type.
+type answersRecord struct {
+	Answers []dnsmessage.Resource
+	Expire  time.Time
+	RCode   dnsmessage.RCode
+}
+
+func (r *answersRecord) getAnswers() ([]dnsmessage.Resource, int32, error) {
+	untilExpire := time.Until(r.Expire).Seconds()
+	ttl := int32(math.Ceil(untilExpire))
+
+	if r.RCode != dnsmessage.RCodeSuccess {
+		return nil, ttl, dns_feature.RCodeError(r.RCode)
+	}
+	if len(r.Answers) == 0 {
+		return nil, ttl, dns_feature.ErrEmptyResponse
+	}
+
+	return r.Answers, ttl, nil
+}
+
+// queryRecords is called from RecordServer.QueryRecords of s, with the OPT
+// record of its queries and its DNSSEC validator.
+func queryRecords(ctx context.Context, s recordNameServer, domain string, qType dnsmessage.Type, opt *dnsmessage.Resource, validator *dnssecValidator) ([]dnsmessage.Resource, uint32, error) {
+	fqdn := Fqdn(domain)
+
+	cache := s.getCacheController()
+	if !cache.disableCache {
+		if rec := cache.findAnswers(fqdn, qType); rec != nil {
+			answers, ttl, err := rec.getAnswers()
+			if ttl > 0 {
+				cache.hits.Add(1)
+				errors.LogDebugInner(ctx, err, cache.name, " cache HIT ", fqdn, " ", qType, " -> ", len(answers), " records")
+				return answers, uint32(ttl), err
+			}
+			if cache.serveStale && (cache.serveExpiredTTL == 0 || cache.serveExpiredTTL < ttl) {
+				cache.hits.Add(1)
+				errors.LogDebugInner(ctx, err, cache.name, " cache OPTIMISTE ", fqdn, " ", qType, " -> ", len(answers), " records")
+				go pullRecords(ctx, s, fqdn, qType, opt, validator)
+				return answers, 1, err
+			}
+		}
+		cache.misses.Add(1)
+	} else {
+		errors.LogDebug(ctx, "DNS cache is disabled. Querying ", qType, " records for ", fqdn, " at ", cache.name)
+	}
+
+	return fetchRecords(ctx, s, fqdn, qType, opt, validator)
+}
+
+func pullRecords(ctx context.Context, s recordNameServer, fqdn string, qType dnsmessage.Type, opt *dnsmessage.Resource, validator *dnssecValidator) {
+	nctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 8*time.Second)
+	defer cancel()
+
+	fetchRecords(nctx, s, fqdn, qType, opt, validator)
+}
+
+type recordsResult struct {
+	answers []dnsmessage.Resource
+	ttl     uint32
+	error
+}
+
+func fetchRecords(ctx context.Context, s recordNameServer, fqdn string, qType dnsmessage.Type, opt *dnsmessage.Resource, validator *dnssecValidator) ([]dnsmessage.Resource, uint32, error) {
+	v, _, _ := s.getCacheController().requestGroup.Do(fqdn+qType.String(), func() (any, error) {
+		return doFetchRecords(ctx, s, fqdn, qType, opt, validator), nil
+	})
+	ret := v.(recordsResult)
+
+	return ret.answers, ret.ttl, ret.error
+}
+
+func doFetchRecords(ctx context.Context, s recordNameServer, fqdn string, qType dnsmessage.Type, opt *dnsmessage.Resource, validator *dnssecValidator) recordsResult {
+	cache := s.getCacheController()
+	errors.LogInfo(ctx, cache.name, " querying DNS for: ", fqdn, " ", qType)
+
+	name, err := dnsmessage.NewName(fqdn)
+	if err != nil {
+		return recordsResult{error: errors.New("failed to build dns query for ", fqdn).Base(err)}
+	}
+	msg := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               s.newReqID(),
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  qType,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	if opt != nil {
+		msg.Additionals = append(msg.Additionals, *opt)
+	}
+
+	start := time.Now()
+	resp, err := s.exchange(ctx, msg)
+	if err != nil {
+		return recordsResult{error: errors.New("failed to exchange query").Base(err)}
+	}
+	if err := validator.validate(ctx, resp); err != nil {
+		errors.LogWarningInner(ctx, err, cache.name, " got bogus response for ", fqdn)
+		return recordsResult{error: err}
+	}
+	rec, err := parseAnswers(resp, qType)
+	if err != nil {
+		return recordsResult{error: err}
+	}
+	cache.updateAnswers(fqdn, qType, rec, time.Since(start))
+
+	answers, ttl, err := rec.getAnswers()
+	if ttl <= 0 {
+		ttl = 1
+	}
+	return recordsResult{answers, uint32(ttl), err}
+}
+
+// parseAnswers parses the answer records of a response to a query of qType,
+// which must not be truncated. Signatures are left out unless they are
+// queried, as the records may be changed before they are served.
+func parseAnswers(payload []byte, qType dnsmessage.Type) (*answersRecord, error) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(payload); err != nil {
+		return nil, errors.New("failed to parse DNS response").Base(err).AtWarning()
+	}
+	// The answers of a truncated response may be incomplete, even none.
+	if msg.Truncated {
+		return nil, errors.New("truncated response").AtWarning()
+	}
+
+	now := time.Now()
+	rec := &answersRecord{
+		RCode: msg.RCode,
+	}
+	for _, ans := range msg.Answers {
+		if ans.Header.Type == dnsmessage.Type(dns.TypeRRSIG) && qType != ans.Header.Type {
+			continue
+		}
+		ttl := ans.Header.TTL
+		if ttl == 0 {
+			ttl = 1
+		}
+		expire := now.Add(time.Duration(ttl) * time.Second)
+		if rec.Expire.IsZero() || rec.Expire.After(expire) {
+			rec.Expire = expire
+		}
+		rec.Answers = append(rec.Answers, ans)
+	}
+	// set to default TTL if no valid TTL is found
+	if rec.Expire.IsZero() {
+		rec.Expire = now.Add(time.Second * dns_feature.DefaultTTL)
+	}
+
+	return rec, nil
+}
+
+func (c *CacheController) findAnswers(domain string, qType dnsmessage.Type) *answersRecord {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.answers[answersKey{domain, qType}]
+}
+
+func (c *CacheController) updateAnswers(domain string, qType dnsmessage.Type, rec *answersRecord, rtt time.Duration) {
+	if c.disableCache {
+		errors.LogInfo(context.Background(), c.name, " got answer: ", domain, " ", qType, " -> ", len(rec.Answers), " records, rtt: ", rtt)
+		return
+	}
+
+	c.Lock()
+	c.answers[answersKey{domain, qType}] = rec
+	c.Unlock()
+
+	errors.LogInfo(context.Background(), c.name, " got answer: ", domain, " ", qType, " -> ", len(rec.Answers), " records, rtt: ", rtt)
+
+	if !c.serveStale || c.serveExpiredTTL != 0 {
+		common.Must(c.answersCleanup.Start())
+	}
+}
+
+// cleanupAnswers clears expired answers from cache.
+func (c *CacheController) cleanupAnswers() error {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.answers) == 0 {
+		return errors.New("nothing to do. stopping...")
+	}
+
+	now := time.Now()
+	if c.serveStale && c.serveExpiredTTL != 0 {
+		now = now.Add(time.Duration(c.serveExpiredTTL) * time.Second)
+	}
+	for key, rec := range c.answers {
+		if rec.Expire.Before(now) {
+			delete(c.answers, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package dns_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	"github.com/xtls/xray-core/app/dispatcher"
+	. "github.com/xtls/xray-core/app/dns"
+	"github.com/xtls/xray-core/app/dns/fakedns"
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/geodata"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	feature_dns "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/testing/servers/tcp"
+	"github.com/xtls/xray-core/testing/servers/udp"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type recordsHandler struct {
+	queries atomic.Int32
+}
+
+func (h *recordsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	h.queries.Add(1)
+
+	ans := new(dns.Msg)
+	ans.SetReply(r)
+	q := r.Question[0]
+	switch {
+	case q.Name == "svc.example." && q.Qtype == dns.TypeHTTPS:
+		ans.Answer = append(ans.Answer, newRR(`svc.example. 300 IN HTTPS 1 . alpn="h2" ipv4hint="8.8.8.8,1.2.3.4" ech="AQID" ipv6hint="2001:db8::1"`))
+	case q.Name == "cname.example." && q.Qtype == dns.TypeHTTPS:
+		ans.Answer = append(ans.Answer,
+			newRR("cname.example. 300 IN CNAME svc.example."),
+			newRR(`svc.example. 300 IN HTTPS 1 . alpn="h2" ipv4hint="1.2.3.4"`))
+	case q.Name == "fake.example." && q.Qtype == dns.TypeHTTPS:
+		ans.Answer = append(ans.Answer, newRR(`fake.example. 300 IN HTTPS 1 . alpn="h2" ipv4hint="8.8.8.8"`))
+	case q.Name == "txt.example." && q.Qtype == dns.TypeTXT:
+		ans.Answer = append(ans.Answer, newRR(`txt.example. 300 IN TXT "hello"`))
+	case q.Name == "notexist.example.":
+		ans.Rcode = dns.RcodeNameError
+	}
+	w.WriteMsg(ans)
+}
+
+func TestLookupRecords(t *testing.T) {
+	port := udp.PickPort()
+	handler := &recordsHandler{}
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: handler,
+		UDPSize: 1200,
+	}
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+	defer dnsServer.Shutdown()
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&fakedns.FakeDnsPool{
+				IpPool:  "198.18.0.0/15",
+				LruSize: 256,
+			}),
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{127, 0, 0, 1},
+								},
+							},
+							Port: uint32(port),
+						},
+						ExpectedIp: []*geodata.IPRule{
+							{Value: &geodata.IPRule_Custom{Custom: &geodata.CIDRRule{Cidr: &geodata.CIDR{Ip: []byte{8, 8, 8, 8}, Prefix: 32}}}},
+						},
+						QueryStrategy: QueryStrategy_USE_IP4,
+					},
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Domain{
+									Domain: "fakedns",
+								},
+							},
+						},
+						Domain: []*geodata.DomainRule{
+							{Value: &geodata.DomainRule_Custom{Custom: &geodata.Domain{Type: geodata.Domain_Full, Value: "fake.example"}}},
+						},
+					},
+				},
+				StaticHosts: []*Config_HostMapping{
+					{
+						Domain:        &geodata.DomainRule{Value: &geodata.DomainRule_Custom{Custom: &geodata.Domain{Type: geodata.Domain_Full, Value: "alias.example"}}},
+						ProxiedDomain: "svc.example",
+					},
+				},
+			}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{
+					FinalRules: []*freedom.FinalRuleConfig{{Action: freedom.RuleAction_Allow}},
+				}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.RecordClient)
+
+	// The hints are filtered by the expected IPs and query strategy.
+	expected := dnsmessage.SVCBResource{
+		Priority: 1,
+		Target:   dnsmessage.MustNewName("."),
+		Params: []dnsmessage.SVCParam{
+			{Key: dnsmessage.SVCParamALPN, Value: []byte("\x02h2")},
+			{Key: dnsmessage.SVCParamIPv4Hint, Value: []byte{8, 8, 8, 8}},
+			{Key: dnsmessage.SVCParamECH, Value: []byte{1, 2, 3}},
+		},
+	}
+	for range 2 {
+		answers, ttl, err := client.LookupRecords("svc.example", dnsmessage.TypeHTTPS)
+		common.Must(err)
+		if len(answers) != 1 || ttl == 0 || ttl > 300 {
+			t.Fatal("unexpected answers ", answers, " with TTL ", ttl)
+		}
+		if r := cmp.Diff(answers[0].Body, &dnsmessage.HTTPSResource{SVCBResource: expected}); r != "" {
+			t.Error(r)
+		}
+	}
+	if n := handler.queries.Load(); n != 1 {
+		t.Error("expected cached answer, but got ", n, " queries")
+	}
+
+	// The CNAME records are answered, and the hints not expected are removed.
+	answers, _, err := client.LookupRecords("cname.example", dnsmessage.TypeHTTPS)
+	common.Must(err)
+	if len(answers) != 2 || answers[0].Header.Type != dnsmessage.TypeCNAME {
+		t.Fatal("unexpected answers ", answers)
+	}
+	if _, found := answers[1].Body.(*dnsmessage.HTTPSResource).GetParam(dnsmessage.SVCParamIPv4Hint); found {
+		t.Error("expected ipv4hint removed")
+	}
+
+	// The domain replaced by static hosts is answered with a CNAME record.
+	answers, _, err = client.LookupRecords("alias.example", dnsmessage.TypeHTTPS)
+	common.Must(err)
+	if len(answers) != 2 || answers[0].Body.(*dnsmessage.CNAMEResource).CNAME.String() != "svc.example." {
+		t.Fatal("unexpected answers ", answers)
+	}
+
+	// The domain of FakeDNS is answered without IP hints, which would bypass
+	// its fake IPs.
+	answers, _, err = client.LookupRecords("fake.example", dnsmessage.TypeHTTPS)
+	common.Must(err)
+	if len(answers) != 1 {
+		t.Fatal("unexpected answers ", answers)
+	}
+	if _, found := answers[0].Body.(*dnsmessage.HTTPSResource).GetParam(dnsmessage.SVCParamIPv4Hint); found {
+		t.Error("expected ipv4hint removed")
+	}
+
+	answers, _, err = client.LookupRecords("txt.example.", dnsmessage.TypeTXT)
+	common.Must(err)
+	if r := cmp.Diff(answers[0].Body, &dnsmessage.TXTResource{TXT: []string{"hello"}}); r != "" {
+		t.Error(r)
+	}
+
+	if _, _, err := client.LookupRecords("notexist.example", dnsmessage.TypeHTTPS); feature_dns.RCodeFromError(err) != uint16(dnsmessage.RCodeNameError) {
+		t.Error("expected NXDOMAIN, but got ", err)
+	}
+
+	if _, _, err := client.LookupRecords("svc.example", dnsmessage.TypeTXT); !errors.Is(err, feature_dns.ErrEmptyResponse) {
+		t.Error("expected empty response, but got ", err)
+	}
+}
+
+func newRecordsInstance(address *net.Endpoint) *core.Instance {
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address:   address,
+						TimeoutMs: 2000,
+					},
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{
+					FinalRules: []*freedom.FinalRuleConfig{{Action: freedom.RuleAction_Allow}},
+				}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	return v
+}
+
+func TestLookupRecordsTruncated(t *testing.T) {
+	port := udp.PickPort()
+	tcpPort := tcp.PickPort()
+	handler := &recordsHandler{}
+
+	// The TXT responses are complete over TCP only, but always truncated at
+	// tcpPort.
+	udpServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &truncatingHandler{Handler: handler, qtype: dns.TypeTXT},
+		UDPSize: 1200,
+	}
+	tcpServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "tcp",
+		Handler: handler,
+	}
+	truncatingServer := dns.Server{
+		Addr:    "127.0.0.1:" + tcpPort.String(),
+		Net:     "tcp",
+		Handler: &truncatingHandler{Handler: handler, qtype: dns.TypeTXT},
+	}
+	go udpServer.ListenAndServe()
+	go tcpServer.ListenAndServe()
+	go truncatingServer.ListenAndServe()
+	time.Sleep(time.Second)
+	defer udpServer.Shutdown()
+	defer tcpServer.Shutdown()
+	defer truncatingServer.Shutdown()
+
+	v := newRecordsInstance(&net.Endpoint{
+		Network: net.Network_UDP,
+		Address: &net.IPOrDomain{
+			Address: &net.IPOrDomain_Ip{
+				Ip: []byte{127, 0, 0, 1},
+			},
+		},
+		Port: uint32(port),
+	})
+	defer v.Close()
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.RecordClient)
+
+	answers, _, err := client.LookupRecords("txt.example", dnsmessage.TypeTXT)
+	common.Must(err)
+	if len(answers) != 1 {
+		t.Fatal("unexpected answers ", answers)
+	}
+
+	// The truncated responses are not cached as empty.
+	v = newRecordsInstance(&net.Endpoint{
+		Network: net.Network_UDP,
+		Address: &net.IPOrDomain{
+			Address: &net.IPOrDomain_Domain{
+				Domain: "tcp://127.0.0.1:" + tcpPort.String(),
+			},
+		},
+	})
+	defer v.Close()
+	client = v.GetFeature(feature_dns.ClientType()).(feature_dns.RecordClient)
+
+	for range 2 {
+		if _, _, err := client.LookupRecords("txt.example", dnsmessage.TypeTXT); err == nil || errors.Is(err, feature_dns.ErrEmptyResponse) {
+			t.Error("expected error of truncated response, but got ", err)
+		}
+	}
+}
@@ -1,14 +1,13 @@
 package dns
 
 import (
-	"bytes"
 	"context"
 	"encoding/binary"
+	"io"
 	"net/url"
 	"sync/atomic"
 	"time"
 
-	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/net/cnc"
@@ -17,6 +16,7 @@ import (
 	dns_feature "github.com/xtls/xray-core/features/dns"
 	"github.com/xtls/xray-core/features/routing"
 	"github.com/xtls/xray-core/transport/internet"
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 // TCPNameServer implemented DNS over TCP (RFC7766).
@@ -26,6 +26,7 @@ type TCPNameServer struct {
 	reqID           uint32
 	dial            func(context.Context) (net.Conn, error)
 	clientIP        net.IP
+	dnssec          *dnssecValidator
 }
 
 // NewTCPNameServer creates DNS over TCP server object for remote resolving.
@@ -109,11 +110,36 @@ func (s *TCPNameServer) getCacheController() *CacheController {
 	return s.cacheController
 }
 
+// setDNSSECValidator implements dnssecNameServer.
+func (s *TCPNameServer) setDNSSECValidator(v *dnssecValidator) {
+	s.dnssec = v
+}
+
+// exchange implements dnssecNameServer.
+func (s *TCPNameServer) exchange(ctx context.Context, msg *dnsmessage.Message) ([]byte, error) {
+	b, err := dns.PackMessage(msg)
+	if err != nil {
+		return nil, errors.New("failed to pack dns query").Base(err)
+	}
+	defer b.Release()
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return nil, errors.New("failed to dial namesever").Base(err)
+	}
+	defer conn.Close()
+
+	if err := writeTCPMessage(conn, b.Bytes()); err != nil {
+		return nil, errors.New("failed to send query").Base(err)
+	}
+	return readTCPMessage(conn)
+}
+
 // sendQuery implements CachedNameserver.
 func (s *TCPNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<- error, fqdn string, option dns_feature.IPOption) {
 	errors.LogInfo(ctx, s.Name(), " querying DNS for: ", fqdn)
 
-	reqs, err := buildReqMsgs(fqdn, option, s.newReqID, genEDNS0Options(s.clientIP, 0))
+	reqs, err := buildReqMsgs(fqdn, option, s.newReqID, genEDNS0Options(s.clientIP, 0, s.dnssec != nil))
 	if err != nil {
 		errors.LogErrorInner(ctx, err, "failed to build dns query for ", fqdn)
 		if noResponseErrCh != nil {
@@ -151,83 +177,24 @@ func (s *TCPNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<- er
 			dnsCtx, cancel = context.WithDeadline(dnsCtx, deadline)
 			defer cancel()
 
-			b, err := dns.PackMessage(r.msg)
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to pack dns query")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-
-			conn, err := s.dial(dnsCtx)
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to dial namesever")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-			defer conn.Close()
-			dnsReqBuf := buf.New()
-			err = binary.Write(dnsReqBuf, binary.BigEndian, uint16(b.Len()))
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "binary write failed")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-			_, err = dnsReqBuf.Write(b.Bytes())
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "buffer write failed")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-			b.Release()
-
-			_, err = conn.Write(dnsReqBuf.Bytes())
+			resp, err := s.exchange(dnsCtx, r.msg)
 			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to send query")
+				errors.LogErrorInner(ctx, err, "failed to exchange query")
 				if noResponseErrCh != nil {
 					noResponseErrCh <- err
 				}
 				return
 			}
-			dnsReqBuf.Release()
 
-			respBuf := buf.New()
-			defer respBuf.Release()
-			n, err := respBuf.ReadFullFrom(conn, 2)
-			if err != nil && n == 0 {
-				errors.LogErrorInner(ctx, err, "failed to read response length")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-			var length uint16
-			err = binary.Read(bytes.NewReader(respBuf.Bytes()), binary.BigEndian, &length)
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to parse response length")
-				if noResponseErrCh != nil {
-					noResponseErrCh <- err
-				}
-				return
-			}
-			respBuf.Clear()
-			n, err = respBuf.ReadFullFrom(conn, int32(length))
-			if err != nil && n == 0 {
-				errors.LogErrorInner(ctx, err, "failed to read response length")
+			if err := s.dnssec.validate(dnsCtx, resp); err != nil {
+				errors.LogWarningInner(ctx, err, s.Name(), " got bogus response for ", fqdn)
 				if noResponseErrCh != nil {
 					noResponseErrCh <- err
 				}
 				return
 			}
 
-			rec, err := parseResponse(respBuf.Bytes())
+			rec, err := parseResponse(resp)
 			if err != nil {
 				errors.LogErrorInner(ctx, err, "failed to parse DNS over TCP response")
 				if noResponseErrCh != nil {
@@ -245,3 +212,36 @@ func (s *TCPNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<- er
 func (s *TCPNameServer) QueryIP(ctx context.Context, domain string, option dns_feature.IPOption) ([]net.IP, uint32, error) {
 	return queryIP(ctx, s, domain, option)
 }
+
+// QueryRecords implements RecordServer.
+func (s *TCPNameServer) QueryRecords(ctx context.Context, domain string, qType dnsmessage.Type) ([]dnsmessage.Resource, uint32, error) {
+	ctx = session.ContextWithContent(ctx, &session.Content{
+		Protocol:       "dns",
+		SkipDNSResolve: true,
+	})
+	return queryRecords(ctx, s, domain, qType, genEDNS0Options(s.clientIP, 0, s.dnssec != nil), s.dnssec)
+}
+
+// writeTCPMessage writes a DNS message prefixed with its length, as in DNS over
+// TCP and DNS over TLS.
+func writeTCPMessage(w io.Writer, msg []byte) error {
+	b := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(b, uint16(len(msg)))
+	copy(b[2:], msg)
+	_, err := w.Write(b)
+	return err
+}
+
+// readTCPMessage reads a DNS message prefixed with its length, as in DNS over
+// TCP and DNS over TLS.
+func readTCPMessage(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, errors.New("failed to read response length").Base(err)
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, errors.New("failed to read response").Base(err)
+	}
+	return msg, nil
+}
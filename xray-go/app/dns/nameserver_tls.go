@@ -0,0 +1,410 @@
+package dns
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/crypto"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/net/cnc"
+	"github.com/xtls/xray-core/common/protocol/dns"
+	"github.com/xtls/xray-core/common/session"
+	dns_feature "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// TLSNameServer implemented DNS over TLS (RFC7858). Queries are pipelined on a
+// connection kept alive until either side closes it.
+type TLSNameServer struct {
+	sync.Mutex
+	cacheController *CacheController
+	destination     *net.Destination
+	reqID           uint32
+	dial            func(context.Context) (net.Conn, error)
+	tlsConfig       *gotls.Config
+	clientIP        net.IP
+	conn            *tlsConn
+	closed          bool
+	dnssec          *dnssecValidator
+}
+
+// NewTLSNameServer creates DNS over TLS server object for remote resolving.
+func NewTLSNameServer(
+	url *url.URL,
+	dispatcher routing.Dispatcher,
+	disableCache bool, serveStale bool, serveExpiredTTL uint32,
+	clientIP net.IP,
+) (*TLSNameServer, error) {
+	s, err := baseTLSNameServer(url, "DOT", disableCache, serveStale, serveExpiredTTL, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dial = func(ctx context.Context) (net.Conn, error) {
+		link, err := dispatcher.Dispatch(toDnsContext(ctx, s.destination.String()), *s.destination)
+		if err != nil {
+			return nil, err
+		}
+
+		cc := common.ChainedClosable{}
+		if cw, ok := link.Writer.(common.Closable); ok {
+			cc = append(cc, cw)
+		}
+		if cr, ok := link.Reader.(common.Closable); ok {
+			cc = append(cc, cr)
+		}
+		return cnc.NewConnection(
+			cnc.ConnectionInputMulti(link.Writer),
+			cnc.ConnectionOutputMulti(link.Reader),
+			cnc.ConnectionOnClose(cc),
+		), nil
+	}
+
+	errors.LogInfo(context.Background(), "DNS: created TLS client initialized for ", url.String())
+	return s, nil
+}
+
+// NewTLSLocalNameServer creates DNS over TLS client object for local resolving
+func NewTLSLocalNameServer(url *url.URL, disableCache bool, serveStale bool, serveExpiredTTL uint32, clientIP net.IP) (*TLSNameServer, error) {
+	s, err := baseTLSNameServer(url, "DOTL", disableCache, serveStale, serveExpiredTTL, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dial = func(ctx context.Context) (net.Conn, error) {
+		log.Record(&log.AccessMessage{
+			From:   "DNS",
+			To:     s.destination,
+			Status: log.AccessAccepted,
+			Detour: "local",
+		})
+		return internet.DialSystem(ctx, *s.destination, nil)
+	}
+
+	errors.LogInfo(context.Background(), "DNS: created Local TLS client initialized for ", url.String())
+	return s, nil
+}
+
+// baseTLSNameServer creates the server of the url, which may have the query
+// parameters "sni" for the server name, and "pcs" for the comma separated
+// SHA-256 hashes of pinned certificates.
+func baseTLSNameServer(url *url.URL, prefix string, disableCache bool, serveStale bool, serveExpiredTTL uint32, clientIP net.IP) (*TLSNameServer, error) {
+	port := net.Port(853)
+	if url.Port() != "" {
+		var err error
+		if port, err = net.PortFromString(url.Port()); err != nil {
+			return nil, err
+		}
+	}
+	dest := net.TCPDestination(net.ParseAddress(url.Hostname()), port)
+
+	config := &tls.Config{
+		ServerName: url.Query().Get("sni"),
+	}
+	if pcs := url.Query().Get("pcs"); pcs != "" {
+		for v := range strings.SplitSeq(pcs, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			hash, err := hex.DecodeString(strings.ReplaceAll(v, ":", ""))
+			if err != nil {
+				return nil, errors.New("invalid pinned certificate hash: ", v).Base(err)
+			}
+			if len(hash) != 32 {
+				return nil, errors.New("incorrect pinned certificate hash length: ", v)
+			}
+			config.PinnedPeerCertSha256 = append(config.PinnedPeerCertSha256, hash)
+		}
+	}
+
+	s := &TLSNameServer{
+		cacheController: NewCacheController(prefix+"//"+dest.NetAddr(), disableCache, serveStale, serveExpiredTTL),
+		destination:     &dest,
+		tlsConfig:       config.GetTLSConfig(tls.WithDestination(dest)),
+		clientIP:        clientIP,
+	}
+
+	return s, nil
+}
+
+// Name implements Server.
+func (s *TLSNameServer) Name() string {
+	return s.cacheController.name
+}
+
+// IsDisableCache implements Server.
+func (s *TLSNameServer) IsDisableCache() bool {
+	return s.cacheController.disableCache
+}
+
+func (s *TLSNameServer) newReqID() uint16 {
+	return uint16(atomic.AddUint32(&s.reqID, 1))
+}
+
+// getCacheController implements CachedNameserver.
+func (s *TLSNameServer) getCacheController() *CacheController {
+	return s.cacheController
+}
+
+// Close implements common.Closable. It closes the kept-alive connection, and
+// no more connection is dialed.
+func (s *TLSNameServer) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.closed = true
+	if s.conn != nil {
+		s.conn.close()
+		s.conn = nil
+	}
+	return nil
+}
+
+// getConn returns the kept-alive connection, or dials a new one if it is
+// closed.
+func (s *TLSNameServer) getConn(ctx context.Context) (*tlsConn, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return nil, errors.New("name server closed")
+	}
+	if s.conn != nil && !s.conn.isClosed() {
+		return s.conn, nil
+	}
+
+	rawConn, err := s.dial(ctx)
+	if err != nil {
+		return nil, errors.New("failed to dial namesever").Base(err)
+	}
+	conn := gotls.Client(rawConn, s.tlsConfig)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		rawConn.Close()
+		return nil, errors.New("failed TLS handshake with nameserver").Base(err)
+	}
+
+	s.conn = newTLSConn(conn)
+	return s.conn, nil
+}
+
+// setDNSSECValidator implements dnssecNameServer.
+func (s *TLSNameServer) setDNSSECValidator(v *dnssecValidator) {
+	s.dnssec = v
+}
+
+// exchange implements dnssecNameServer.
+func (s *TLSNameServer) exchange(ctx context.Context, msg *dnsmessage.Message) ([]byte, error) {
+	b, err := dns.PackMessage(msg)
+	if err != nil {
+		return nil, errors.New("failed to pack dns query").Base(err)
+	}
+	defer b.Release()
+
+	conn, err := s.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn.exchange(ctx, msg.ID, b.Bytes())
+}
+
+// sendQuery implements CachedNameserver.
+func (s *TLSNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<- error, fqdn string, option dns_feature.IPOption) {
+	errors.LogInfo(ctx, s.Name(), " querying DNS for: ", fqdn)
+
+	reqs, err := buildReqMsgs(fqdn, option, s.newReqID, genEDNS0Options(s.clientIP, int(crypto.RandBetween(100, 300)), s.dnssec != nil))
+	if err != nil {
+		errors.LogErrorInner(ctx, err, "failed to build dns query for ", fqdn)
+		if noResponseErrCh != nil {
+			if option.IPv4Enable {
+				noResponseErrCh <- err
+			}
+			if option.IPv6Enable {
+				noResponseErrCh <- err
+			}
+		}
+		return
+	}
+
+	var deadline time.Time
+	if d, ok := ctx.Deadline(); ok {
+		deadline = d
+	} else {
+		deadline = time.Now().Add(time.Second * 5)
+	}
+
+	for _, req := range reqs {
+		go func(r *dnsRequest) {
+			dnsCtx := ctx
+
+			if inbound := session.InboundFromContext(ctx); inbound != nil {
+				dnsCtx = session.ContextWithInbound(dnsCtx, inbound)
+			}
+
+			dnsCtx = session.ContextWithContent(dnsCtx, &session.Content{
+				Protocol:       "tls",
+				SkipDNSResolve: true,
+			})
+
+			var cancel context.CancelFunc
+			dnsCtx, cancel = context.WithDeadline(dnsCtx, deadline)
+			defer cancel()
+
+			resp, err := s.exchange(dnsCtx, r.msg)
+			if err != nil {
+				errors.LogErrorInner(ctx, err, "failed to exchange query")
+				if noResponseErrCh != nil {
+					noResponseErrCh <- err
+				}
+				return
+			}
+
+			if err := s.dnssec.validate(dnsCtx, resp); err != nil {
+				errors.LogWarningInner(ctx, err, s.Name(), " got bogus response for ", fqdn)
+				if noResponseErrCh != nil {
+					noResponseErrCh <- err
+				}
+				return
+			}
+
+			rec, err := parseResponse(resp)
+			if err != nil {
+				errors.LogErrorInner(ctx, err, "failed to parse DNS over TLS response")
+				if noResponseErrCh != nil {
+					noResponseErrCh <- err
+				}
+				return
+			}
+
+			s.cacheController.updateRecord(r, rec)
+		}(req)
+	}
+}
+
+// QueryIP implements Server.
+func (s *TLSNameServer) QueryIP(ctx context.Context, domain string, option dns_feature.IPOption) ([]net.IP, uint32, error) {
+	return queryIP(ctx, s, domain, option)
+}
+
+// QueryRecords implements RecordServer.
+func (s *TLSNameServer) QueryRecords(ctx context.Context, domain string, qType dnsmessage.Type) ([]dnsmessage.Resource, uint32, error) {
+	ctx = session.ContextWithContent(ctx, &session.Content{
+		Protocol:       "tls",
+		SkipDNSResolve: true,
+	})
+	return queryRecords(ctx, s, domain, qType, genEDNS0Options(s.clientIP, int(crypto.RandBetween(100, 300)), s.dnssec != nil), s.dnssec)
+}
+
+// tlsConn is a connection to a DNS over TLS server, on which queries are
+// pipelined and their responses are matched by IDs.
+type tlsConn struct {
+	conn net.Conn
+
+	writeAccess sync.Mutex
+
+	access  sync.Mutex
+	pending map[uint16]chan []byte
+	closed  bool
+}
+
+func newTLSConn(conn net.Conn) *tlsConn {
+	c := &tlsConn{
+		conn:    conn,
+		pending: make(map[uint16]chan []byte),
+	}
+	go c.readResponses()
+	return c
+}
+
+func (c *tlsConn) isClosed() bool {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.closed
+}
+
+// exchange sends the query of id, and waits for its response.
+func (c *tlsConn) exchange(ctx context.Context, id uint16, query []byte) ([]byte, error) {
+	ch := make(chan []byte, 1)
+	c.access.Lock()
+	if c.closed {
+		c.access.Unlock()
+		return nil, errors.New("connection closed")
+	}
+	c.pending[id] = ch
+	c.access.Unlock()
+
+	defer func() {
+		c.access.Lock()
+		if c.pending[id] == ch {
+			delete(c.pending, id)
+		}
+		c.access.Unlock()
+	}()
+
+	c.writeAccess.Lock()
+	err := writeTCPMessage(c.conn, query)
+	c.writeAccess.Unlock()
+	if err != nil {
+		c.close()
+		return nil, errors.New("failed to send query").Base(err)
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, errors.New("connection closed before response")
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *tlsConn) readResponses() {
+	defer c.close()
+
+	for {
+		resp, err := readTCPMessage(c.conn)
+		if err != nil {
+			return
+		}
+		if len(resp) < 2 {
+			continue
+		}
+		id := uint16(resp[0])<<8 | uint16(resp[1])
+
+		c.access.Lock()
+		if ch, found := c.pending[id]; found {
+			delete(c.pending, id)
+			ch <- resp
+		}
+		c.access.Unlock()
+	}
+}
+
+func (c *tlsConn) close() {
+	c.access.Lock()
+	defer c.access.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.conn.Close()
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
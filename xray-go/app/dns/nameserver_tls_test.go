@@ -0,0 +1,220 @@
+package dns_test
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"encoding/hex"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	"github.com/xtls/xray-core/app/dispatcher"
+	. "github.com/xtls/xray-core/app/dns"
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	feature_dns "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/proxy/freedom"
+	_ "github.com/xtls/xray-core/transport/internet/tcp"
+)
+
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int32
+	// closed receives a value when an accepted connection is closed by the
+	// client.
+	closed chan struct{}
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+		conn = &closeNotifyConn{Conn: conn, closed: l.closed}
+	}
+	return conn, err
+}
+
+type closeNotifyConn struct {
+	net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (c *closeNotifyConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err != nil {
+		c.once.Do(func() { c.closed <- struct{}{} })
+	}
+	return n, err
+}
+
+// startTLSServer starts a DNS over TLS stub with the certificate of
+// "dns.example", and returns its listener and the hash of its certificate.
+func startTLSServer(t *testing.T) (*countingListener, string) {
+	ct, ctHash := cert.MustGenerate(nil, cert.CommonName("dns.example"), cert.DNSNames("dns.example"))
+	certificate, err := gotls.X509KeyPair(ct.ToPEM())
+	common.Must(err)
+	tlsListener, err := gotls.Listen("tcp", "127.0.0.1:0", &gotls.Config{
+		Certificates: []gotls.Certificate{certificate},
+	})
+	common.Must(err)
+	listener := &countingListener{Listener: tlsListener, closed: make(chan struct{}, 16)}
+
+	dnsServer := &dns.Server{
+		Listener: listener,
+		Net:      "tcp-tls",
+		Handler:  &staticHandler{},
+	}
+	go dnsServer.ActivateAndServe()
+	t.Cleanup(func() { dnsServer.Shutdown() })
+	return listener, hex.EncodeToString(ctHash[:])
+}
+
+func TestTLSLocalNameServer(t *testing.T) {
+	listener, hash := startTLSServer(t)
+
+	url, err := url.Parse("tls+local://" + listener.Addr().String() + "?sni=dns.example&pcs=" + hash)
+	common.Must(err)
+	s, err := NewTLSLocalNameServer(url, true, false, 0, net.IP(nil))
+	common.Must(err)
+
+	for _, domain := range []string{"google.com", "facebook.com", "ipv6.google.com"} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		ips, _, err := s.QueryIP(ctx, domain, feature_dns.IPOption{
+			IPv4Enable: true,
+			IPv6Enable: true,
+		})
+		cancel()
+		common.Must(err)
+		if len(ips) == 0 {
+			t.Error("expect some ips of ", domain, ", but got 0")
+		}
+	}
+
+	if n := listener.accepted.Load(); n != 1 {
+		t.Error("expected 1 kept-alive connection, but actually ", n)
+	}
+}
+
+func TestTLSLocalNameServerClose(t *testing.T) {
+	listener, hash := startTLSServer(t)
+
+	url, err := url.Parse("tls+local://" + listener.Addr().String() + "?sni=dns.example&pcs=" + hash)
+	common.Must(err)
+	s, err := NewTLSLocalNameServer(url, true, false, 0, net.IP(nil))
+	common.Must(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, _, err = s.QueryIP(ctx, "google.com", feature_dns.IPOption{
+		IPv4Enable: true,
+	})
+	common.Must(err)
+
+	common.Must(s.Close())
+	select {
+	case <-listener.closed:
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected the connection closed")
+	}
+
+	if _, _, err := s.QueryIP(ctx, "facebook.com", feature_dns.IPOption{
+		IPv4Enable: true,
+	}); err == nil {
+		t.Error("expected error of closed name server")
+	}
+	if n := listener.accepted.Load(); n != 1 {
+		t.Error("expected no more connection, but actually ", n)
+	}
+}
+
+func TestTLSLocalNameServerUnpinnedCert(t *testing.T) {
+	listener, _ := startTLSServer(t)
+
+	for _, query := range []string{
+		"?sni=dns.example&pcs=" + hex.EncodeToString(make([]byte, 32)),
+		"?sni=dns.example",
+	} {
+		url, err := url.Parse("tls+local://" + listener.Addr().String() + query)
+		common.Must(err)
+		s, err := NewTLSLocalNameServer(url, true, false, 0, net.IP(nil))
+		common.Must(err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		_, _, err = s.QueryIP(ctx, "google.com", feature_dns.IPOption{
+			IPv4Enable: true,
+		})
+		cancel()
+		if err == nil {
+			t.Error("expected error of untrusted certificate for ", query)
+		}
+	}
+}
+
+func TestTLSNameServerInvalidPinnedCert(t *testing.T) {
+	url, err := url.Parse("tls://1.1.1.1?pcs=abcd")
+	common.Must(err)
+	if _, err := NewTLSNameServer(url, nil, false, false, 0, net.IP(nil)); err == nil {
+		t.Error("expected error of invalid pinned certificate hash")
+	}
+}
+
+func TestTLSNameServer(t *testing.T) {
+	listener, hash := startTLSServer(t)
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Domain{
+									Domain: "tls://127.0.0.1:" + net.Port(port).String() + "?sni=dns.example&pcs=" + hash,
+								},
+							},
+						},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{
+					FinalRules: []*freedom.FinalRuleConfig{{Action: freedom.RuleAction_Allow}},
+				}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	defer v.Close()
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+
+	ips, _, err := client.LookupIP("google.com", feature_dns.IPOption{
+		IPv4Enable: true,
+		IPv6Enable: true,
+	})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if r := cmp.Diff(ips, []net.IP{{8, 8, 8, 8}}); r != "" {
+		t.Fatal(r)
+	}
+}
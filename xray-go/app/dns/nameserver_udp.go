@@ -10,6 +10,7 @@ import (
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/net/cnc"
 	"github.com/xtls/xray-core/common/protocol/dns"
 	udp_proto "github.com/xtls/xray-core/common/protocol/udp"
 	"github.com/xtls/xray-core/common/task"
@@ -26,14 +27,19 @@ type ClassicNameServer struct {
 	address         *net.Destination
 	requests        map[uint16]*udpDnsRequest
 	udpServer       *udp.Dispatcher
+	dispatcher      routing.Dispatcher
 	requestsCleanup *task.Periodic
 	reqID           uint32
 	clientIP        net.IP
+	dnssec          *dnssecValidator
 }
 
 type udpDnsRequest struct {
 	dnsRequest
-	ctx context.Context
+	ctx             context.Context
+	noResponseErrCh chan<- error
+	// resp receives the raw response if set, instead of the cache.
+	resp chan []byte
 }
 
 // NewClassicNameServer creates udp server object for remote resolving.
@@ -47,6 +53,7 @@ func NewClassicNameServer(address net.Destination, dispatcher routing.Dispatcher
 		cacheController: NewCacheController(strings.ToUpper(address.String()), disableCache, serveStale, serveExpiredTTL),
 		address:         &address,
 		requests:        make(map[uint16]*udpDnsRequest),
+		dispatcher:      dispatcher,
 		clientIP:        clientIP,
 	}
 	s.requestsCleanup = &task.Periodic{
@@ -69,6 +76,11 @@ func (s *ClassicNameServer) IsDisableCache() bool {
 	return s.cacheController.disableCache
 }
 
+// Close implements common.Closable.
+func (s *ClassicNameServer) Close() error {
+	return s.requestsCleanup.Close()
+}
+
 // RequestsCleanup clears expired items from cache
 func (s *ClassicNameServer) RequestsCleanup() error {
 	now := time.Now()
@@ -95,8 +107,8 @@ func (s *ClassicNameServer) RequestsCleanup() error {
 // HandleResponse handles udp response packet from remote DNS server.
 func (s *ClassicNameServer) HandleResponse(ctx context.Context, packet *udp_proto.Packet) {
 	payload := packet.Payload
+	defer payload.Release()
 	ipRec, err := parseResponse(payload.Bytes())
-	payload.Release()
 	if err != nil {
 		errors.LogErrorInner(ctx, err, s.Name(), " fail to parse responded DNS udp")
 		return
@@ -117,7 +129,7 @@ func (s *ClassicNameServer) HandleResponse(ctx context.Context, packet *udp_prot
 
 	// if truncated, retry with EDNS0 option(udp payload size: 1350)
 	if ipRec.RawHeader.Truncated {
-		// if already has EDNS0 option, no need to retry
+		// if already has EDNS0 option, retry over TCP
 		if len(req.msg.Additionals) == 0 {
 			// copy necessary meta data from original request
 			// and add EDNS0 option
@@ -134,11 +146,99 @@ func (s *ClassicNameServer) HandleResponse(ctx context.Context, packet *udp_prot
 			s.udpServer.Dispatch(toDnsContext(newReq.ctx, s.address.String()), *s.address, b)
 			return
 		}
+		go s.retryTCP(req, ipRec)
+		return
+	}
+
+	s.handleResponse(req, ipRec, payload.Bytes())
+}
+
+// handleResponse delivers the complete response of req, validating it first
+// if DNSSEC is enabled.
+func (s *ClassicNameServer) handleResponse(req *udpDnsRequest, ipRec *IPRecord, resp []byte) {
+	if req.resp != nil {
+		req.resp <- append([]byte(nil), resp...)
+		return
+	}
+
+	if s.dnssec != nil {
+		// Validation queries are answered here as well, so it waits elsewhere.
+		resp := append([]byte(nil), resp...)
+		go func() {
+			if err := s.dnssec.validate(req.ctx, resp); err != nil {
+				errors.LogWarningInner(req.ctx, err, s.Name(), " got bogus response for ", req.domain)
+				if req.noResponseErrCh != nil {
+					req.noResponseErrCh <- err
+				}
+				return
+			}
+			s.cacheController.updateRecord(&req.dnsRequest, ipRec)
+		}()
+		return
 	}
 
 	s.cacheController.updateRecord(&req.dnsRequest, ipRec)
 }
 
+// retryTCP sends req again over TCP, as its response is truncated even with
+// EDNS0.
+func (s *ClassicNameServer) retryTCP(req *udpDnsRequest, truncated *IPRecord) {
+	name := req.msg.Questions[0].Name.String()
+	errors.LogDebug(req.ctx, s.Name(), " retrying truncated response over TCP for ", name)
+
+	resp, err := s.exchangeTCP(req.ctx, req.msg)
+	var ipRec *IPRecord
+	if err == nil {
+		ipRec, err = parseResponse(resp)
+	}
+	if err != nil {
+		// The IPs of a truncated response are still usable, but a truncated
+		// raw response can be neither validated nor cached.
+		if req.resp == nil && s.dnssec == nil {
+			errors.LogInfoInner(req.ctx, err, s.Name(), " failed to retry over TCP, using truncated response for ", name)
+			s.cacheController.updateRecord(&req.dnsRequest, truncated)
+			return
+		}
+		errors.LogWarningInner(req.ctx, err, s.Name(), " failed to retry truncated response over TCP for ", name)
+		if req.noResponseErrCh != nil {
+			req.noResponseErrCh <- errors.New("truncated response").Base(err)
+		}
+		return
+	}
+
+	s.handleResponse(req, ipRec, resp)
+}
+
+// exchangeTCP sends msg over TCP to the port of the server.
+func (s *ClassicNameServer) exchangeTCP(ctx context.Context, msg *dnsmessage.Message) ([]byte, error) {
+	b, err := dns.PackMessage(msg)
+	if err != nil {
+		return nil, errors.New("failed to pack dns query").Base(err)
+	}
+	defer b.Release()
+
+	dest := net.TCPDestination(s.address.Address, s.address.Port)
+	link, err := s.dispatcher.Dispatch(toDnsContext(ctx, dest.String()), dest)
+	if err != nil {
+		return nil, errors.New("failed to dial namesever").Base(err)
+	}
+	conn := cnc.NewConnection(
+		cnc.ConnectionInputMulti(link.Writer),
+		cnc.ConnectionOutputMulti(link.Reader),
+	)
+	defer conn.Close()
+	// The link is detached from ctx, so it is closed to stop reading.
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	if err := writeTCPMessage(conn, b.Bytes()); err != nil {
+		return nil, errors.New("failed to send query").Base(err)
+	}
+	return readTCPMessage(conn)
+}
+
 func (s *ClassicNameServer) newReqID() uint16 {
 	return uint16(atomic.AddUint32(&s.reqID, 1))
 }
@@ -157,11 +257,48 @@ func (s *ClassicNameServer) getCacheController() *CacheController {
 	return s.cacheController
 }
 
+// setDNSSECValidator implements dnssecNameServer.
+func (s *ClassicNameServer) setDNSSECValidator(v *dnssecValidator) {
+	s.dnssec = v
+}
+
+// exchange implements dnssecNameServer.
+func (s *ClassicNameServer) exchange(ctx context.Context, msg *dnsmessage.Message) ([]byte, error) {
+	b, err := dns.PackMessage(msg)
+	if err != nil {
+		return nil, errors.New("failed to pack dns query").Base(err)
+	}
+
+	errCh := make(chan error, 1)
+	req := &udpDnsRequest{
+		dnsRequest:      dnsRequest{msg: msg},
+		ctx:             ctx,
+		noResponseErrCh: errCh,
+		resp:            make(chan []byte, 1),
+	}
+	s.addPendingRequest(req)
+	s.udpServer.Dispatch(toDnsContext(ctx, s.address.String()), *s.address, b)
+
+	select {
+	case resp := <-req.resp:
+		return resp, nil
+	case err := <-errCh:
+		return nil, err
+	case <-ctx.Done():
+		s.Lock()
+		if s.requests[msg.ID] == req {
+			delete(s.requests, msg.ID)
+		}
+		s.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
 // sendQuery implements CachedNameserver.
 func (s *ClassicNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<- error, fqdn string, option dns_feature.IPOption) {
 	errors.LogInfo(ctx, s.Name(), " querying DNS for: ", fqdn)
 
-	reqs, err := buildReqMsgs(fqdn, option, s.newReqID, genEDNS0Options(s.clientIP, 0))
+	reqs, err := buildReqMsgs(fqdn, option, s.newReqID, genEDNS0Options(s.clientIP, 0, s.dnssec != nil))
 	if err != nil {
 		errors.LogErrorInner(ctx, err, "failed to build dns query for ", fqdn)
 		if noResponseErrCh != nil {
@@ -177,8 +314,9 @@ func (s *ClassicNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<
 
 	for _, req := range reqs {
 		udpReq := &udpDnsRequest{
-			dnsRequest: *req,
-			ctx:        ctx,
+			dnsRequest:      *req,
+			ctx:             ctx,
+			noResponseErrCh: noResponseErrCh,
 		}
 		s.addPendingRequest(udpReq)
 		b, err := dns.PackMessage(req.msg)
@@ -197,3 +335,8 @@ func (s *ClassicNameServer) sendQuery(ctx context.Context, noResponseErrCh chan<
 func (s *ClassicNameServer) QueryIP(ctx context.Context, domain string, option dns_feature.IPOption) ([]net.IP, uint32, error) {
 	return queryIP(ctx, s, domain, option)
 }
+
+// QueryRecords implements RecordServer.
+func (s *ClassicNameServer) QueryRecords(ctx context.Context, domain string, qType dnsmessage.Type) ([]dnsmessage.Resource, uint32, error) {
+	return queryRecords(ctx, s, domain, qType, genEDNS0Options(s.clientIP, 0, s.dnssec != nil), s.dnssec)
+}
@@ -74,6 +74,127 @@ func (LogType) EnumDescriptor() ([]byte, []int) {
 	return file_app_log_config_proto_rawDescGZIP(), []int{0}
 }
 
+type LogFormat int32
+
+const (
+	LogFormat_Text LogFormat = 0
+	LogFormat_Json LogFormat = 1
+)
+
+// Enum value maps for LogFormat.
+var (
+	LogFormat_name = map[int32]string{
+		0: "Text",
+		1: "Json",
+	}
+	LogFormat_value = map[string]int32{
+		"Text": 0,
+		"Json": 1,
+	}
+)
+
+func (x LogFormat) Enum() *LogFormat {
+	p := new(LogFormat)
+	*p = x
+	return p
+}
+
+func (x LogFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LogFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_app_log_config_proto_enumTypes[1].Descriptor()
+}
+
+func (LogFormat) Type() protoreflect.EnumType {
+	return &file_app_log_config_proto_enumTypes[1]
+}
+
+func (x LogFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LogFormat.Descriptor instead.
+func (LogFormat) EnumDescriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{1}
+}
+
+// Rotation of log files. Zero values disable rotation by size, by time, and
+// the limit of retained files.
+type Rotation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Size in bytes over which a log file is rotated.
+	MaxSize uint64 `protobuf:"varint,1,opt,name=max_size,json=maxSize,proto3" json:"max_size,omitempty"`
+	// Interval in seconds, aligned to UTC, at the end of which a log file is
+	// rotated.
+	Interval uint64 `protobuf:"varint,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	// Maximum number of rotated files retained of a log file.
+	MaxFiles uint32 `protobuf:"varint,3,opt,name=max_files,json=maxFiles,proto3" json:"max_files,omitempty"`
+	// Whether rotated files are compressed with gzip.
+	Compress      bool `protobuf:"varint,4,opt,name=compress,proto3" json:"compress,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rotation) Reset() {
+	*x = Rotation{}
+	mi := &file_app_log_config_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rotation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rotation) ProtoMessage() {}
+
+func (x *Rotation) ProtoReflect() protoreflect.Message {
+	mi := &file_app_log_config_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rotation.ProtoReflect.Descriptor instead.
+func (*Rotation) Descriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Rotation) GetMaxSize() uint64 {
+	if x != nil {
+		return x.MaxSize
+	}
+	return 0
+}
+
+func (x *Rotation) GetInterval() uint64 {
+	if x != nil {
+		return x.Interval
+	}
+	return 0
+}
+
+func (x *Rotation) GetMaxFiles() uint32 {
+	if x != nil {
+		return x.MaxFiles
+	}
+	return 0
+}
+
+func (x *Rotation) GetCompress() bool {
+	if x != nil {
+		return x.Compress
+	}
+	return false
+}
+
 type Config struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ErrorLogType  LogType                `protobuf:"varint,1,opt,name=error_log_type,json=errorLogType,proto3,enum=xray.app.log.LogType" json:"error_log_type,omitempty"`
@@ -83,13 +204,18 @@ type Config struct {
 	AccessLogPath string                 `protobuf:"bytes,5,opt,name=access_log_path,json=accessLogPath,proto3" json:"access_log_path,omitempty"`
 	EnableDnsLog  bool                   `protobuf:"varint,6,opt,name=enable_dns_log,json=enableDnsLog,proto3" json:"enable_dns_log,omitempty"`
 	MaskAddress   string                 `protobuf:"bytes,7,opt,name=mask_address,json=maskAddress,proto3" json:"mask_address,omitempty"`
+	// Format of access and DNS logs. JSON access logs are written when sessions
+	// close, with their traffic and duration.
+	AccessLogFormat LogFormat `protobuf:"varint,8,opt,name=access_log_format,json=accessLogFormat,proto3,enum=xray.app.log.LogFormat" json:"access_log_format,omitempty"`
+	// Rotation of the error and access log files.
+	Rotation      *Rotation `protobuf:"bytes,9,opt,name=rotation,proto3" json:"rotation,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Config) Reset() {
 	*x = Config{}
-	mi := &file_app_log_config_proto_msgTypes[0]
+	mi := &file_app_log_config_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -101,7 +227,7 @@ func (x *Config) String() string {
 func (*Config) ProtoMessage() {}
 
 func (x *Config) ProtoReflect() protoreflect.Message {
-	mi := &file_app_log_config_proto_msgTypes[0]
+	mi := &file_app_log_config_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -114,7 +240,7 @@ func (x *Config) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Config.ProtoReflect.Descriptor instead.
 func (*Config) Descriptor() ([]byte, []int) {
-	return file_app_log_config_proto_rawDescGZIP(), []int{0}
+	return file_app_log_config_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *Config) GetErrorLogType() LogType {
@@ -166,11 +292,30 @@ func (x *Config) GetMaskAddress() string {
 	return ""
 }
 
+func (x *Config) GetAccessLogFormat() LogFormat {
+	if x != nil {
+		return x.AccessLogFormat
+	}
+	return LogFormat_Text
+}
+
+func (x *Config) GetRotation() *Rotation {
+	if x != nil {
+		return x.Rotation
+	}
+	return nil
+}
+
 var File_app_log_config_proto protoreflect.FileDescriptor
 
 const file_app_log_config_proto_rawDesc = "" +
 	"\n" +
-	"\x14app/log/config.proto\x12\fxray.app.log\x1a\x14common/log/log.proto\"\xde\x02\n" +
+	"\x14app/log/config.proto\x12\fxray.app.log\x1a\x14common/log/log.proto\"z\n" +
+	"\bRotation\x12\x19\n" +
+	"\bmax_size\x18\x01 \x01(\x04R\amaxSize\x12\x1a\n" +
+	"\binterval\x18\x02 \x01(\x04R\binterval\x12\x1b\n" +
+	"\tmax_files\x18\x03 \x01(\rR\bmaxFiles\x12\x1a\n" +
+	"\bcompress\x18\x04 \x01(\bR\bcompress\"\xd7\x03\n" +
 	"\x06Config\x12;\n" +
 	"\x0eerror_log_type\x18\x01 \x01(\x0e2\x15.xray.app.log.LogTypeR\ferrorLogType\x12A\n" +
 	"\x0ferror_log_level\x18\x02 \x01(\x0e2\x19.xray.common.log.SeverityR\rerrorLogLevel\x12$\n" +
@@ -178,12 +323,17 @@ const file_app_log_config_proto_rawDesc = "" +
 	"\x0faccess_log_type\x18\x04 \x01(\x0e2\x15.xray.app.log.LogTypeR\raccessLogType\x12&\n" +
 	"\x0faccess_log_path\x18\x05 \x01(\tR\raccessLogPath\x12$\n" +
 	"\x0eenable_dns_log\x18\x06 \x01(\bR\fenableDnsLog\x12!\n" +
-	"\fmask_address\x18\a \x01(\tR\vmaskAddress*5\n" +
+	"\fmask_address\x18\a \x01(\tR\vmaskAddress\x12C\n" +
+	"\x11access_log_format\x18\b \x01(\x0e2\x17.xray.app.log.LogFormatR\x0faccessLogFormat\x122\n" +
+	"\brotation\x18\t \x01(\v2\x16.xray.app.log.RotationR\brotation*5\n" +
 	"\aLogType\x12\b\n" +
 	"\x04None\x10\x00\x12\v\n" +
 	"\aConsole\x10\x01\x12\b\n" +
 	"\x04File\x10\x02\x12\t\n" +
-	"\x05Event\x10\x03BF\n" +
+	"\x05Event\x10\x03*\x1f\n" +
+	"\tLogFormat\x12\b\n" +
+	"\x04Text\x10\x00\x12\b\n" +
+	"\x04Json\x10\x01BF\n" +
 	"\x10com.xray.app.logP\x01Z!github.com/xtls/xray-core/app/log\xaa\x02\fXray.App.Logb\x06proto3"
 
 var (
@@ -198,22 +348,26 @@ func file_app_log_config_proto_rawDescGZIP() []byte {
 	return file_app_log_config_proto_rawDescData
 }
 
-var file_app_log_config_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_app_log_config_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_app_log_config_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_app_log_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_app_log_config_proto_goTypes = []any{
 	(LogType)(0),      // 0: xray.app.log.LogType
-	(*Config)(nil),    // 1: xray.app.log.Config
-	(log.Severity)(0), // 2: xray.common.log.Severity
+	(LogFormat)(0),    // 1: xray.app.log.LogFormat
+	(*Rotation)(nil),  // 2: xray.app.log.Rotation
+	(*Config)(nil),    // 3: xray.app.log.Config
+	(log.Severity)(0), // 4: xray.common.log.Severity
 }
 var file_app_log_config_proto_depIdxs = []int32{
 	0, // 0: xray.app.log.Config.error_log_type:type_name -> xray.app.log.LogType
-	2, // 1: xray.app.log.Config.error_log_level:type_name -> xray.common.log.Severity
+	4, // 1: xray.app.log.Config.error_log_level:type_name -> xray.common.log.Severity
 	0, // 2: xray.app.log.Config.access_log_type:type_name -> xray.app.log.LogType
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	1, // 3: xray.app.log.Config.access_log_format:type_name -> xray.app.log.LogFormat
+	2, // 4: xray.app.log.Config.rotation:type_name -> xray.app.log.Rotation
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_app_log_config_proto_init() }
@@ -226,8 +380,8 @@ func file_app_log_config_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_app_log_config_proto_rawDesc), len(file_app_log_config_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   1,
+			NumEnums:      2,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
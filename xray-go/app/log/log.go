@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
@@ -53,7 +54,9 @@ func New(ctx context.Context, config *Config) (*Instance, error) {
 
 func (g *Instance) initAccessLogger() error {
 	handler, err := createHandler(g.config.AccessLogType, HandlerCreatorOptions{
-		Path: g.config.AccessLogPath,
+		Path:     g.config.AccessLogPath,
+		Raw:      g.config.AccessLogFormat == LogFormat_Json,
+		Rotation: g.config.Rotation.toLogRotation(),
 	})
 	if err != nil {
 		return err
@@ -64,7 +67,8 @@ func (g *Instance) initAccessLogger() error {
 
 func (g *Instance) initErrorLogger() error {
 	handler, err := createHandler(g.config.ErrorLogType, HandlerCreatorOptions{
-		Path: g.config.ErrorLogPath,
+		Path:     g.config.ErrorLogPath,
+		Rotation: g.config.Rotation.toLogRotation(),
 	})
 	if err != nil {
 		return err
@@ -123,14 +127,32 @@ func (g *Instance) Handle(msg log.Message) {
 		Msg = msg
 	}
 
+	jsonFormat := g.config.AccessLogFormat == LogFormat_Json
+	accessMsg := Msg
+	if jsonFormat && g.config.AccessLogType != LogType_Event {
+		w := &JSONMsgWrapper{
+			Message: msg,
+			Time:    time.Now(),
+		}
+		if m, ok := Msg.(*MaskedMsgWrapper); ok {
+			w.Mask = m.mask
+		}
+		accessMsg = w
+	}
+
 	switch msg := msg.(type) {
 	case *log.AccessMessage:
+		// Sessions are logged when they open in text, and when they close with
+		// their traffic and duration in JSON.
+		if (jsonFormat && msg.Phase == log.AccessOpen) || (!jsonFormat && msg.Phase == log.AccessClose) {
+			return
+		}
 		if g.accessLogger != nil {
-			g.accessLogger.Handle(Msg)
+			g.accessLogger.Handle(accessMsg)
 		}
 	case *log.DNSLog:
 		if g.dns && g.accessLogger != nil {
-			g.accessLogger.Handle(Msg)
+			g.accessLogger.Handle(accessMsg)
 		}
 	case *log.GeneralMessage:
 		if g.errorLogger != nil && msg.Severity <= g.config.ErrorLogLevel {
@@ -141,6 +163,15 @@ func (g *Instance) Handle(msg log.Message) {
 	}
 }
 
+// RecordsAccessClose implements log.AccessCloseHandler. Sessions are logged
+// when they close in JSON only.
+func (g *Instance) RecordsAccessClose() bool {
+	g.RLock()
+	defer g.RUnlock()
+
+	return g.active && g.accessLogger != nil && g.config.AccessLogFormat == LogFormat_Json
+}
+
 // Close implements common.Closable.Close().
 func (g *Instance) Close() error {
 	errors.LogDebug(context.Background(), "Logger closing")
@@ -213,8 +244,10 @@ var (
 )
 
 func (m *MaskedMsgWrapper) String() string {
-	str := m.Message.String()
+	return m.mask(m.Message.String())
+}
 
+func (m *MaskedMsgWrapper) mask(str string) string {
 	// Process ipv4
 	maskedMsg := ipv4Regex.ReplaceAllStringFunc(str, func(s string) string {
 		if m.Mask4 == 32 {
@@ -2,6 +2,7 @@ package log
 
 import (
 	"sync"
+	"time"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
@@ -10,6 +11,23 @@ import (
 
 type HandlerCreatorOptions struct {
 	Path string
+	// Raw is whether messages are written without timestamps, such as JSON
+	// lines carrying their own.
+	Raw bool
+	// Rotation is how the log file is rotated, if not nil.
+	Rotation *log.Rotation
+}
+
+func (r *Rotation) toLogRotation() *log.Rotation {
+	if r == nil {
+		return nil
+	}
+	return &log.Rotation{
+		MaxSize:  int64(r.MaxSize),
+		Interval: time.Duration(r.Interval) * time.Second,
+		MaxFiles: int(r.MaxFiles),
+		Compress: r.Compress,
+	}
 }
 
 type HandlerCreator func(LogType, HandlerCreatorOptions) (log.Handler, error)
@@ -43,11 +61,17 @@ func createHandler(logType LogType, options HandlerCreatorOptions) (log.Handler,
 
 func init() {
 	common.Must(RegisterHandlerCreator(LogType_Console, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
+		if options.Raw {
+			return log.NewLogger(log.CreateRawStdoutLogWriter()), nil
+		}
 		return log.NewLogger(log.CreateStdoutLogWriter()), nil
 	}))
 
 	common.Must(RegisterHandlerCreator(LogType_File, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
-		creator, err := log.CreateFileLogWriter(options.Path)
+		creator, err := log.CreateFileLogWriterWithOptions(options.Path, log.FileLogOptions{
+			Raw:      options.Raw,
+			Rotation: options.Rotation,
+		})
 		if err != nil {
 			return nil, err
 		}
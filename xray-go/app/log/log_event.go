@@ -0,0 +1,179 @@
+package log
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/serial"
+)
+
+// Event is the structured form of a message written to a log of type Event.
+type Event struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Severity string    `json:"severity,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	Message  string    `json:"message"`
+
+	// Access log fields.
+	From        string `json:"from,omitempty"`
+	To          string `json:"to,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Email       string `json:"email,omitempty"`
+	Detour      string `json:"detour,omitempty"`
+	InboundTag  string `json:"inboundTag,omitempty"`
+	OutboundTag string `json:"outboundTag,omitempty"`
+	SniffDomain string `json:"sniffDomain,omitempty"`
+	Uplink      int64  `json:"uplink,omitempty"`
+	Downlink    int64  `json:"downlink,omitempty"`
+	DurationMs  int64  `json:"durationMs,omitempty"`
+
+	// DNS log fields.
+	Server string `json:"server,omitempty"`
+	Domain string `json:"domain,omitempty"`
+}
+
+// NewEvent converts a log message into an Event. Addresses are masked the same
+// way as in the text form when the message is wrapped by MaskedMsgWrapper.
+func NewEvent(msg log.Message) *Event {
+	mask := func(s string) string { return s }
+	if m, ok := msg.(*MaskedMsgWrapper); ok {
+		mask = m.mask
+		msg = m.Message
+	}
+
+	event := &Event{
+		Time:    time.Now(),
+		Message: mask(msg.String()),
+	}
+	switch msg := msg.(type) {
+	case *log.GeneralMessage:
+		event.Type = "error"
+		event.Severity = msg.Severity.String()
+		if err, ok := msg.Content.(*errors.Error); ok {
+			event.Source = err.Caller()
+		}
+	case *log.AccessMessage:
+		event.Type = "access"
+		event.From = mask(serial.ToString(msg.From))
+		event.To = mask(serial.ToString(msg.To))
+		event.Status = string(msg.Status)
+		event.Reason = mask(serial.ToString(msg.Reason))
+		event.Email = msg.Email
+		event.Detour = msg.Detour
+		event.InboundTag = msg.InboundTag
+		event.OutboundTag = msg.OutboundTag
+		event.SniffDomain = msg.Domain
+		event.Uplink = msg.Uplink
+		event.Downlink = msg.Downlink
+		event.DurationMs = msg.Duration.Milliseconds()
+	case *log.DNSLog:
+		event.Type = "dns"
+		event.Server = msg.Server
+		event.Domain = msg.Domain
+	default:
+		event.Type = "unknown"
+	}
+	return event
+}
+
+// EventBuffer is a bounded in-memory queue of log events, meant to be polled by
+// an embedding application. When the buffer is full, the oldest event is
+// dropped.
+type EventBuffer struct {
+	sync.Mutex
+	events  []*Event
+	start   int
+	size    int
+	dropped uint64
+}
+
+// NewEventBuffer creates an EventBuffer holding at most capacity events.
+func NewEventBuffer(capacity int) *EventBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &EventBuffer{
+		events: make([]*Event, capacity),
+	}
+}
+
+// Handle implements log.Handler.
+func (b *EventBuffer) Handle(msg log.Message) {
+	event := NewEvent(msg)
+
+	b.Lock()
+	defer b.Unlock()
+
+	if b.size == len(b.events) {
+		b.events[b.start] = event
+		b.start = (b.start + 1) % len(b.events)
+		b.dropped++
+		return
+	}
+	b.events[(b.start+b.size)%len(b.events)] = event
+	b.size++
+}
+
+// Poll removes and returns up to max buffered events, oldest first. All
+// buffered events are returned if max is not positive.
+func (b *EventBuffer) Poll(max int) []*Event {
+	b.Lock()
+	defer b.Unlock()
+
+	if max <= 0 || max > b.size {
+		max = b.size
+	}
+	events := make([]*Event, 0, max)
+	for i := 0; i < max; i++ {
+		events = append(events, b.events[b.start])
+		b.events[b.start] = nil
+		b.start = (b.start + 1) % len(b.events)
+	}
+	b.size -= max
+	return events
+}
+
+// Dropped returns the number of events discarded because the buffer was full.
+func (b *EventBuffer) Dropped() uint64 {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.dropped
+}
+
+var eventHandler struct {
+	sync.RWMutex
+	handler log.Handler
+}
+
+// SetEventHandler sets the handler that receives every message written to a
+// log of type Event, such as an EventBuffer. A nil handler discards them.
+func SetEventHandler(handler log.Handler) {
+	eventHandler.Lock()
+	defer eventHandler.Unlock()
+
+	eventHandler.handler = handler
+}
+
+type eventLogger struct{}
+
+// Handle implements log.Handler.
+func (eventLogger) Handle(msg log.Message) {
+	eventHandler.RLock()
+	defer eventHandler.RUnlock()
+
+	if eventHandler.handler != nil {
+		eventHandler.handler.Handle(msg)
+	}
+}
+
+func init() {
+	common.Must(RegisterHandlerCreator(LogType_Event, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
+		return eventLogger{}, nil
+	}))
+}
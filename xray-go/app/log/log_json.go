@@ -0,0 +1,99 @@
+package log
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/serial"
+)
+
+type jsonAccessRecord struct {
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Status      string    `json:"status"`
+	Reason      string    `json:"reason,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	Detour      string    `json:"detour,omitempty"`
+	InboundTag  string    `json:"inboundTag,omitempty"`
+	OutboundTag string    `json:"outboundTag,omitempty"`
+	Domain      string    `json:"domain,omitempty"`
+	Uplink      int64     `json:"uplink"`
+	Downlink    int64     `json:"downlink"`
+	DurationMs  int64     `json:"durationMs"`
+}
+
+type jsonDNSRecord struct {
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`
+	Server    string    `json:"server"`
+	Status    string    `json:"status"`
+	Domain    string    `json:"domain"`
+	Result    []string  `json:"result"`
+	ElapsedMs int64     `json:"elapsedMs"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// JSONMsgWrapper is to wrap the String() method to encode access and DNS logs
+// as JSON lines.
+type JSONMsgWrapper struct {
+	log.Message
+	Time time.Time
+	// Mask masks IP addresses, as MaskedMsgWrapper does, if not nil.
+	Mask func(string) string
+}
+
+func (m *JSONMsgWrapper) String() string {
+	mask := m.Mask
+	if mask == nil {
+		mask = func(s string) string { return s }
+	}
+
+	var record interface{}
+	switch msg := m.Message.(type) {
+	case *log.AccessMessage:
+		record = &jsonAccessRecord{
+			Time:        m.Time,
+			Type:        "access",
+			From:        mask(serial.ToString(msg.From)),
+			To:          mask(serial.ToString(msg.To)),
+			Status:      string(msg.Status),
+			Reason:      mask(serial.ToString(msg.Reason)),
+			Email:       msg.Email,
+			Detour:      msg.Detour,
+			InboundTag:  msg.InboundTag,
+			OutboundTag: msg.OutboundTag,
+			Domain:      msg.Domain,
+			Uplink:      msg.Uplink,
+			Downlink:    msg.Downlink,
+			DurationMs:  msg.Duration.Milliseconds(),
+		}
+	case *log.DNSLog:
+		r := &jsonDNSRecord{
+			Time:      m.Time,
+			Type:      "dns",
+			Server:    msg.Server,
+			Status:    string(msg.Status),
+			Domain:    msg.Domain,
+			Result:    make([]string, 0, len(msg.Result)),
+			ElapsedMs: msg.Elapsed.Milliseconds(),
+		}
+		for _, ip := range msg.Result {
+			r.Result = append(r.Result, mask(ip.String()))
+		}
+		if msg.Error != nil {
+			r.Error = msg.Error.Error()
+		}
+		record = r
+	default:
+		return mask(m.Message.String())
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return mask(m.Message.String())
+	}
+	return string(b)
+}
@@ -2,8 +2,10 @@ package log_test
 
 import (
 	"context"
+	"encoding/json"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/xtls/xray-core/app/log"
@@ -87,3 +89,164 @@ func TestMaskAddress(t *testing.T) {
 		t.Fatal("expected '11:45:14:19::/64', but actually", maskedAddr.String())
 	}
 }
+
+func TestEventLog(t *testing.T) {
+	buffer := log.NewEventBuffer(2)
+	log.SetEventHandler(buffer)
+	defer log.SetEventHandler(nil)
+
+	logger, err := log.New(context.Background(), &log.Config{
+		ErrorLogLevel: clog.Severity_Debug,
+		ErrorLogType:  log.LogType_Event,
+		AccessLogType: log.LogType_Event,
+	})
+	common.Must(err)
+	defer logger.Close()
+
+	clog.Record(&clog.AccessMessage{
+		From:   "127.0.0.1:1234",
+		To:     "tcp:example.com:443",
+		Status: clog.AccessAccepted,
+		Email:  "love@xray.com",
+		Detour: "in >> out",
+	})
+	clog.Record(&clog.GeneralMessage{
+		Severity: clog.Severity_Warning,
+		Content:  "test",
+	})
+
+	events := buffer.Poll(0)
+	if len(events) != 2 {
+		t.Fatal("expected 2 events, but actually ", len(events))
+	}
+	if buffer.Dropped() != 1 {
+		t.Error("expected 1 dropped event, but actually ", buffer.Dropped())
+	}
+
+	access := events[0]
+	if access.Type != "access" || access.From != "127.0.0.1:1234" || access.To != "tcp:example.com:443" ||
+		access.Status != "accepted" || access.Email != "love@xray.com" || access.Detour != "in >> out" {
+		t.Error("unexpected access event: ", access)
+	}
+
+	general := events[1]
+	if general.Type != "error" || general.Severity != "Warning" || general.Message != "[Warning] test" {
+		t.Error("unexpected error event: ", general)
+	}
+
+	if events := buffer.Poll(0); len(events) != 0 {
+		t.Error("expected empty buffer, but actually ", len(events))
+	}
+}
+
+func TestJSONAccessLog(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	var loggedValue []string
+
+	mockHandler := mocks.NewLogHandler(mockCtl)
+	mockHandler.EXPECT().Handle(gomock.Any()).AnyTimes().DoAndReturn(func(msg clog.Message) {
+		loggedValue = append(loggedValue, msg.String())
+	})
+
+	var raw bool
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		raw = options.Raw
+		return mockHandler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		ErrorLogType:    log.LogType_None,
+		AccessLogType:   log.LogType_Console,
+		AccessLogFormat: log.LogFormat_Json,
+		MaskAddress:     "quarter",
+	})
+	common.Must(err)
+	defer logger.Close()
+
+	if !raw {
+		t.Error("expected raw access log writer")
+	}
+	if !clog.RecordsAccessClose() {
+		t.Error("expected access messages recorded when sessions close")
+	}
+
+	msg := &clog.AccessMessage{
+		From:        "11.45.1.4:1234",
+		To:          "tcp:example.com:443",
+		Status:      clog.AccessAccepted,
+		Email:       "love@xray.com",
+		InboundTag:  "in",
+		OutboundTag: "out",
+		Domain:      "example.com",
+		Phase:       clog.AccessOpen,
+	}
+	clog.Record(msg)
+	if len(loggedValue) != 0 {
+		t.Fatal("expected no log when session opens, but actually ", loggedValue)
+	}
+
+	closed := *msg
+	closed.Phase = clog.AccessClose
+	closed.Uplink = 100
+	closed.Downlink = 200
+	closed.Duration = 1500 * time.Millisecond
+	clog.Record(&closed)
+	if len(loggedValue) != 1 {
+		t.Fatal("expected 1 log message, but actually ", loggedValue)
+	}
+
+	var record map[string]interface{}
+	common.Must(json.Unmarshal([]byte(loggedValue[0]), &record))
+	if record["type"] != "access" || record["from"] != "11.*.*.*:1234" || record["to"] != "tcp:example.com:443" ||
+		record["status"] != "accepted" || record["email"] != "love@xray.com" || record["inboundTag"] != "in" ||
+		record["outboundTag"] != "out" || record["domain"] != "example.com" || record["uplink"] != 100.0 ||
+		record["downlink"] != 200.0 || record["durationMs"] != 1500.0 || record["time"] == nil {
+		t.Error("unexpected access record: ", loggedValue[0])
+	}
+}
+
+func TestTextAccessLogSkipsClose(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	var loggedValue []string
+
+	mockHandler := mocks.NewLogHandler(mockCtl)
+	mockHandler.EXPECT().Handle(gomock.Any()).AnyTimes().DoAndReturn(func(msg clog.Message) {
+		loggedValue = append(loggedValue, msg.String())
+	})
+
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return mockHandler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		ErrorLogType:  log.LogType_None,
+		AccessLogType: log.LogType_Console,
+	})
+	common.Must(err)
+	defer logger.Close()
+
+	// The traffic of sessions is not counted for text.
+	if clog.RecordsAccessClose() {
+		t.Error("expected access messages not recorded when sessions close")
+	}
+
+	clog.Record(&clog.AccessMessage{
+		From:   "127.0.0.1:1234",
+		To:     "tcp:example.com:443",
+		Status: clog.AccessAccepted,
+		Phase:  clog.AccessOpen,
+	})
+	clog.Record(&clog.AccessMessage{
+		From:   "127.0.0.1:1234",
+		To:     "tcp:example.com:443",
+		Status: clog.AccessAccepted,
+		Phase:  clog.AccessClose,
+	})
+	if len(loggedValue) != 1 || loggedValue[0] != "from 127.0.0.1:1234 accepted tcp:example.com:443" {
+		t.Error("unexpected log messages: ", loggedValue)
+	}
+}
@@ -134,6 +134,7 @@ func isClosedListenerError(err error) bool {
 
 func (p *MetricsHandler) httpHandler() http.Handler {
 	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", p.handleMetrics)
 	mux.HandleFunc("/debug/vars", p.handleDebugVars)
 	mux.HandleFunc("/debug/pprof/", pprof.Index)
 	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
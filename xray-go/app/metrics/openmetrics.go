@@ -0,0 +1,224 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xtls/xray-core/app/dns"
+	"github.com/xtls/xray-core/app/observatory"
+	"github.com/xtls/xray-core/core"
+	feature_dns "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/extension"
+	feature_stats "github.com/xtls/xray-core/features/stats"
+)
+
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	labels []label
+	value  float64
+}
+
+// metricFamily is a metric family in the OpenMetrics text format.
+type metricFamily struct {
+	name    string
+	typ     string
+	unit    string
+	help    string
+	samples []sample
+}
+
+func (f *metricFamily) add(value float64, labels ...label) {
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (f *metricFamily) writeTo(b *bytes.Buffer) {
+	b.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+	if f.unit != "" {
+		b.WriteString("# UNIT " + f.name + " " + f.unit + "\n")
+	}
+	b.WriteString("# HELP " + f.name + " " + f.help + "\n")
+
+	suffix := ""
+	if f.typ == "counter" {
+		suffix = "_total"
+	}
+	lines := make([]string, 0, len(f.samples))
+	for _, s := range f.samples {
+		var line strings.Builder
+		line.WriteString(f.name + suffix)
+		if len(s.labels) > 0 {
+			line.WriteByte('{')
+			for i, l := range s.labels {
+				if i > 0 {
+					line.WriteByte(',')
+				}
+				line.WriteString(l.name + `="` + labelValueReplacer.Replace(l.value) + `"`)
+			}
+			line.WriteByte('}')
+		}
+		line.WriteString(" " + strconv.FormatFloat(s.value, 'g', -1, 64) + "\n")
+		lines = append(lines, line.String())
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+}
+
+func (p *MetricsHandler) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var b bytes.Buffer
+	for _, f := range p.metricFamilies() {
+		f.writeTo(&b)
+	}
+	b.WriteString("# EOF\n")
+
+	w.Header().Set("Content-Type", openMetricsContentType)
+	w.Write(b.Bytes())
+}
+
+func (p *MetricsHandler) metricFamilies() []*metricFamily {
+	var families []*metricFamily
+	families = append(families, p.statsMetrics()...)
+	families = append(families, p.observatoryMetrics()...)
+	families = append(families, p.dnsMetrics()...)
+	families = append(families, runtimeMetrics()...)
+	return families
+}
+
+// statsMetrics exports counters like "user>>>[email]>>>traffic>>>uplink" as
+// traffic with the parts of their names as labels, and other counters with
+// their names as labels.
+func (p *MetricsHandler) statsMetrics() []*metricFamily {
+	traffic := &metricFamily{
+		name: "xray_traffic_bytes",
+		typ:  "counter",
+		unit: "bytes",
+		help: "Traffic of inbounds, outbounds and users.",
+	}
+	counters := &metricFamily{
+		name: "xray_stats_counter",
+		typ:  "gauge",
+		help: "Values of other stats counters.",
+	}
+	online := &metricFamily{
+		name: "xray_user_online_ips",
+		typ:  "gauge",
+		help: "Number of online IPs of users.",
+	}
+
+	p.statsManager.VisitCounters(func(name string, counter feature_stats.Counter) bool {
+		parts := strings.Split(name, ">>>")
+		if len(parts) == 4 && parts[2] == "traffic" {
+			traffic.add(float64(counter.Value()),
+				label{"type", parts[0]}, label{"name", parts[1]}, label{"direction", parts[3]})
+		} else {
+			counters.add(float64(counter.Value()), label{"name", name})
+		}
+		return true
+	})
+	p.statsManager.VisitOnlineMaps(func(name string, om feature_stats.OnlineMap) bool {
+		parts := strings.Split(name, ">>>")
+		if len(parts) == 3 && parts[0] == "user" && parts[2] == "online" {
+			online.add(float64(om.Count()), label{"user", parts[1]})
+		}
+		return true
+	})
+
+	return []*metricFamily{traffic, counters, online}
+}
+
+func (p *MetricsHandler) observatoryMetrics() []*metricFamily {
+	feature := core.MustFromContext(p.ctx).GetFeature(extension.ObservatoryType())
+	if feature == nil {
+		return nil
+	}
+	o, err := feature.(extension.Observatory).GetObservation(context.Background())
+	if err != nil {
+		return nil
+	}
+	result, ok := o.(*observatory.ObservationResult)
+	if !ok {
+		return nil
+	}
+
+	alive := &metricFamily{
+		name: "xray_outbound_alive",
+		typ:  "gauge",
+		help: "Whether outbounds are alive by the observatory.",
+	}
+	delay := &metricFamily{
+		name: "xray_outbound_delay_seconds",
+		typ:  "gauge",
+		unit: "seconds",
+		help: "Delay of outbounds measured by the observatory.",
+	}
+	for _, status := range result.GetStatus() {
+		value := 0.0
+		if status.Alive {
+			value = 1
+		}
+		alive.add(value, label{"outbound", status.OutboundTag})
+		delay.add(float64(status.Delay)/1000, label{"outbound", status.OutboundTag})
+	}
+	return []*metricFamily{alive, delay}
+}
+
+func (p *MetricsHandler) dnsMetrics() []*metricFamily {
+	client, ok := core.MustFromContext(p.ctx).GetFeature(feature_dns.ClientType()).(*dns.DNS)
+	if !ok {
+		return nil
+	}
+
+	hits := &metricFamily{
+		name: "xray_dns_cache_hits",
+		typ:  "counter",
+		help: "Queries answered from the caches of name servers.",
+	}
+	misses := &metricFamily{
+		name: "xray_dns_cache_misses",
+		typ:  "counter",
+		help: "Queries not found in the caches of name servers.",
+	}
+	for _, s := range client.CacheStats() {
+		hits.add(float64(s.Hits), label{"server", s.Server})
+		misses.add(float64(s.Misses), label{"server", s.Server})
+	}
+	return []*metricFamily{hits, misses}
+}
+
+func runtimeMetrics() []*metricFamily {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	gauge := func(name, unit, help string, value float64) *metricFamily {
+		f := &metricFamily{name: name, typ: "gauge", unit: unit, help: help}
+		f.add(value)
+		return f
+	}
+	counter := func(name, unit, help string, value float64) *metricFamily {
+		f := &metricFamily{name: name, typ: "counter", unit: unit, help: help}
+		f.add(value)
+		return f
+	}
+	return []*metricFamily{
+		gauge("go_goroutines", "", "Number of goroutines.", float64(runtime.NumGoroutine())),
+		gauge("go_memstats_alloc_bytes", "bytes", "Bytes of allocated heap objects.", float64(m.HeapAlloc)),
+		gauge("go_memstats_sys_bytes", "bytes", "Bytes of memory obtained from the OS.", float64(m.Sys)),
+		gauge("go_memstats_heap_objects", "", "Number of allocated heap objects.", float64(m.HeapObjects)),
+		counter("go_gc_cycles", "", "Number of completed GC cycles.", float64(m.NumGC)),
+		counter("go_gc_pause_seconds", "seconds", "Time spent in GC stop-the-world pauses.", float64(m.PauseTotalNs)/1e9),
+	}
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	feature_stats "github.com/xtls/xray-core/features/stats"
+)
+
+func TestMetricsOpenMetrics(t *testing.T) {
+	server := startMetricsTestServer(t)
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+
+	sm := server.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	c, err := sm.RegisterCounter("user>>>a\"b>>>traffic>>>uplink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add(100)
+	c, err = sm.RegisterCounter("user>>>a>>>quota>>>used")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add(20)
+
+	recorder := httptest.NewRecorder()
+	metricsHandler(t, server).httpHandler().ServeHTTP(
+		recorder,
+		httptest.NewRequest(http.MethodGet, "/metrics", nil),
+	)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected metrics status: %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != openMetricsContentType {
+		t.Fatalf("unexpected metrics content type: %s", contentType)
+	}
+
+	body := recorder.Body.String()
+	for _, expected := range []string{
+		"# TYPE xray_traffic_bytes counter\n",
+		`xray_traffic_bytes_total{type="user",name="a\"b",direction="uplink"} 100` + "\n",
+		`xray_stats_counter{name="user>>>a>>>quota>>>used"} 20` + "\n",
+		"# TYPE go_goroutines gauge\n",
+		"go_gc_cycles_total ",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics missing %q:\n%s", expected, body)
+		}
+	}
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Error("metrics not terminated by EOF")
+	}
+}
@@ -0,0 +1,198 @@
+package observatory
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/proxyman"
+	_ "github.com/xtls/xray-core/app/proxyman/inbound"  // for the instance made by ProbeOutbounds
+	_ "github.com/xtls/xray-core/app/proxyman/outbound" // for the instance made by ProbeOutbounds
+	"github.com/xtls/xray-core/common/errors"
+	v2net "github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/common/utils"
+	"github.com/xtls/xray-core/core"
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultProbeURL = "https://www.google.com/generate_204"
+
+// probeReport is the outcome of probeOutbound.
+type probeReport struct {
+	Alive           bool
+	Delay           time.Duration
+	TLSHandshake    time.Duration
+	LastErrorReason string
+}
+
+// probeOutbound sends an HTTP GET request to probeURL through the connections
+// made by dial, which is expected to use outbound.
+func probeOutbound(ctx context.Context, outbound string, probeURL string, timeout time.Duration, dial func(ctx context.Context, dest v2net.Destination) (net.Conn, error)) *probeReport {
+	errorCollectorForRequest := newErrorCollector()
+
+	httpTransport := http.Transport{
+		Proxy: func(*http.Request) (*url.URL, error) {
+			return nil, nil
+		},
+		DialContext: func(dialCtx context.Context, network string, addr string) (net.Conn, error) {
+			var connection net.Conn
+			taskErr := task.Run(dialCtx, func() error {
+				// MUST use Xray's built in context system
+				dest, err := v2net.ParseDestination(network + ":" + addr)
+				if err != nil {
+					return errors.New("cannot understand address").Base(err)
+				}
+				trackedCtx := session.TrackedConnectionError(ctx, errorCollectorForRequest)
+				conn, err := dial(trackedCtx, dest)
+				if err != nil {
+					return errors.New("cannot dial remote address ", dest).Base(err)
+				}
+				connection = conn
+				return nil
+			})
+			if taskErr != nil {
+				return nil, errors.New("cannot finish connection").Base(taskErr)
+			}
+			return connection, nil
+		},
+		TLSHandshakeTimeout: timeout,
+	}
+	defer httpTransport.CloseIdleConnections()
+	httpClient := &http.Client{
+		Transport: &httpTransport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Jar:     nil,
+		Timeout: timeout,
+	}
+
+	var handshakeStart time.Time
+	report := new(probeReport)
+	trace := &httptrace.ClientTrace{
+		TLSHandshakeStart: func() {
+			handshakeStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			report.TLSHandshake = time.Since(handshakeStart)
+		},
+	}
+
+	var GETTime time.Duration
+	err := task.Run(ctx, func() error {
+		startTime := time.Now()
+		if probeURL == "" {
+			probeURL = defaultProbeURL
+		}
+		req, err := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, probeURL, nil)
+		if err != nil {
+			return errors.New("invalid probe URL").Base(err)
+		}
+		utils.TryDefaultHeadersWith(req.Header, "nav")
+		response, err := httpClient.Do(req)
+		if err != nil {
+			return errors.New("outbound failed to relay connection").Base(err)
+		}
+		if response.Body != nil {
+			response.Body.Close()
+		}
+		endTime := time.Now()
+		GETTime = endTime.Sub(startTime)
+		return nil
+	})
+	if err != nil {
+		errorMessage := "the outbound " + outbound + " is dead: GET request failed:" + err.Error() + "with outbound handler report underlying connection failed"
+		errors.LogInfoInner(ctx, errorCollectorForRequest.UnderlyingError(), errorMessage)
+		report.LastErrorReason = errorMessage
+		return report
+	}
+	errors.LogInfo(ctx, "the outbound ", outbound, " is alive:", GETTime.Seconds())
+	report.Alive = true
+	report.Delay = GETTime
+	return report
+}
+
+// OutboundProbeResult is the result of probing an outbound with
+// ProbeOutbounds. Delay and TLSHandshake are in milliseconds, where Delay
+// covers the whole request including the TLS handshake with the probe URL.
+type OutboundProbeResult struct {
+	OutboundTag  string `json:"outboundTag"`
+	Alive        bool   `json:"alive"`
+	Delay        int64  `json:"delay"`
+	TLSHandshake int64  `json:"tlsHandshake"`
+	ErrorReason  string `json:"errorReason,omitempty"`
+}
+
+// ProbeOutbounds tests outbounds without a running config. It starts a
+// temporary instance containing only outbounds, sends an HTTP GET request to
+// probeURL through each of them concurrently the same way as the observatory,
+// and closes the instance. Outbounds without a tag are given one. A timeout of
+// 0 means 5 seconds, and an empty probeURL means the observatory default.
+func ProbeOutbounds(outbounds []*core.OutboundHandlerConfig, probeURL string, timeout time.Duration) ([]*OutboundProbeResult, error) {
+	if timeout <= 0 {
+		timeout = time.Second * 5
+	}
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+		},
+	}
+	tags := make([]string, len(outbounds))
+	for i, outbound := range outbounds {
+		tags[i] = outbound.Tag
+		if tags[i] == "" {
+			outbound = proto.Clone(outbound).(*core.OutboundHandlerConfig)
+			outbound.Tag = "probe-" + strconv.Itoa(i)
+			tags[i] = outbound.Tag
+		}
+		config.Outbound = append(config.Outbound, outbound)
+	}
+
+	server, err := core.New(config)
+	if err != nil {
+		return nil, errors.New("failed to create instance for probing").Base(err)
+	}
+	defer server.Close()
+	if err := server.Start(); err != nil {
+		return nil, errors.New("failed to start instance for probing").Base(err)
+	}
+
+	ctx := context.Background()
+	results := make([]*OutboundProbeResult, len(tags))
+	var wg sync.WaitGroup
+	for i, tag := range tags {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report := probeOutbound(ctx, tag, probeURL, timeout, func(ctx context.Context, dest v2net.Destination) (net.Conn, error) {
+				content := new(session.Content)
+				content.SkipDNSResolve = true
+				ctx = session.ContextWithContent(ctx, content)
+				ctx = session.SetForcedOutboundTagToContext(ctx, tag)
+				return core.Dial(ctx, server, dest)
+			})
+			results[i] = &OutboundProbeResult{
+				OutboundTag:  tag,
+				Alive:        report.Alive,
+				Delay:        report.Delay.Milliseconds(),
+				TLSHandshake: report.TLSHandshake.Milliseconds(),
+				ErrorReason:  report.LastErrorReason,
+			}
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
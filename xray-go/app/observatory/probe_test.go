@@ -0,0 +1,42 @@
+package observatory_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/xtls/xray-core/app/observatory"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/proxy/blackhole"
+	"github.com/xtls/xray-core/proxy/freedom"
+)
+
+func TestProbeOutbounds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	results, err := ProbeOutbounds([]*core.OutboundHandlerConfig{
+		{
+			Tag:           "direct",
+			ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+		},
+		{
+			ProxySettings: serial.ToTypedMessage(&blackhole.Config{}),
+		},
+	}, server.URL, 0)
+	common.Must(err)
+
+	if len(results) != 2 {
+		t.Fatal("expected 2 results, but actually ", len(results))
+	}
+	if r := results[0]; r.OutboundTag != "direct" || !r.Alive || r.ErrorReason != "" {
+		t.Error("expected direct to be alive, but actually ", r)
+	}
+	if r := results[1]; r.OutboundTag != "probe-1" || r.Alive || r.ErrorReason == "" {
+		t.Error("expected blackhole to be dead, but actually ", r)
+	}
+}
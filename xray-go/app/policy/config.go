@@ -58,6 +58,13 @@ func (p *Policy) overrideWith(another *Policy) {
 			Connection: another.Buffer.Connection,
 		}
 	}
+	if another.Limit != nil {
+		p.Limit = &Policy_Limit{
+			UplinkRate:   another.Limit.UplinkRate,
+			DownlinkRate: another.Limit.DownlinkRate,
+			Quota:        another.Limit.Quota,
+		}
+	}
 }
 
 // ToCorePolicy converts this Policy to policy.Session.
@@ -78,17 +85,36 @@ func (p *Policy) ToCorePolicy() policy.Session {
 	if p.Buffer != nil {
 		cp.Buffer.PerConnection = p.Buffer.Connection
 	}
+	if p.Limit != nil {
+		cp.Limit = p.Limit.ToCorePolicy()
+	}
 	return cp
 }
 
+// ToCorePolicy converts this Policy_Limit to policy.Limit.
+func (l *Policy_Limit) ToCorePolicy() policy.Limit {
+	return policy.Limit{
+		UplinkRate:   l.UplinkRate,
+		DownlinkRate: l.DownlinkRate,
+		Quota:        l.Quota,
+	}
+}
+
 // ToCorePolicy converts this SystemPolicy to policy.System.
 func (p *SystemPolicy) ToCorePolicy() policy.System {
-	return policy.System{
+	sp := policy.System{
 		Stats: policy.SystemStats{
-			InboundUplink:    p.Stats.InboundUplink,
-			InboundDownlink:  p.Stats.InboundDownlink,
-			OutboundUplink:   p.Stats.OutboundUplink,
-			OutboundDownlink: p.Stats.OutboundDownlink,
+			InboundUplink:    p.Stats.GetInboundUplink(),
+			InboundDownlink:  p.Stats.GetInboundDownlink(),
+			OutboundUplink:   p.Stats.GetOutboundUplink(),
+			OutboundDownlink: p.Stats.GetOutboundDownlink(),
 		},
 	}
+	if len(p.InboundLimit) > 0 {
+		sp.InboundLimits = make(map[string]policy.Limit, len(p.InboundLimit))
+		for tag, limit := range p.InboundLimit {
+			sp.InboundLimits[tag] = limit.ToCorePolicy()
+		}
+	}
+	return sp
 }
@@ -70,6 +70,7 @@ type Policy struct {
 	Timeout       *Policy_Timeout        `protobuf:"bytes,1,opt,name=timeout,proto3" json:"timeout,omitempty"`
 	Stats         *Policy_Stats          `protobuf:"bytes,2,opt,name=stats,proto3" json:"stats,omitempty"`
 	Buffer        *Policy_Buffer         `protobuf:"bytes,3,opt,name=buffer,proto3" json:"buffer,omitempty"`
+	Limit         *Policy_Limit          `protobuf:"bytes,4,opt,name=limit,proto3" json:"limit,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -125,9 +126,18 @@ func (x *Policy) GetBuffer() *Policy_Buffer {
 	return nil
 }
 
+func (x *Policy) GetLimit() *Policy_Limit {
+	if x != nil {
+		return x.Limit
+	}
+	return nil
+}
+
 type SystemPolicy struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Stats         *SystemPolicy_Stats    `protobuf:"bytes,1,opt,name=stats,proto3" json:"stats,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Stats *SystemPolicy_Stats    `protobuf:"bytes,1,opt,name=stats,proto3" json:"stats,omitempty"`
+	// Traffic limits of inbounds, by tag.
+	InboundLimit  map[string]*Policy_Limit `protobuf:"bytes,2,rep,name=inbound_limit,json=inboundLimit,proto3" json:"inbound_limit,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -169,6 +179,13 @@ func (x *SystemPolicy) GetStats() *SystemPolicy_Stats {
 	return nil
 }
 
+func (x *SystemPolicy) GetInboundLimit() map[string]*Policy_Limit {
+	if x != nil {
+		return x.InboundLimit
+	}
+	return nil
+}
+
 type Config struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Level         map[uint32]*Policy     `protobuf:"bytes,1,rep,name=level,proto3" json:"level,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
@@ -395,6 +412,73 @@ func (x *Policy_Buffer) GetConnection() int32 {
 	return 0
 }
 
+// Limit is a message for traffic limits of each user, or each inbound.
+type Policy_Limit struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Rate of uplink traffic, in bytes per second. 0 for unlimited.
+	UplinkRate uint64 `protobuf:"varint,1,opt,name=uplink_rate,json=uplinkRate,proto3" json:"uplink_rate,omitempty"`
+	// Rate of downlink traffic, in bytes per second. 0 for unlimited.
+	DownlinkRate uint64 `protobuf:"varint,2,opt,name=downlink_rate,json=downlinkRate,proto3" json:"downlink_rate,omitempty"`
+	// Total traffic in bytes, after which connections are closed. 0 for unlimited.
+	// It is counted by the stats counter "user>>>[email]>>>quota>>>used" or
+	// "inbound>>>[tag]>>>quota>>>used", which is only reset through the stats
+	// API, or by the quota app when the traffic of the user is reset.
+	Quota         uint64 `protobuf:"varint,3,opt,name=quota,proto3" json:"quota,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Policy_Limit) Reset() {
+	*x = Policy_Limit{}
+	mi := &file_app_policy_config_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Policy_Limit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Policy_Limit) ProtoMessage() {}
+
+func (x *Policy_Limit) ProtoReflect() protoreflect.Message {
+	mi := &file_app_policy_config_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Policy_Limit.ProtoReflect.Descriptor instead.
+func (*Policy_Limit) Descriptor() ([]byte, []int) {
+	return file_app_policy_config_proto_rawDescGZIP(), []int{1, 3}
+}
+
+func (x *Policy_Limit) GetUplinkRate() uint64 {
+	if x != nil {
+		return x.UplinkRate
+	}
+	return 0
+}
+
+func (x *Policy_Limit) GetDownlinkRate() uint64 {
+	if x != nil {
+		return x.DownlinkRate
+	}
+	return 0
+}
+
+func (x *Policy_Limit) GetQuota() uint64 {
+	if x != nil {
+		return x.Quota
+	}
+	return 0
+}
+
 type SystemPolicy_Stats struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	InboundUplink    bool                   `protobuf:"varint,1,opt,name=inbound_uplink,json=inboundUplink,proto3" json:"inbound_uplink,omitempty"`
@@ -407,7 +491,7 @@ type SystemPolicy_Stats struct {
 
 func (x *SystemPolicy_Stats) Reset() {
 	*x = SystemPolicy_Stats{}
-	mi := &file_app_policy_config_proto_msgTypes[7]
+	mi := &file_app_policy_config_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -419,7 +503,7 @@ func (x *SystemPolicy_Stats) String() string {
 func (*SystemPolicy_Stats) ProtoMessage() {}
 
 func (x *SystemPolicy_Stats) ProtoReflect() protoreflect.Message {
-	mi := &file_app_policy_config_proto_msgTypes[7]
+	mi := &file_app_policy_config_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -469,11 +553,12 @@ const file_app_policy_config_proto_rawDesc = "" +
 	"\n" +
 	"\x17app/policy/config.proto\x12\x0fxray.app.policy\"\x1e\n" +
 	"\x06Second\x12\x14\n" +
-	"\x05value\x18\x01 \x01(\rR\x05value\"\xc7\x04\n" +
+	"\x05value\x18\x01 \x01(\rR\x05value\"\xe1\x05\n" +
 	"\x06Policy\x129\n" +
 	"\atimeout\x18\x01 \x01(\v2\x1f.xray.app.policy.Policy.TimeoutR\atimeout\x123\n" +
 	"\x05stats\x18\x02 \x01(\v2\x1d.xray.app.policy.Policy.StatsR\x05stats\x126\n" +
-	"\x06buffer\x18\x03 \x01(\v2\x1e.xray.app.policy.Policy.BufferR\x06buffer\x1a\xfa\x01\n" +
+	"\x06buffer\x18\x03 \x01(\v2\x1e.xray.app.policy.Policy.BufferR\x06buffer\x123\n" +
+	"\x05limit\x18\x04 \x01(\v2\x1d.xray.app.policy.Policy.LimitR\x05limit\x1a\xfa\x01\n" +
 	"\aTimeout\x125\n" +
 	"\thandshake\x18\x01 \x01(\v2\x17.xray.app.policy.SecondR\thandshake\x12@\n" +
 	"\x0fconnection_idle\x18\x02 \x01(\v2\x17.xray.app.policy.SecondR\x0econnectionIdle\x128\n" +
@@ -489,14 +574,23 @@ const file_app_policy_config_proto_rawDesc = "" +
 	"\x06Buffer\x12\x1e\n" +
 	"\n" +
 	"connection\x18\x01 \x01(\x05R\n" +
-	"connection\"\xfb\x01\n" +
+	"connection\x1ac\n" +
+	"\x05Limit\x12\x1f\n" +
+	"\vuplink_rate\x18\x01 \x01(\x04R\n" +
+	"uplinkRate\x12#\n" +
+	"\rdownlink_rate\x18\x02 \x01(\x04R\fdownlinkRate\x12\x14\n" +
+	"\x05quota\x18\x03 \x01(\x04R\x05quota\"\xb1\x03\n" +
 	"\fSystemPolicy\x129\n" +
-	"\x05stats\x18\x01 \x01(\v2#.xray.app.policy.SystemPolicy.StatsR\x05stats\x1a\xaf\x01\n" +
+	"\x05stats\x18\x01 \x01(\v2#.xray.app.policy.SystemPolicy.StatsR\x05stats\x12T\n" +
+	"\rinbound_limit\x18\x02 \x03(\v2/.xray.app.policy.SystemPolicy.InboundLimitEntryR\finboundLimit\x1a\xaf\x01\n" +
 	"\x05Stats\x12%\n" +
 	"\x0einbound_uplink\x18\x01 \x01(\bR\rinboundUplink\x12)\n" +
 	"\x10inbound_downlink\x18\x02 \x01(\bR\x0finboundDownlink\x12'\n" +
 	"\x0foutbound_uplink\x18\x03 \x01(\bR\x0eoutboundUplink\x12+\n" +
-	"\x11outbound_downlink\x18\x04 \x01(\bR\x10outboundDownlink\"\xcc\x01\n" +
+	"\x11outbound_downlink\x18\x04 \x01(\bR\x10outboundDownlink\x1a^\n" +
+	"\x11InboundLimitEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x123\n" +
+	"\x05value\x18\x02 \x01(\v2\x1d.xray.app.policy.Policy.LimitR\x05value:\x028\x01\"\xcc\x01\n" +
 	"\x06Config\x128\n" +
 	"\x05level\x18\x01 \x03(\v2\".xray.app.policy.Config.LevelEntryR\x05level\x125\n" +
 	"\x06system\x18\x02 \x01(\v2\x1d.xray.app.policy.SystemPolicyR\x06system\x1aQ\n" +
@@ -518,7 +612,7 @@ func file_app_policy_config_proto_rawDescGZIP() []byte {
 	return file_app_policy_config_proto_rawDescData
 }
 
-var file_app_policy_config_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_app_policy_config_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_app_policy_config_proto_goTypes = []any{
 	(*Second)(nil),             // 0: xray.app.policy.Second
 	(*Policy)(nil),             // 1: xray.app.policy.Policy
@@ -527,26 +621,31 @@ var file_app_policy_config_proto_goTypes = []any{
 	(*Policy_Timeout)(nil),     // 4: xray.app.policy.Policy.Timeout
 	(*Policy_Stats)(nil),       // 5: xray.app.policy.Policy.Stats
 	(*Policy_Buffer)(nil),      // 6: xray.app.policy.Policy.Buffer
-	(*SystemPolicy_Stats)(nil), // 7: xray.app.policy.SystemPolicy.Stats
-	nil,                        // 8: xray.app.policy.Config.LevelEntry
+	(*Policy_Limit)(nil),       // 7: xray.app.policy.Policy.Limit
+	(*SystemPolicy_Stats)(nil), // 8: xray.app.policy.SystemPolicy.Stats
+	nil,                        // 9: xray.app.policy.SystemPolicy.InboundLimitEntry
+	nil,                        // 10: xray.app.policy.Config.LevelEntry
 }
 var file_app_policy_config_proto_depIdxs = []int32{
 	4,  // 0: xray.app.policy.Policy.timeout:type_name -> xray.app.policy.Policy.Timeout
 	5,  // 1: xray.app.policy.Policy.stats:type_name -> xray.app.policy.Policy.Stats
 	6,  // 2: xray.app.policy.Policy.buffer:type_name -> xray.app.policy.Policy.Buffer
-	7,  // 3: xray.app.policy.SystemPolicy.stats:type_name -> xray.app.policy.SystemPolicy.Stats
-	8,  // 4: xray.app.policy.Config.level:type_name -> xray.app.policy.Config.LevelEntry
-	2,  // 5: xray.app.policy.Config.system:type_name -> xray.app.policy.SystemPolicy
-	0,  // 6: xray.app.policy.Policy.Timeout.handshake:type_name -> xray.app.policy.Second
-	0,  // 7: xray.app.policy.Policy.Timeout.connection_idle:type_name -> xray.app.policy.Second
-	0,  // 8: xray.app.policy.Policy.Timeout.uplink_only:type_name -> xray.app.policy.Second
-	0,  // 9: xray.app.policy.Policy.Timeout.downlink_only:type_name -> xray.app.policy.Second
-	1,  // 10: xray.app.policy.Config.LevelEntry.value:type_name -> xray.app.policy.Policy
-	11, // [11:11] is the sub-list for method output_type
-	11, // [11:11] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	7,  // 3: xray.app.policy.Policy.limit:type_name -> xray.app.policy.Policy.Limit
+	8,  // 4: xray.app.policy.SystemPolicy.stats:type_name -> xray.app.policy.SystemPolicy.Stats
+	9,  // 5: xray.app.policy.SystemPolicy.inbound_limit:type_name -> xray.app.policy.SystemPolicy.InboundLimitEntry
+	10, // 6: xray.app.policy.Config.level:type_name -> xray.app.policy.Config.LevelEntry
+	2,  // 7: xray.app.policy.Config.system:type_name -> xray.app.policy.SystemPolicy
+	0,  // 8: xray.app.policy.Policy.Timeout.handshake:type_name -> xray.app.policy.Second
+	0,  // 9: xray.app.policy.Policy.Timeout.connection_idle:type_name -> xray.app.policy.Second
+	0,  // 10: xray.app.policy.Policy.Timeout.uplink_only:type_name -> xray.app.policy.Second
+	0,  // 11: xray.app.policy.Policy.Timeout.downlink_only:type_name -> xray.app.policy.Second
+	7,  // 12: xray.app.policy.SystemPolicy.InboundLimitEntry.value:type_name -> xray.app.policy.Policy.Limit
+	1,  // 13: xray.app.policy.Config.LevelEntry.value:type_name -> xray.app.policy.Policy
+	14, // [14:14] is the sub-list for method output_type
+	14, // [14:14] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_app_policy_config_proto_init() }
@@ -560,7 +659,7 @@ func file_app_policy_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_app_policy_config_proto_rawDesc), len(file_app_policy_config_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
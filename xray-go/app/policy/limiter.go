@@ -0,0 +1,76 @@
+package policy
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xtls/xray-core/features/policy"
+	"golang.org/x/time/rate"
+)
+
+// minBurst is the least burst of a rate limiter, so that a buffer of a
+// connection does not need to be split when the rate is low.
+const minBurst = 64 * 1024
+
+// RateLimiter is an implementation of policy.RateLimiter, as a token bucket
+// refilled with the rate every second.
+type RateLimiter struct {
+	limiter *rate.Limiter
+}
+
+// NewRateLimiter creates a RateLimiter allowing bytesPerSecond.
+func NewRateLimiter(bytesPerSecond uint64) *RateLimiter {
+	return &RateLimiter{
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), burstOf(bytesPerSecond)),
+	}
+}
+
+func burstOf(bytesPerSecond uint64) int {
+	return int(max(bytesPerSecond, minBurst))
+}
+
+// setRate changes the limiter to allow bytesPerSecond, for the connections
+// using it already as well.
+func (l *RateLimiter) setRate(bytesPerSecond uint64) {
+	if l.limiter.Limit() == rate.Limit(bytesPerSecond) {
+		return
+	}
+	l.limiter.SetLimit(rate.Limit(bytesPerSecond))
+	l.limiter.SetBurst(burstOf(bytesPerSecond))
+}
+
+// WaitN implements policy.RateLimiter.
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	burst := l.limiter.Burst()
+	for n > 0 {
+		c := min(n, burst)
+		if err := l.limiter.WaitN(ctx, c); err != nil {
+			return err
+		}
+		n -= c
+	}
+	return nil
+}
+
+type rateLimiters struct {
+	access   sync.Mutex
+	limiters map[string]*RateLimiter
+}
+
+// getOrRegister returns the limiter with name, changed to allow bytesPerSecond
+// if the policy is reloaded with another rate.
+func (r *rateLimiters) getOrRegister(name string, bytesPerSecond uint64) policy.RateLimiter {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	if l, found := r.limiters[name]; found {
+		l.setRate(bytesPerSecond)
+		return l
+	}
+	if r.limiters == nil {
+		r.limiters = make(map[string]*RateLimiter)
+	}
+	l := NewRateLimiter(bytesPerSecond)
+	r.limiters[name] = l
+	return l
+}
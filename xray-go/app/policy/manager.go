@@ -9,8 +9,9 @@ import (
 
 // Instance is an instance of Policy manager.
 type Instance struct {
-	levels map[uint32]*Policy
-	system *SystemPolicy
+	levels   map[uint32]*Policy
+	system   *SystemPolicy
+	limiters rateLimiters
 }
 
 // New creates new Policy manager instance.
@@ -51,6 +52,11 @@ func (m *Instance) ForSystem() policy.System {
 	return m.system.ToCorePolicy()
 }
 
+// GetOrRegisterRateLimiter implements policy.RateLimiterManager.
+func (m *Instance) GetOrRegisterRateLimiter(name string, rate uint64) policy.RateLimiter {
+	return m.limiters.getOrRegister(name, rate)
+}
+
 // Start implements common.Runnable.Start().
 func (m *Instance) Start() error {
 	return nil
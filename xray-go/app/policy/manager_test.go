@@ -43,3 +43,28 @@ func TestPolicy(t *testing.T) {
 		}
 	}
 }
+
+func TestRateLimiterReload(t *testing.T) {
+	manager, err := New(context.Background(), &Config{})
+	common.Must(err)
+
+	wait := func(l policy.RateLimiter) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		return l.WaitN(ctx, 64*1024)
+	}
+
+	l := manager.GetOrRegisterRateLimiter("test", 64*1024)
+	common.Must(wait(l))
+	if err := wait(l); err == nil {
+		t.Error("expected rate limited")
+	}
+
+	// The limiter is kept with the new rate.
+	if manager.GetOrRegisterRateLimiter("test", 1024*1024*1024) != l {
+		t.Error("expected the same limiter")
+	}
+	if err := wait(l); err != nil {
+		t.Error("expected the new rate, but got ", err)
+	}
+}
@@ -210,14 +210,20 @@ type service struct {
 	v *core.Instance
 }
 
-func (s *service) Register(server *grpc.Server) {
+// NewHandlerServer creates a handler service operating on the given instance.
+func NewHandlerServer(v *core.Instance) HandlerServiceServer {
 	hs := &handlerServer{
-		s: s.v,
+		s: v,
 	}
-	common.Must(s.v.RequireFeatures(func(im inbound.Manager, om outbound.Manager) {
+	common.Must(v.RequireFeatures(func(im inbound.Manager, om outbound.Manager) {
 		hs.ihm = im
 		hs.ohm = om
 	}, false))
+	return hs
+}
+
+func (s *service) Register(server *grpc.Server) {
+	hs := NewHandlerServer(s.v)
 	RegisterHandlerServiceServer(server, hs)
 
 	// For compatibility purposes
@@ -187,7 +187,10 @@ func (h *Handler) Dispatch(ctx context.Context, link *transport.Link) {
 		if ob.Target.Network == net.Network_UDP && ob.OriginalTarget.Address != nil {
 			strategy = strategy.GetDynamicStrategy(ob.OriginalTarget.Address.Family())
 		}
+		_, span := session.StartSpan(ctx, "dns")
+		span.SetAttribute("domain", ob.Target.Address.Domain())
 		ips, err := internet.LookupForIP(ob.Target.Address.Domain(), strategy, nil)
+		span.End(err)
 		if err != nil {
 			errors.LogInfoInner(ctx, err, "failed to resolve ip for target ", ob.Target.Address.Domain())
 			if h.senderSettings.TargetStrategy.ForceIP() {
@@ -309,7 +312,11 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (stat.Connecti
 		}
 	}
 
-	conn, err := internet.Dial(ctx, dest, h.streamSettings)
+	dialCtx, span := session.StartSpan(ctx, "dial")
+	span.SetAttribute("outbound", h.tag)
+	span.SetAttribute("destination", dest.String())
+	conn, err := internet.Dial(dialCtx, dest, h.streamSettings)
+	span.End(err)
 	conn = h.getStatCouterConnection(conn)
 	outbounds := session.OutboundsFromContext(ctx)
 	if outbounds != nil {
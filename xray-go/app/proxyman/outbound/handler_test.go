@@ -18,6 +18,7 @@ import (
 	core "github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/outbound"
 	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/transport"
 	"github.com/xtls/xray-core/transport/internet/stat"
 )
 
@@ -173,3 +174,75 @@ func TestTagsCache(t *testing.T) {
 	stop_get = true
 	wg_get.Wait()
 }
+
+type testHandler struct {
+	tag    string
+	closed bool
+}
+
+func (h *testHandler) Start() error                                       { return nil }
+func (h *testHandler) Close() error                                       { h.closed = true; return nil }
+func (h *testHandler) Tag() string                                        { return h.tag }
+func (h *testHandler) Dispatch(ctx context.Context, link *transport.Link) {}
+func (h *testHandler) SenderSettings() *serial.TypedMessage               { return nil }
+func (h *testHandler) ProxySettings() *serial.TypedMessage                { return nil }
+
+func TestReplaceHandlers(t *testing.T) {
+	ohm, err := New(context.Background(), &proxyman.OutboundConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := &testHandler{tag: "sub-old"}
+	if err := ohm.AddHandler(context.Background(), old); err != nil {
+		t.Fatal(err)
+	}
+
+	// The handlers with duplicated tags are closed, as they are not added.
+	a := &testHandler{tag: "sub-a"}
+	dup := &testHandler{tag: "sub-a"}
+	if err := ohm.ReplaceHandlers(context.Background(), "sub-", []outbound.Handler{a, dup}); err == nil {
+		t.Error("expected error of duplicated tag")
+	}
+	if !old.closed || a.closed || !dup.closed {
+		t.Error("unexpected handlers closed: ", old.closed, " ", a.closed, " ", dup.closed)
+	}
+	if ohm.GetHandler("sub-a") != a || ohm.GetHandler("sub-old") != nil {
+		t.Error("unexpected handlers after replacing")
+	}
+
+	// The handlers are closed if they are rejected at all.
+	other := &testHandler{tag: "other"}
+	if err := ohm.ReplaceHandlers(context.Background(), "sub-", []outbound.Handler{other}); err == nil {
+		t.Error("expected error of tag without prefix")
+	}
+	if !other.closed || a.closed || ohm.GetHandler("sub-a") != a {
+		t.Error("unexpected handlers after rejecting")
+	}
+}
+
+func TestSetDefaultHandler(t *testing.T) {
+	ohm, err := New(context.Background(), &proxyman.OutboundConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &testHandler{tag: "a"}
+	b := &testHandler{tag: "b"}
+	for _, h := range []outbound.Handler{a, b} {
+		if err := ohm.AddHandler(context.Background(), h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ohm.SetDefaultHandler("b"); err != nil {
+		t.Fatal(err)
+	}
+	if ohm.GetDefaultHandler() != b || ohm.GetHandler("a") != a {
+		t.Error("unexpected handlers after setting default")
+	}
+	if err := ohm.SetDefaultHandler("c"); err == nil {
+		t.Error("expected error of unknown tag")
+	}
+	if ohm.GetDefaultHandler() != b {
+		t.Error("expected default handler kept on failure")
+	}
+}
@@ -99,6 +99,19 @@ func (m *Manager) GetHandler(tag string) outbound.Handler {
 	return nil
 }
 
+// SetDefaultHandler implements outbound.DefaultHandlerSetter.
+func (m *Manager) SetDefaultHandler(tag string) error {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	handler, found := m.taggedHandler[tag]
+	if !found {
+		return errors.New("outbound handler not found: " + tag)
+	}
+	m.defaultHandler = handler
+	return nil
+}
+
 // AddHandler implements outbound.Manager.
 func (m *Manager) AddHandler(ctx context.Context, handler outbound.Handler) error {
 	m.access.Lock()
@@ -145,6 +158,62 @@ func (m *Manager) RemoveHandler(ctx context.Context, tag string) error {
 	return nil
 }
 
+// ReplaceHandlers implements outbound.HandlerGroupReplacer.
+func (m *Manager) ReplaceHandlers(ctx context.Context, prefix string, handlers []outbound.Handler) error {
+	reject := func(err error) error {
+		for _, handler := range handlers {
+			common.Close(handler)
+		}
+		return err
+	}
+	if prefix == "" {
+		return reject(errors.New("empty tag prefix"))
+	}
+	for _, handler := range handlers {
+		if !strings.HasPrefix(handler.Tag(), prefix) {
+			return reject(errors.New("tag ", handler.Tag(), " does not start with ", prefix))
+		}
+	}
+
+	m.access.Lock()
+
+	m.tagsCache = &sync.Map{}
+
+	var removed []outbound.Handler
+	for tag, handler := range m.taggedHandler {
+		if strings.HasPrefix(tag, prefix) {
+			removed = append(removed, handler)
+			delete(m.taggedHandler, tag)
+		}
+	}
+	if m.defaultHandler != nil && strings.HasPrefix(m.defaultHandler.Tag(), prefix) {
+		m.defaultHandler = nil
+	}
+
+	var errs []error
+	for _, handler := range handlers {
+		if _, found := m.taggedHandler[handler.Tag()]; found {
+			errs = append(errs, errors.New("existing tag found: "+handler.Tag()))
+			removed = append(removed, handler)
+			continue
+		}
+		m.taggedHandler[handler.Tag()] = handler
+		if m.defaultHandler == nil {
+			m.defaultHandler = handler
+		}
+		if m.running {
+			errs = append(errs, handler.Start())
+		}
+	}
+
+	m.access.Unlock()
+
+	for _, handler := range removed {
+		errs = append(errs, handler.Close())
+	}
+	return errors.Combine(errs...)
+}
+
 // ListHandlers implements outbound.Manager.
 func (m *Manager) ListHandlers(ctx context.Context) []outbound.Handler {
 	m.access.RLock()
@@ -0,0 +1,203 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.5
+// source: app/quota/config.proto
+
+package quota
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type User struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Email string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	// Total traffic of uplink and downlink in bytes.
+	Quota uint64 `protobuf:"varint,2,opt,name=quota,proto3" json:"quota,omitempty"`
+	// Cron expression of when the traffic of the user is reset.
+	ResetCron     string `protobuf:"bytes,3,opt,name=reset_cron,json=resetCron,proto3" json:"reset_cron,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_app_quota_config_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_app_quota_config_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_app_quota_config_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *User) GetQuota() uint64 {
+	if x != nil {
+		return x.Quota
+	}
+	return 0
+}
+
+func (x *User) GetResetCron() string {
+	if x != nil {
+		return x.ResetCron
+	}
+	return ""
+}
+
+type Config struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Users []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	// File keeping the traffic of users across restarts.
+	StateFile     string `protobuf:"bytes,2,opt,name=state_file,json=stateFile,proto3" json:"state_file,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_app_quota_config_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_app_quota_config_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_app_quota_config_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Config) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *Config) GetStateFile() string {
+	if x != nil {
+		return x.StateFile
+	}
+	return ""
+}
+
+var File_app_quota_config_proto protoreflect.FileDescriptor
+
+const file_app_quota_config_proto_rawDesc = "" +
+	"\n" +
+	"\x16app/quota/config.proto\x12\x0exray.app.quota\"Q\n" +
+	"\x04User\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x14\n" +
+	"\x05quota\x18\x02 \x01(\x04R\x05quota\x12\x1d\n" +
+	"\n" +
+	"reset_cron\x18\x03 \x01(\tR\tresetCron\"S\n" +
+	"\x06Config\x12*\n" +
+	"\x05users\x18\x01 \x03(\v2\x14.xray.app.quota.UserR\x05users\x12\x1d\n" +
+	"\n" +
+	"state_file\x18\x02 \x01(\tR\tstateFileBL\n" +
+	"\x12com.xray.app.quotaP\x01Z#github.com/xtls/xray-core/app/quota\xaa\x02\x0eXray.App.Quotab\x06proto3"
+
+var (
+	file_app_quota_config_proto_rawDescOnce sync.Once
+	file_app_quota_config_proto_rawDescData []byte
+)
+
+func file_app_quota_config_proto_rawDescGZIP() []byte {
+	file_app_quota_config_proto_rawDescOnce.Do(func() {
+		file_app_quota_config_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_app_quota_config_proto_rawDesc), len(file_app_quota_config_proto_rawDesc)))
+	})
+	return file_app_quota_config_proto_rawDescData
+}
+
+var file_app_quota_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_app_quota_config_proto_goTypes = []any{
+	(*User)(nil),   // 0: xray.app.quota.User
+	(*Config)(nil), // 1: xray.app.quota.Config
+}
+var file_app_quota_config_proto_depIdxs = []int32{
+	0, // 0: xray.app.quota.Config.users:type_name -> xray.app.quota.User
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_app_quota_config_proto_init() }
+func file_app_quota_config_proto_init() {
+	if File_app_quota_config_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_app_quota_config_proto_rawDesc), len(file_app_quota_config_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_app_quota_config_proto_goTypes,
+		DependencyIndexes: file_app_quota_config_proto_depIdxs,
+		MessageInfos:      file_app_quota_config_proto_msgTypes,
+	}.Build()
+	File_app_quota_config_proto = out.File
+	file_app_quota_config_proto_goTypes = nil
+	file_app_quota_config_proto_depIdxs = nil
+}
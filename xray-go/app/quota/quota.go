@@ -0,0 +1,252 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/inbound"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+	"github.com/xtls/xray-core/proxy"
+)
+
+const checkInterval = 10 * time.Second
+
+type user struct {
+	email    string
+	quota    uint64
+	schedule cron.Schedule
+}
+
+// removedUser is a user removed from an inbound, to be added back on reset.
+type removedUser struct {
+	tag  string
+	user *protocol.MemoryUser
+}
+
+// Instance counts the traffic of users from their stats counters, removes
+// users from all inbounds once they exceed their quotas, and adds them back
+// when their traffic is reset.
+type Instance struct {
+	ctx       context.Context
+	users     []*user
+	stateFile string
+	ihm       inbound.Manager
+	sm        stats.Manager
+	sessions  routing.UserSessionCloser
+	checker   *task.Periodic
+
+	access  sync.Mutex
+	state   map[string]*userState
+	last    map[string]int64
+	removed map[string][]removedUser
+	saved   []byte
+}
+
+func New(ctx context.Context, config *Config) (*Instance, error) {
+	q := &Instance{
+		ctx:       ctx,
+		stateFile: config.StateFile,
+		state:     make(map[string]*userState),
+		last:      make(map[string]int64),
+		removed:   make(map[string][]removedUser),
+	}
+	for _, u := range config.Users {
+		schedule, err := cron.ParseStandard(u.ResetCron)
+		if err != nil {
+			return nil, errors.New("invalid reset cron of user ", u.Email).Base(err)
+		}
+		q.users = append(q.users, &user{
+			email:    u.Email,
+			quota:    u.Quota,
+			schedule: schedule,
+		})
+	}
+
+	if err := core.RequireFeatures(ctx, func(ihm inbound.Manager, sm stats.Manager, d routing.Dispatcher) {
+		q.ihm = ihm
+		q.sm = sm
+		q.sessions, _ = d.(routing.UserSessionCloser)
+	}); err != nil {
+		return nil, err
+	}
+	q.checker = &task.Periodic{
+		Interval: checkInterval,
+		Execute: func() error {
+			q.check(time.Now())
+			return nil
+		},
+	}
+	return q, nil
+}
+
+// check accounts the traffic of users since the last check, resets it for
+// users whose period is over, and applies their quotas.
+func (q *Instance) check(now time.Time) {
+	q.access.Lock()
+	defer q.access.Unlock()
+
+	for _, u := range q.users {
+		s := q.state[u.email]
+		if s == nil {
+			s = &userState{PeriodStart: now}
+			q.state[u.email] = s
+		}
+
+		for _, name := range trafficCounters(u.email) {
+			s.Used += q.delta(name)
+		}
+
+		if !u.schedule.Next(s.PeriodStart).After(now) {
+			errors.LogInfo(q.ctx, "reset traffic of user ", u.email, " after ", s.Used, " bytes")
+			s.Used = 0
+			s.PeriodStart = now
+			// The quota of the user in its policy is reset along.
+			if c := q.sm.GetCounter("user>>>" + u.email + ">>>quota>>>used"); c != nil {
+				c.Set(0)
+			}
+			if s.Suspended {
+				q.restore(u.email)
+				s.Suspended = false
+			}
+		}
+
+		if s.Used >= u.quota && !s.Suspended {
+			errors.LogWarning(q.ctx, "user ", u.email, " exceeded quota of ", u.quota, " bytes")
+			q.suspend(u.email)
+			s.Suspended = true
+		}
+	}
+
+	if err := q.save(); err != nil {
+		errors.LogWarningInner(q.ctx, err, "failed to save quota state")
+	}
+}
+
+func trafficCounters(email string) []string {
+	return []string{
+		"user>>>" + email + ">>>traffic>>>uplink",
+		"user>>>" + email + ">>>traffic>>>downlink",
+	}
+}
+
+// delta returns the traffic on the counter since the last check. A counter
+// which is smaller than before has been reset, such as by the stats service.
+func (q *Instance) delta(name string) uint64 {
+	c := q.sm.GetCounter(name)
+	if c == nil {
+		return 0
+	}
+	value := c.Value()
+	last := q.last[name]
+	q.last[name] = value
+	if value < last {
+		return uint64(value)
+	}
+	return uint64(value - last)
+}
+
+func userManager(handler inbound.Handler) proxy.UserManager {
+	gi, ok := handler.(proxy.GetInbound)
+	if !ok {
+		return nil
+	}
+	um, _ := gi.GetInbound().(proxy.UserManager)
+	return um
+}
+
+// suspend removes the user from all inbounds, as RemoveUserOperation of the
+// handler service does, and closes the sessions of the user.
+func (q *Instance) suspend(email string) {
+	for _, handler := range q.ihm.ListHandlers(q.ctx) {
+		um := userManager(handler)
+		if um == nil {
+			continue
+		}
+		mUser := um.GetUser(q.ctx, email)
+		if mUser == nil {
+			continue
+		}
+		if err := um.RemoveUser(q.ctx, email); err != nil {
+			errors.LogWarningInner(q.ctx, err, "failed to remove user ", email, " from inbound ", handler.Tag())
+			continue
+		}
+		q.removed[email] = append(q.removed[email], removedUser{tag: handler.Tag(), user: mUser})
+	}
+	if q.sessions != nil {
+		if n := q.sessions.CloseUserSessions(email); n > 0 {
+			errors.LogInfo(q.ctx, "closed ", n, " sessions of user ", email)
+		}
+	}
+}
+
+// restore adds the user back to the inbounds it was removed from.
+func (q *Instance) restore(email string) {
+	for _, r := range q.removed[email] {
+		handler, err := q.ihm.GetHandler(q.ctx, r.tag)
+		if err != nil {
+			errors.LogWarningInner(q.ctx, err, "failed to find inbound ", r.tag, " to restore user ", email)
+			continue
+		}
+		um := userManager(handler)
+		if um == nil {
+			continue
+		}
+		if err := um.AddUser(q.ctx, r.user); err != nil {
+			errors.LogWarningInner(q.ctx, err, "failed to restore user ", email, " to inbound ", r.tag)
+		}
+	}
+	delete(q.removed, email)
+}
+
+func (q *Instance) Type() interface{} {
+	return (*Instance)(nil)
+}
+
+// Start loads the saved state, and removes users still over their quotas
+// from the inbounds they are configured in.
+func (q *Instance) Start() error {
+	state, err := loadState(q.stateFile)
+	if err != nil {
+		return errors.New("failed to load quota state").Base(err)
+	}
+
+	q.access.Lock()
+	for _, u := range q.users {
+		// Traffic on counters before start is not counted again, as it is in
+		// the saved state if any.
+		for _, name := range trafficCounters(u.email) {
+			if c := q.sm.GetCounter(name); c != nil {
+				q.last[name] = c.Value()
+			}
+		}
+		if s := state[u.email]; s != nil {
+			q.state[u.email] = s
+			if s.Suspended {
+				q.suspend(u.email)
+			}
+		}
+	}
+	q.access.Unlock()
+
+	return q.checker.Start()
+}
+
+func (q *Instance) Close() error {
+	q.checker.Close()
+	q.check(time.Now())
+	return nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		return New(ctx, cfg.(*Config))
+	}))
+}
@@ -0,0 +1,119 @@
+package quota
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/proxyman"
+	_ "github.com/xtls/xray-core/app/proxyman/inbound"
+	_ "github.com/xtls/xray-core/app/proxyman/outbound"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/common/uuid"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/proxy/vless"
+	"github.com/xtls/xray-core/proxy/vless/inbound"
+	"github.com/xtls/xray-core/testing/servers/tcp"
+)
+
+func newInstance(t *testing.T, stateFile string) (*core.Instance, *Instance) {
+	t.Helper()
+
+	id := uuid.New()
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&Config{
+				Users: []*User{
+					{Email: "a", Quota: 100, ResetCron: "@monthly"},
+				},
+				StateFile: stateFile,
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				Tag: "in",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(tcp.PickPort())}},
+					Listen:   net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&inbound.Config{
+					Users: []*protocol.User{
+						{
+							Email:   "a",
+							Account: serial.ToTypedMessage(&vless.Account{Id: id.String()}),
+						},
+					},
+				}),
+			},
+		},
+	}
+
+	server, err := core.New(config)
+	common.Must(err)
+	return server, server.GetFeature((*Instance)(nil)).(*Instance)
+}
+
+func hasUser(q *Instance, email string) bool {
+	handler, err := q.ihm.GetHandler(context.Background(), "in")
+	common.Must(err)
+	return userManager(handler).GetUser(context.Background(), email) != nil
+}
+
+func TestQuota(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "quota.json")
+	server, q := newInstance(t, stateFile)
+	common.Must(server.Start())
+
+	counter, err := q.sm.RegisterCounter("user>>>a>>>traffic>>>uplink")
+	common.Must(err)
+	counter.Add(60)
+	q.check(time.Now())
+	if !hasUser(q, "a") {
+		t.Fatal("user removed under quota")
+	}
+
+	counter.Add(60)
+	q.check(time.Now())
+	if hasUser(q, "a") {
+		t.Fatal("user not removed over quota")
+	}
+
+	// The state survives restarts, and the user is removed again.
+	common.Must(server.Close())
+	server, q = newInstance(t, stateFile)
+	common.Must(server.Start())
+	if hasUser(q, "a") {
+		t.Fatal("user not removed after restart")
+	}
+	if s := q.state["a"]; s == nil || s.Used != 120 || !s.Suspended {
+		t.Fatal("unexpected state after restart: ", s)
+	}
+
+	// The user is added back when the period is over, with the quota in its
+	// policy reset as well.
+	used, err := q.sm.RegisterCounter("user>>>a>>>quota>>>used")
+	common.Must(err)
+	used.Set(50)
+	q.state["a"].PeriodStart = time.Now().AddDate(0, -2, 0)
+	q.check(time.Now())
+	if !hasUser(q, "a") {
+		t.Fatal("user not restored after reset")
+	}
+	if s := q.state["a"]; s.Used != 0 || s.Suspended {
+		t.Fatal("unexpected state after reset: ", s)
+	}
+	if used.Value() != 0 {
+		t.Error("expected quota used reset, but got ", used.Value())
+	}
+	common.Must(server.Close())
+}
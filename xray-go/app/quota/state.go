@@ -0,0 +1,55 @@
+package quota
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/xtls/xray-core/common/platform/filesystem"
+)
+
+// userState is the traffic of a user in the current period.
+type userState struct {
+	Used        uint64    `json:"used"`
+	PeriodStart time.Time `json:"periodStart"`
+	Suspended   bool      `json:"suspended"`
+}
+
+// loadState reads the state saved in file, which is empty if there is no file.
+func loadState(file string) (map[string]*userState, error) {
+	state := make(map[string]*userState)
+	if file == "" {
+		return state, nil
+	}
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// save writes the state to the state file.
+func (q *Instance) save() error {
+	if q.stateFile == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(q.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(b, q.saved) {
+		return nil
+	}
+	if err := filesystem.WriteFileAtomic(q.stateFile, b); err != nil {
+		return err
+	}
+	q.saved = b
+	return nil
+}
@@ -141,8 +141,11 @@ type service struct {
 }
 
 func (s *service) Register(server *grpc.Server) {
-	common.Must(s.v.RequireFeatures(func(router routing.Router, stats stats.Manager) {
-		rs := NewRoutingServer(router, nil)
+	common.Must(s.v.RequireFeatures(func(router routing.Router, sm stats.Manager) {
+		// Routing stats are only available with the stats app, since
+		// stats.NoopManager has no channels.
+		routingStats, _ := sm.GetOrRegisterChannel(routing.StatsChannel)
+		rs := NewRoutingServer(router, routingStats)
 		RegisterRoutingServiceServer(server, rs)
 
 		// For compatibility purposes
@@ -0,0 +1,157 @@
+package router
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// RouteEvent is the structured form of a routing decision published to the
+// routing stats channel.
+type RouteEvent struct {
+	Time        time.Time `json:"time"`
+	InboundTag  string    `json:"inboundTag,omitempty"`
+	OutboundTag string    `json:"outboundTag"`
+	RuleTag     string    `json:"ruleTag,omitempty"`
+	Network     string    `json:"network,omitempty"`
+	Protocol    string    `json:"protocol,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Target      string    `json:"target,omitempty"`
+}
+
+// NewRouteEvent converts route into a RouteEvent.
+func NewRouteEvent(route routing.Route) *RouteEvent {
+	event := &RouteEvent{
+		Time:        time.Now(),
+		InboundTag:  route.GetInboundTag(),
+		OutboundTag: route.GetOutboundTag(),
+		RuleTag:     route.GetRuleTag(),
+		Network:     route.GetNetwork().SystemString(),
+		Protocol:    route.GetProtocol(),
+		Email:       route.GetUser(),
+	}
+	if srcIPs := route.GetSourceIPs(); len(srcIPs) > 0 {
+		event.Source = net.JoinHostPort(srcIPs[0].String(), route.GetSourcePort().String())
+	}
+	if domain := route.GetTargetDomain(); domain != "" {
+		event.Target = net.JoinHostPort(domain, route.GetTargetPort().String())
+	} else if targetIPs := route.GetTargetIPs(); len(targetIPs) > 0 {
+		event.Target = net.JoinHostPort(targetIPs[0].String(), route.GetTargetPort().String())
+	}
+	return event
+}
+
+// RouteEventQueue is a bounded queue of the routing decisions published to a
+// routing stats channel, meant to be polled by an embedding application. When
+// the queue is full, either the oldest or the newest event is dropped.
+type RouteEventQueue struct {
+	sync.Mutex
+	events     []*RouteEvent
+	start      int
+	size       int
+	dropped    uint64
+	dropOldest bool
+
+	channel    stats.Channel
+	subscriber chan interface{}
+	closed     chan struct{}
+	closeOnce  sync.Once
+}
+
+// SubscribeRouteEvents subscribes to channel, usually the one named
+// routing.StatsChannel, and queues up to capacity events from it until the
+// queue or the channel is closed.
+func SubscribeRouteEvents(channel stats.Channel, capacity int, dropOldest bool) (*RouteEventQueue, error) {
+	if capacity < 1 {
+		capacity = 1
+	}
+	subscriber, err := stats.SubscribeRunnableChannel(channel)
+	if err != nil {
+		return nil, err
+	}
+	q := &RouteEventQueue{
+		events:     make([]*RouteEvent, capacity),
+		dropOldest: dropOldest,
+		channel:    channel,
+		subscriber: subscriber,
+		closed:     make(chan struct{}),
+	}
+	go q.run()
+	return q, nil
+}
+
+func (q *RouteEventQueue) run() {
+	for {
+		select {
+		case msg, ok := <-q.subscriber:
+			if !ok {
+				return
+			}
+			if route, ok := msg.(routing.Route); ok {
+				q.push(NewRouteEvent(route))
+			}
+		case <-q.closed:
+			return
+		}
+	}
+}
+
+func (q *RouteEventQueue) push(event *RouteEvent) {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.size == len(q.events) {
+		q.dropped++
+		if !q.dropOldest {
+			return
+		}
+		q.events[q.start] = event
+		q.start = (q.start + 1) % len(q.events)
+		return
+	}
+	q.events[(q.start+q.size)%len(q.events)] = event
+	q.size++
+}
+
+// Poll removes and returns up to max queued events, oldest first. All queued
+// events are returned if max is not positive.
+func (q *RouteEventQueue) Poll(max int) []*RouteEvent {
+	q.Lock()
+	defer q.Unlock()
+
+	if max <= 0 || max > q.size {
+		max = q.size
+	}
+	events := make([]*RouteEvent, 0, max)
+	for i := 0; i < max; i++ {
+		events = append(events, q.events[q.start])
+		q.events[q.start] = nil
+		q.start = (q.start + 1) % len(q.events)
+	}
+	q.size -= max
+	return events
+}
+
+// Dropped returns the number of events discarded because the queue was full.
+func (q *RouteEventQueue) Dropped() uint64 {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.dropped
+}
+
+// Close unsubscribes from the channel. Events already queued can still be
+// polled. The channel is left running, as it is owned by the stats manager and
+// restarting a channel may close the subscribers that come next.
+func (q *RouteEventQueue) Close() error {
+	var err error
+	q.closeOnce.Do(func() {
+		close(q.closed)
+		err = q.channel.Unsubscribe(q.subscriber)
+	})
+	return err
+}
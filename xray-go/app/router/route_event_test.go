@@ -0,0 +1,85 @@
+package router_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+	routing_session "github.com/xtls/xray-core/features/routing/session"
+	"github.com/xtls/xray-core/testing/mocks"
+)
+
+func TestRouteEventQueue(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				RuleTag:   "rule",
+				TargetTag: &RoutingRule_Tag{Tag: "test"},
+				Networks:  []net.Network{net.Network_TCP},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mocks.NewDNSClient(mockCtl), &mockOutboundManager{
+		Manager:         mocks.NewOutboundManager(mockCtl),
+		HandlerSelector: mocks.NewOutboundHandlerSelector(mockCtl),
+	}, nil))
+
+	channel := stats.NewChannel(&stats.ChannelConfig{BufferSize: 16})
+	for _, dropOldest := range []bool{true, false} {
+		queue, err := SubscribeRouteEvents(channel, 2, dropOldest)
+		common.Must(err)
+
+		for _, port := range []net.Port{1, 2, 3} {
+			ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+				Tag:    "in",
+				Source: net.TCPDestination(net.LocalHostIP, 1234),
+				User:   &protocol.MemoryUser{Email: "love@xray.com"},
+			})
+			ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{
+				Target: net.TCPDestination(net.DomainAddress("example.com"), port),
+			}})
+			route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+			common.Must(err)
+			channel.Publish(context.Background(), route)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for queue.Dropped() == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		common.Must(queue.Close())
+
+		events := queue.Poll(0)
+		if len(events) != 2 {
+			t.Fatal("expected 2 events, but actually ", len(events))
+		}
+		if queue.Dropped() != 1 {
+			t.Error("expected 1 dropped event, but actually ", queue.Dropped())
+		}
+		first := "example.com:1"
+		if dropOldest {
+			first = "example.com:2"
+		}
+		if events[0].Target != first {
+			t.Error("expected first event to ", first, ", but actually ", events[0].Target)
+		}
+
+		event := events[0]
+		if event.InboundTag != "in" || event.OutboundTag != "test" || event.RuleTag != "rule" ||
+			event.Email != "love@xray.com" || event.Source != "127.0.0.1:1234" || event.Network != "tcp" {
+			t.Error("unexpected event: ", event)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package router
 
 import (
 	"context"
+	"maps"
+	"slices"
 	"sync"
 
 	"github.com/xtls/xray-core/common"
@@ -12,6 +14,7 @@ import (
 	"github.com/xtls/xray-core/features/outbound"
 	"github.com/xtls/xray-core/features/routing"
 	routing_dns "github.com/xtls/xray-core/features/routing/dns"
+	"google.golang.org/protobuf/proto"
 )
 
 // Router is an implementation of routing.Router.
@@ -24,7 +27,11 @@ type Router struct {
 	ctx        context.Context
 	ohm        outbound.Manager
 	dispatcher routing.Dispatcher
-	mu         sync.Mutex
+	mu         sync.RWMutex
+
+	// reloading serializes the changes of rules, so that they are built
+	// without holding mu.
+	reloading sync.Mutex
 }
 
 // Route is an implementation of routing.Route.
@@ -57,7 +64,7 @@ func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm out
 	for _, rule := range config.Rule {
 		cond, err := rule.BuildCondition()
 		if err != nil {
-			r.closeWebhooks()
+			closeWebhooks(r.rules)
 			return err
 		}
 		rr := &Rule{
@@ -68,7 +75,7 @@ func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm out
 		if wh := rule.GetWebhook(); wh != nil {
 			notifier, err := NewWebhookNotifier(wh)
 			if err != nil {
-				r.closeWebhooks()
+				closeWebhooks(r.rules)
 				return err
 			}
 			rr.Webhook = notifier
@@ -80,7 +87,7 @@ func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm out
 				if rr.Webhook != nil {
 					rr.Webhook.Close()
 				}
-				r.closeWebhooks()
+				closeWebhooks(r.rules)
 				return errors.New("balancer ", btag, " not found")
 			}
 			rr.Balancer = brule
@@ -120,51 +127,85 @@ func (r *Router) AddRule(config *serial.TypedMessage, shouldAppend bool) error {
 	return errors.New("AddRule: config type error")
 }
 
-func (r *Router) ReloadRules(config *Config, shouldAppend bool) error {
+// Reload implements features.Reloadable. It replaces all rules and balancers,
+// and the domain strategy with those of config.
+func (r *Router) Reload(config proto.Message) error {
+	c, ok := config.(*Config)
+	if !ok {
+		return errors.New("Reload: config type error")
+	}
+
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+
+	balancers, rules, err := r.buildRules(c, false)
+	if err != nil {
+		return err
+	}
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	oldRules := r.rules
+	r.domainStrategy, r.balancers, r.rules = c.DomainStrategy, balancers, rules
+	r.mu.Unlock()
+	closeWebhooks(oldRules)
+	return nil
+}
+
+func (r *Router) ReloadRules(config *Config, shouldAppend bool) error {
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
 
+	balancers, rules, err := r.buildRules(config, shouldAppend)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	oldRules := r.rules
+	r.balancers, r.rules = balancers, rules
+	r.mu.Unlock()
 	if !shouldAppend {
-		for _, rule := range r.rules {
-			if rule.Webhook != nil {
-				rule.Webhook.Close()
-			}
-		}
-		r.balancers = make(map[string]*Balancer, len(config.BalancingRule))
-		r.rules = make([]*Rule, 0, len(config.Rule))
+		closeWebhooks(oldRules)
+	}
+	return nil
+}
+
+// buildRules builds the balancers and rules of config, after the current ones
+// if shouldAppend. The current ones are left untouched, so that the router
+// keeps working with them if config is invalid.
+func (r *Router) buildRules(config *Config, shouldAppend bool) (map[string]*Balancer, []*Rule, error) {
+	balancers := make(map[string]*Balancer, len(config.BalancingRule))
+	rules := make([]*Rule, 0, len(config.Rule))
+	if shouldAppend {
+		maps.Copy(balancers, r.balancers)
+		rules = slices.Clip(r.rules)
 	}
+
 	for _, rule := range config.BalancingRule {
-		_, found := r.balancers[rule.Tag]
+		_, found := balancers[rule.Tag]
 		if found {
-			return errors.New("duplicate balancer tag")
+			return nil, nil, errors.New("duplicate balancer tag")
 		}
 		balancer, err := rule.Build(r.ohm, r.dispatcher)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		balancer.InjectContext(r.ctx)
-		r.balancers[rule.Tag] = balancer
+		balancers[rule.Tag] = balancer
 	}
 
-	startIdx := len(r.rules)
+	startIdx := len(rules)
 	closeNewWebhooks := func() {
-		for i := startIdx; i < len(r.rules); i++ {
-			if r.rules[i].Webhook != nil {
-				r.rules[i].Webhook.Close()
-			}
-		}
-		r.rules = r.rules[:startIdx]
+		closeWebhooks(rules[startIdx:])
 	}
 
 	for _, rule := range config.Rule {
-		if r.RuleExists(rule.GetRuleTag()) {
+		if ruleExists(rules, rule.GetRuleTag()) {
 			closeNewWebhooks()
-			return errors.New("duplicate ruleTag ", rule.GetRuleTag())
+			return nil, nil, errors.New("duplicate ruleTag ", rule.GetRuleTag())
 		}
 		cond, err := rule.BuildCondition()
 		if err != nil {
 			closeNewWebhooks()
-			return err
+			return nil, nil, err
 		}
 		rr := &Rule{
 			Condition: cond,
@@ -175,31 +216,37 @@ func (r *Router) ReloadRules(config *Config, shouldAppend bool) error {
 			notifier, err := NewWebhookNotifier(wh)
 			if err != nil {
 				closeNewWebhooks()
-				return err
+				return nil, nil, err
 			}
 			rr.Webhook = notifier
 		}
 		btag := rule.GetBalancingTag()
 		if len(btag) > 0 {
-			brule, found := r.balancers[btag]
+			brule, found := balancers[btag]
 			if !found {
 				if rr.Webhook != nil {
 					rr.Webhook.Close()
 				}
 				closeNewWebhooks()
-				return errors.New("balancer ", btag, " not found")
+				return nil, nil, errors.New("balancer ", btag, " not found")
 			}
 			rr.Balancer = brule
 		}
-		r.rules = append(r.rules, rr)
+		rules = append(rules, rr)
 	}
 
-	return nil
+	return balancers, rules, nil
 }
 
 func (r *Router) RuleExists(tag string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return ruleExists(r.rules, tag)
+}
+
+func ruleExists(rules []*Rule, tag string) bool {
 	if tag != "" {
-		for _, rule := range r.rules {
+		for _, rule := range rules {
 			if rule.RuleTag == tag {
 				return true
 			}
@@ -210,19 +257,23 @@ func (r *Router) RuleExists(tag string) bool {
 
 // RemoveRule implements routing.Router.
 func (r *Router) RemoveRule(tag string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
 
 	newRules := []*Rule{}
 	if tag != "" {
+		var removed []*Rule
 		for _, rule := range r.rules {
 			if rule.RuleTag != tag {
 				newRules = append(newRules, rule)
-			} else if rule.Webhook != nil {
-				rule.Webhook.Close()
+			} else {
+				removed = append(removed, rule)
 			}
 		}
+		r.mu.Lock()
 		r.rules = newRules
+		r.mu.Unlock()
+		closeWebhooks(removed)
 		return nil
 	}
 	return errors.New("empty tag name!")
@@ -230,8 +281,8 @@ func (r *Router) RemoveRule(tag string) error {
 
 // ListRule implements routing.Router
 func (r *Router) ListRule() []routing.Route {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	ruleList := make([]routing.Route, 0)
 	for _, rule := range r.rules {
 		ruleList = append(ruleList, &Route{
@@ -248,24 +299,28 @@ func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context,
 	// this prevents cycle resolving dead loop
 	skipDNSResolve := ctx.GetSkipDNSResolve()
 
-	if r.domainStrategy == Config_IpOnDemand && !skipDNSResolve {
+	r.mu.RLock()
+	domainStrategy, rules := r.domainStrategy, r.rules
+	r.mu.RUnlock()
+
+	if domainStrategy == Config_IpOnDemand && !skipDNSResolve {
 		ctx = routing_dns.ContextWithDNSClient(ctx, r.dns)
 	}
 
-	for _, rule := range r.rules {
+	for _, rule := range rules {
 		if rule.Apply(ctx) {
 			return rule, ctx, nil
 		}
 	}
 
-	if r.domainStrategy != Config_IpIfNonMatch || len(ctx.GetTargetDomain()) == 0 || skipDNSResolve {
+	if domainStrategy != Config_IpIfNonMatch || len(ctx.GetTargetDomain()) == 0 || skipDNSResolve {
 		return nil, ctx, common.ErrNoClue
 	}
 
 	ctx = routing_dns.ContextWithDNSClient(ctx, r.dns)
 
 	// Try applying rules again if we have IPs.
-	for _, rule := range r.rules {
+	for _, rule := range rules {
 		if rule.Apply(ctx) {
 			return rule, ctx, nil
 		}
@@ -279,9 +334,9 @@ func (r *Router) Start() error {
 	return nil
 }
 
-// closeWebhooks closes all webhook notifiers in the current rule set.
-func (r *Router) closeWebhooks() {
-	for _, rule := range r.rules {
+// closeWebhooks closes all webhook notifiers in rules.
+func closeWebhooks(rules []*Rule) {
+	for _, rule := range rules {
 		if rule.Webhook != nil {
 			rule.Webhook.Close()
 		}
@@ -290,9 +345,9 @@ func (r *Router) closeWebhooks() {
 
 // Close implements common.Closable.
 func (r *Router) Close() error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.closeWebhooks()
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+	closeWebhooks(r.rules)
 	return nil
 }
 
@@ -274,3 +274,64 @@ func TestIPIfNonMatchIP(t *testing.T) {
 		t.Error("expect tag 'test', bug actually ", tag)
 	}
 }
+
+func TestReloadInvalidConfig(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "test",
+				},
+				Networks: []net.Network{net.Network_TCP},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, &mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	}, nil))
+
+	invalid := &Config{
+		DomainStrategy: Config_IpOnDemand,
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "direct",
+				},
+				Networks: []net.Network{net.Network_TCP},
+			},
+			{
+				TargetTag: &RoutingRule_BalancingTag{
+					BalancingTag: "missing",
+				},
+				Networks: []net.Network{net.Network_UDP},
+			},
+		},
+	}
+	if err := r.Reload(invalid); err == nil {
+		t.Fatal("expected error, but got nil")
+	}
+
+	// The router keeps its rules, and does not resolve the domain with the
+	// domain strategy of the invalid config.
+	ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 80),
+	}})
+	route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+	common.Must(err)
+	if tag := route.GetOutboundTag(); tag != "test" {
+		t.Error("expect tag 'test', bug actually ", tag)
+	}
+	if rules := r.ListRule(); len(rules) != 1 {
+		t.Error("expect 1 rule, but got ", len(rules))
+	}
+}
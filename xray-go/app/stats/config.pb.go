@@ -23,6 +23,7 @@ const (
 
 type Config struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Persistence   *Persistence           `protobuf:"bytes,1,opt,name=persistence,proto3" json:"persistence,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -57,6 +58,78 @@ func (*Config) Descriptor() ([]byte, []int) {
 	return file_app_stats_config_proto_rawDescGZIP(), []int{0}
 }
 
+func (x *Config) GetPersistence() *Persistence {
+	if x != nil {
+		return x.Persistence
+	}
+	return nil
+}
+
+// Persistence saves counters to a file, and restores them on restart.
+type Persistence struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// JSON file to save counters in.
+	File string `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	// Counters whose names contain any of the patterns are saved, which are all
+	// counters if empty.
+	Patterns []string `protobuf:"bytes,2,rep,name=patterns,proto3" json:"patterns,omitempty"`
+	// Seconds between saves. Counters are also saved on close.
+	Interval      uint32 `protobuf:"varint,3,opt,name=interval,proto3" json:"interval,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Persistence) Reset() {
+	*x = Persistence{}
+	mi := &file_app_stats_config_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Persistence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Persistence) ProtoMessage() {}
+
+func (x *Persistence) ProtoReflect() protoreflect.Message {
+	mi := &file_app_stats_config_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Persistence.ProtoReflect.Descriptor instead.
+func (*Persistence) Descriptor() ([]byte, []int) {
+	return file_app_stats_config_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Persistence) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Persistence) GetPatterns() []string {
+	if x != nil {
+		return x.Patterns
+	}
+	return nil
+}
+
+func (x *Persistence) GetInterval() uint32 {
+	if x != nil {
+		return x.Interval
+	}
+	return 0
+}
+
 type ChannelConfig struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	Blocking        bool                   `protobuf:"varint,1,opt,name=Blocking,proto3" json:"Blocking,omitempty"`
@@ -68,7 +141,7 @@ type ChannelConfig struct {
 
 func (x *ChannelConfig) Reset() {
 	*x = ChannelConfig{}
-	mi := &file_app_stats_config_proto_msgTypes[1]
+	mi := &file_app_stats_config_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -80,7 +153,7 @@ func (x *ChannelConfig) String() string {
 func (*ChannelConfig) ProtoMessage() {}
 
 func (x *ChannelConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_app_stats_config_proto_msgTypes[1]
+	mi := &file_app_stats_config_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -93,7 +166,7 @@ func (x *ChannelConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChannelConfig.ProtoReflect.Descriptor instead.
 func (*ChannelConfig) Descriptor() ([]byte, []int) {
-	return file_app_stats_config_proto_rawDescGZIP(), []int{1}
+	return file_app_stats_config_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *ChannelConfig) GetBlocking() bool {
@@ -121,8 +194,13 @@ var File_app_stats_config_proto protoreflect.FileDescriptor
 
 const file_app_stats_config_proto_rawDesc = "" +
 	"\n" +
-	"\x16app/stats/config.proto\x12\x0exray.app.stats\"\b\n" +
-	"\x06Config\"u\n" +
+	"\x16app/stats/config.proto\x12\x0exray.app.stats\"G\n" +
+	"\x06Config\x12=\n" +
+	"\vpersistence\x18\x01 \x01(\v2\x1b.xray.app.stats.PersistenceR\vpersistence\"Y\n" +
+	"\vPersistence\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\tR\x04file\x12\x1a\n" +
+	"\bpatterns\x18\x02 \x03(\tR\bpatterns\x12\x1a\n" +
+	"\binterval\x18\x03 \x01(\rR\binterval\"u\n" +
 	"\rChannelConfig\x12\x1a\n" +
 	"\bBlocking\x18\x01 \x01(\bR\bBlocking\x12(\n" +
 	"\x0fSubscriberLimit\x18\x02 \x01(\x05R\x0fSubscriberLimit\x12\x1e\n" +
@@ -143,17 +221,19 @@ func file_app_stats_config_proto_rawDescGZIP() []byte {
 	return file_app_stats_config_proto_rawDescData
 }
 
-var file_app_stats_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_app_stats_config_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_app_stats_config_proto_goTypes = []any{
 	(*Config)(nil),        // 0: xray.app.stats.Config
-	(*ChannelConfig)(nil), // 1: xray.app.stats.ChannelConfig
+	(*Persistence)(nil),   // 1: xray.app.stats.Persistence
+	(*ChannelConfig)(nil), // 2: xray.app.stats.ChannelConfig
 }
 var file_app_stats_config_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: xray.app.stats.Config.persistence:type_name -> xray.app.stats.Persistence
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_app_stats_config_proto_init() }
@@ -167,7 +247,7 @@ func file_app_stats_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_app_stats_config_proto_rawDesc), len(file_app_stats_config_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
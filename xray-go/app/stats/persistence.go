@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+const defaultPersistInterval = 60 * time.Second
+
+// persistence saves counters of a Manager to a JSON file.
+type persistence struct {
+	file     string
+	patterns []string
+	saver    *task.Periodic
+
+	access sync.Mutex
+	saved  []byte
+}
+
+func newPersistence(m *Manager, config *Persistence) *persistence {
+	p := &persistence{
+		file:     config.File,
+		patterns: config.Patterns,
+	}
+	interval := time.Duration(config.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultPersistInterval
+	}
+	p.saver = &task.Periodic{
+		Interval: interval,
+		Execute: func() error {
+			if err := p.save(m); err != nil {
+				errors.LogWarningInner(context.Background(), err, "failed to save stats to ", p.file)
+			}
+			return nil
+		},
+	}
+	return p
+}
+
+func (p *persistence) match(name string) bool {
+	if len(p.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// restore registers the counters saved in the file with their values.
+func (p *persistence) restore(m *Manager) error {
+	b, err := os.ReadFile(p.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var values map[string]int64
+	if err := json.Unmarshal(b, &values); err != nil {
+		return err
+	}
+	for name, value := range values {
+		if !p.match(name) {
+			continue
+		}
+		c, _ := m.GetOrRegisterCounter(name)
+		c.Set(value)
+	}
+	p.saved = b
+	return nil
+}
+
+// save writes the values of matching counters to the file.
+func (p *persistence) save(m *Manager) error {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	values := make(map[string]int64)
+	m.VisitCounters(func(name string, c stats.Counter) bool {
+		if p.match(name) {
+			values[name] = c.Value()
+		}
+		return true
+	})
+	b, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(b, p.saved) {
+		return nil
+	}
+
+	if err := filesystem.WriteFileAtomic(p.file, b); err != nil {
+		return err
+	}
+	p.saved = b
+	return nil
+}
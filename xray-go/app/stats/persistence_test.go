@@ -0,0 +1,52 @@
+package stats_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+)
+
+func TestPersistence(t *testing.T) {
+	config := &Config{
+		Persistence: &Persistence{
+			File:     filepath.Join(t.TempDir(), "stats.json"),
+			Patterns: []string{"user>>>"},
+		},
+	}
+
+	m, err := NewManager(context.Background(), config)
+	common.Must(err)
+	common.Must(m.Start())
+	user, err := m.RegisterCounter("user>>>a>>>traffic>>>uplink")
+	common.Must(err)
+	user.Add(100)
+	inbound, err := m.RegisterCounter("inbound>>>in>>>traffic>>>uplink")
+	common.Must(err)
+	inbound.Add(100)
+	common.Must(m.Close())
+
+	m, err = NewManager(context.Background(), config)
+	common.Must(err)
+	if c := m.GetCounter("user>>>a>>>traffic>>>uplink"); c == nil || c.Value() != 100 {
+		t.Error("unexpected restored counter: ", c)
+	}
+	if c := m.GetCounter("inbound>>>in>>>traffic>>>uplink"); c != nil {
+		t.Error("unexpected counter not matching patterns: ", c.Value())
+	}
+
+	// Counters keep counting from the restored values.
+	c, err := m.GetOrRegisterCounter("user>>>a>>>traffic>>>uplink")
+	common.Must(err)
+	c.Add(50)
+	common.Must(m.Start())
+	common.Must(m.Close())
+
+	m, err = NewManager(context.Background(), config)
+	common.Must(err)
+	if c := m.GetCounter("user>>>a>>>traffic>>>uplink"); c == nil || c.Value() != 150 {
+		t.Error("unexpected restored counter: ", c)
+	}
+}
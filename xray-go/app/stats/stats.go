@@ -16,6 +16,8 @@ type Manager struct {
 	onlineMaps map[string]*OnlineMap
 	channels   map[string]*Channel
 	running    bool
+
+	persistence *persistence
 }
 
 // NewManager creates an instance of Statistics Manager.
@@ -26,6 +28,13 @@ func NewManager(ctx context.Context, config *Config) (*Manager, error) {
 		channels:   make(map[string]*Channel),
 	}
 
+	if p := config.GetPersistence(); p != nil && p.File != "" {
+		m.persistence = newPersistence(m, p)
+		if err := m.persistence.restore(m); err != nil {
+			return nil, errors.New("failed to restore stats from ", p.File).Base(err)
+		}
+	}
+
 	return m, nil
 }
 
@@ -238,6 +247,12 @@ func (m *Manager) GetAllOnlineUsers() []string {
 
 // Start implements common.Runnable.
 func (m *Manager) Start() error {
+	if m.persistence != nil {
+		if err := m.persistence.saver.Start(); err != nil {
+			return err
+		}
+	}
+
 	m.access.Lock()
 	defer m.access.Unlock()
 	m.running = true
@@ -255,6 +270,14 @@ func (m *Manager) Start() error {
 
 // Close implement common.Closable.
 func (m *Manager) Close() error {
+	errs := []error{}
+	if m.persistence != nil {
+		m.persistence.saver.Close()
+		if err := m.persistence.save(m); err != nil {
+			errs = append(errs, errors.New("failed to save stats to ", m.persistence.file).Base(err))
+		}
+	}
+
 	m.access.Lock()
 	defer m.access.Unlock()
 	m.running = false
@@ -262,7 +285,6 @@ func (m *Manager) Close() error {
 		errors.LogDebug(context.Background(), "remove OnlineMap ", name)
 		delete(m.onlineMaps, name)
 	}
-	errs := []error{}
 	for name, channel := range m.channels {
 		errors.LogDebug(context.Background(), "remove channel ", name)
 		delete(m.channels, name)
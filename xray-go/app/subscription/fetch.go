@@ -0,0 +1,98 @@
+package subscription
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/common/utils"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet/tagged"
+)
+
+const (
+	fetchTimeout   = time.Minute
+	maxContentSize = 16 * 1024 * 1024
+)
+
+type fetcher struct {
+	transport *http.Transport
+	client    *http.Client
+}
+
+func newFetcher(ctx context.Context, dispatcher routing.Dispatcher, outbound string) *fetcher {
+	transport := &http.Transport{
+		Proxy: nil,
+		DialContext: func(dialCtx context.Context, network, address string) (net.Conn, error) {
+			var conn net.Conn
+			err := task.Run(dialCtx, func() error {
+				if tagged.Dialer == nil {
+					return errors.New("tagged dialer is not initialized")
+				}
+				dest, err := net.ParseDestination(network + ":" + address)
+				if err != nil {
+					return errors.New("cannot understand address").Base(err)
+				}
+				c, err := tagged.Dialer(ctx, dispatcher, dest, outbound)
+				if err != nil {
+					return errors.New("cannot dial remote address ", dest).Base(err)
+				}
+				conn = c
+				return nil
+			})
+			if err != nil {
+				return nil, errors.New("cannot finish connection").Base(err)
+			}
+			return conn, nil
+		},
+		ForceAttemptHTTP2:     true,
+		IdleConnTimeout:       30 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	}
+	return &fetcher{
+		transport: transport,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   fetchTimeout,
+		},
+	}
+}
+
+func (f *fetcher) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	utils.TryDefaultHeadersWith(req.Header, "nav")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		io.Copy(io.Discard, resp.Body)
+		return nil, errors.New("unexpected status code: ", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxContentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, errors.New("empty response body")
+	}
+	if len(content) > maxContentSize {
+		return nil, errors.New("response body exceeds ", maxContentSize, " bytes")
+	}
+	return content, nil
+}
+
+func (f *fetcher) close() {
+	f.transport.CloseIdleConnections()
+}
@@ -0,0 +1,181 @@
+package subscription
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/robfig/cron/v3"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/features/routing"
+)
+
+// DecodeFunc decodes the content of a subscription, which is a list of share
+// links, possibly encoded in base64, or outbounds in JSON.
+type DecodeFunc func(content []byte) ([]*core.OutboundHandlerConfig, error)
+
+// Decoder decodes subscriptions. It is set by subscriptionimpl, as the config
+// parsers it uses depend on this package.
+var Decoder DecodeFunc
+
+type Instance struct {
+	ctx     context.Context
+	server  *core.Instance
+	ohm     outbound.Manager
+	sources []*Source
+	fetcher *fetcher
+	tasker  *cron.Cron
+
+	mu      sync.Mutex
+	running bool
+	initial sync.WaitGroup
+	// updateCtx is canceled on close, to abort updates in progress.
+	updateCtx context.Context
+	cancel    context.CancelFunc
+}
+
+func New(ctx context.Context, config *Config) (*Instance, error) {
+	s := &Instance{
+		ctx:     ctx,
+		server:  core.MustFromContext(ctx),
+		sources: config.Sources,
+	}
+	if len(s.sources) == 0 {
+		return s, nil
+	}
+
+	if err := core.RequireFeatures(ctx, func(d routing.Dispatcher, ohm outbound.Manager) {
+		s.fetcher = newFetcher(ctx, d, config.Outbound)
+		s.ohm = ohm
+	}); err != nil {
+		return nil, errors.New("failed to get dispatcher for subscription fetcher").Base(err)
+	}
+
+	if config.Cron != "" {
+		s.tasker = cron.New(
+			cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)),
+			cron.WithLogger(cron.DiscardLogger),
+		)
+		if _, err := s.tasker.AddFunc(config.Cron, func() { s.execute(s.updateCtx) }); err != nil {
+			return nil, errors.New("invalid subscription cron").Base(err)
+		}
+		errors.LogInfo(ctx, "scheduled subscription update with cron: ", config.Cron)
+	}
+
+	return s, nil
+}
+
+func (s *Instance) execute(ctx context.Context) {
+	for _, source := range s.sources {
+		if err := s.update(ctx, source); err != nil {
+			errors.LogErrorInner(s.ctx, err, "failed to update subscription ", source.TagPrefix)
+		}
+	}
+}
+
+// Update fetches source and replaces the outbounds with its tag prefix with
+// those in it. The outbounds are kept as they are if it fails.
+func (s *Instance) Update(source *Source) error {
+	return s.update(s.ctx, source)
+}
+
+func (s *Instance) update(ctx context.Context, source *Source) error {
+	if Decoder == nil {
+		return errors.New("subscription decoder is not initialized")
+	}
+	content, err := s.fetcher.fetch(ctx, source.Url)
+	if err != nil {
+		return errors.New("failed to fetch subscription from ", source.Url).Base(err)
+	}
+	configs, err := Decoder(content)
+	if err != nil {
+		return errors.New("failed to decode subscription from ", source.Url).Base(err)
+	}
+	replacer, ok := s.ohm.(outbound.HandlerGroupReplacer)
+	if !ok {
+		return errors.New("outbound manager cannot replace outbounds")
+	}
+
+	handlers := make([]outbound.Handler, 0, len(configs))
+	tags := make(map[string]bool, len(configs))
+	for i, config := range configs {
+		config.Tag = source.TagPrefix + config.Tag
+		if config.Tag == source.TagPrefix || tags[config.Tag] {
+			config.Tag = source.TagPrefix + strconv.Itoa(i)
+		}
+		tags[config.Tag] = true
+
+		handler, err := core.CreateObject(s.server, config)
+		if err != nil {
+			for _, h := range handlers {
+				common.Close(h)
+			}
+			return errors.New("failed to create outbound ", config.Tag).Base(err)
+		}
+		handlers = append(handlers, handler.(outbound.Handler))
+	}
+
+	if err := replacer.ReplaceHandlers(s.ctx, source.TagPrefix, handlers); err != nil {
+		return err
+	}
+	errors.LogInfo(s.ctx, "updated ", len(handlers), " outbounds of subscription ", source.TagPrefix)
+	return nil
+}
+
+func (s *Instance) Type() interface{} {
+	return (*Instance)(nil)
+}
+
+func (s *Instance) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running || len(s.sources) == 0 {
+		return nil
+	}
+
+	// Subscriptions are fetched once on start without blocking it, as they
+	// may go through outbounds which are not ready yet.
+	s.updateCtx, s.cancel = context.WithCancel(s.ctx)
+	s.initial.Add(1)
+	go func() {
+		defer s.initial.Done()
+		s.execute(s.updateCtx)
+	}()
+	if s.tasker != nil {
+		s.tasker.Start()
+	}
+
+	s.running = true
+
+	return nil
+}
+
+func (s *Instance) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.running {
+		return nil
+	}
+
+	s.cancel()
+	if s.tasker != nil {
+		<-s.tasker.Stop().Done()
+	}
+	s.initial.Wait()
+	s.fetcher.close()
+
+	s.running = false
+
+	return nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		return New(ctx, cfg.(*Config))
+	}))
+}
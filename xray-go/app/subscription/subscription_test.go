@@ -0,0 +1,127 @@
+package subscription_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/proxyman"
+	_ "github.com/xtls/xray-core/app/proxyman/inbound"
+	_ "github.com/xtls/xray-core/app/proxyman/outbound"
+	. "github.com/xtls/xray-core/app/subscription"
+	_ "github.com/xtls/xray-core/app/subscription/subscriptionimpl"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/proxy/freedom"
+	_ "github.com/xtls/xray-core/proxy/trojan"
+	_ "github.com/xtls/xray-core/proxy/vless/outbound"
+	_ "github.com/xtls/xray-core/transport/internet/tagged/taggedimpl"
+)
+
+func TestUpdate(t *testing.T) {
+	content := "vless://27848739-7e62-4138-9fd3-098a63964b6b@example.com:443?encryption=none&security=tls&sni=example.com&type=tcp#a\n" +
+		"unknown://example.com:443#skipped\n" +
+		"trojan://password@example.com:443?security=tls&sni=example.com#a\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(base64.StdEncoding.EncodeToString([]byte(content))))
+	}))
+	defer server.Close()
+
+	source := &Source{Url: server.URL, TagPrefix: "sub-"}
+	instance, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&Config{Sources: []*Source{source}}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				Tag:           "direct",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+			{
+				Tag:           "sub-old",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	})
+	common.Must(err)
+	defer common.Close(instance)
+
+	s := instance.GetFeature((*Instance)(nil)).(*Instance)
+	common.Must(s.Update(source))
+
+	ohm := instance.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	tags := ohm.(outbound.HandlerSelector).Select([]string{"sub-"})
+	sort.Strings(tags)
+	if strings.Join(tags, ",") != "sub-1,sub-a" {
+		t.Error("expected outbounds sub-1 and sub-a, but actually ", tags)
+	}
+	if ohm.GetHandler("direct") == nil {
+		t.Error("expected outbound direct to be kept")
+	}
+
+	source.Url = server.URL + "/invalid\x00"
+	if err := s.Update(source); err == nil {
+		t.Error("expected error for invalid url")
+	}
+	if tags := ohm.(outbound.HandlerSelector).Select([]string{"sub-"}); len(tags) != 2 {
+		t.Error("expected outbounds to be kept on failure, but actually ", tags)
+	}
+}
+
+func TestCloseAbortsInitialUpdate(t *testing.T) {
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-release
+		w.Write([]byte("trojan://password@example.com:443?security=tls&sni=example.com#a\n"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	instance, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&Config{Sources: []*Source{{Url: server.URL, TagPrefix: "sub-"}}}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				Tag:           "direct",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	})
+	common.Must(err)
+	defer common.Close(instance)
+	s := instance.GetFeature((*Instance)(nil)).(*Instance)
+	common.Must(s.Start())
+	<-requested
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("not closed while the initial update is in progress")
+	}
+
+	ohm := instance.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	if tags := ohm.(outbound.HandlerSelector).Select([]string{"sub-"}); len(tags) != 0 {
+		t.Error("expected no outbounds replaced after close, but actually ", tags)
+	}
+}
@@ -0,0 +1,77 @@
+package subscriptionimpl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/xtls/xray-core/app/subscription"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"github.com/xtls/xray-core/infra/conf/sharelink"
+)
+
+// Decode decodes outbounds in JSON, or share links one per line, which may be
+// encoded in base64 as a whole. Links which cannot be parsed are skipped.
+func Decode(content []byte) ([]*core.OutboundHandlerConfig, error) {
+	content = bytes.TrimSpace(content)
+	if len(content) > 0 && (content[0] == '{' || content[0] == '[') {
+		return serial.BuildOutboundsFromJSONString(string(content))
+	}
+
+	if decoded, ok := decodeBase64(content); ok {
+		content = decoded
+	}
+
+	var configs []*core.OutboundHandlerConfig
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, len(content)+1)
+	for scanner.Scan() {
+		link := strings.TrimSpace(scanner.Text())
+		if link == "" {
+			continue
+		}
+		config, err := build(link)
+		if err != nil {
+			errors.LogWarningInner(context.Background(), err, "skipped subscription link")
+			continue
+		}
+		configs = append(configs, config)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return nil, errors.New("no valid share link in subscription")
+	}
+	return configs, nil
+}
+
+func build(link string) (*core.OutboundHandlerConfig, error) {
+	outbound, err := sharelink.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	config, err := outbound.Build()
+	if err != nil {
+		return nil, errors.New("failed to build outbound of share link").Base(err)
+	}
+	return config, nil
+}
+
+func decodeBase64(content []byte) ([]byte, bool) {
+	s := strings.Join(strings.Fields(string(content)), "")
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(s); err == nil {
+			return decoded, true
+		}
+	}
+	return nil, false
+}
+
+func init() {
+	subscription.Decoder = Decode
+}
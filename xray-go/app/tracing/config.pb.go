@@ -0,0 +1,156 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.5
+// source: app/tracing/config.proto
+
+package tracing
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Config is the settings for tracing of sessions.
+type Config struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// File to append spans to, one OTLP/JSON export request per line.
+	File string `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	// URL of the OTLP/HTTP collector, such as http://127.0.0.1:4318/v1/traces.
+	Collector string `protobuf:"bytes,2,opt,name=collector,proto3" json:"collector,omitempty"`
+	// Service name of the spans, which is "xray" if empty.
+	ServiceName string `protobuf:"bytes,3,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	// Seconds between exports, which is 5 if zero. Spans are also exported on
+	// close.
+	Interval      uint32 `protobuf:"varint,4,opt,name=interval,proto3" json:"interval,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_app_tracing_config_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_app_tracing_config_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_app_tracing_config_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Config) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Config) GetCollector() string {
+	if x != nil {
+		return x.Collector
+	}
+	return ""
+}
+
+func (x *Config) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *Config) GetInterval() uint32 {
+	if x != nil {
+		return x.Interval
+	}
+	return 0
+}
+
+var File_app_tracing_config_proto protoreflect.FileDescriptor
+
+const file_app_tracing_config_proto_rawDesc = "" +
+	"\n" +
+	"\x18app/tracing/config.proto\x12\x10xray.app.tracing\"y\n" +
+	"\x06Config\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\tR\x04file\x12\x1c\n" +
+	"\tcollector\x18\x02 \x01(\tR\tcollector\x12!\n" +
+	"\fservice_name\x18\x03 \x01(\tR\vserviceName\x12\x1a\n" +
+	"\binterval\x18\x04 \x01(\rR\bintervalBR\n" +
+	"\x14com.xray.app.tracingP\x01Z%github.com/xtls/xray-core/app/tracing\xaa\x02\x10Xray.App.Tracingb\x06proto3"
+
+var (
+	file_app_tracing_config_proto_rawDescOnce sync.Once
+	file_app_tracing_config_proto_rawDescData []byte
+)
+
+func file_app_tracing_config_proto_rawDescGZIP() []byte {
+	file_app_tracing_config_proto_rawDescOnce.Do(func() {
+		file_app_tracing_config_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_app_tracing_config_proto_rawDesc), len(file_app_tracing_config_proto_rawDesc)))
+	})
+	return file_app_tracing_config_proto_rawDescData
+}
+
+var file_app_tracing_config_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_app_tracing_config_proto_goTypes = []any{
+	(*Config)(nil), // 0: xray.app.tracing.Config
+}
+var file_app_tracing_config_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_app_tracing_config_proto_init() }
+func file_app_tracing_config_proto_init() {
+	if File_app_tracing_config_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_app_tracing_config_proto_rawDesc), len(file_app_tracing_config_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_app_tracing_config_proto_goTypes,
+		DependencyIndexes: file_app_tracing_config_proto_depIdxs,
+		MessageInfos:      file_app_tracing_config_proto_msgTypes,
+	}.Build()
+	File_app_tracing_config_proto = out.File
+	file_app_tracing_config_proto_goTypes = nil
+	file_app_tracing_config_proto_depIdxs = nil
+}
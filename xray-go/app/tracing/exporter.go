@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+// exporter sends encoded spans to their destination.
+type exporter interface {
+	export(data []byte) error
+}
+
+// fileExporter appends spans to a file, one export request per line.
+type fileExporter struct {
+	file string
+}
+
+func (e *fileExporter) export(data []byte) error {
+	f, err := os.OpenFile(e.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// httpExporter posts spans to an OTLP/HTTP collector.
+type httpExporter struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPExporter(url string) *httpExporter {
+	return &httpExporter{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (e *httpExporter) export(data []byte) error {
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New("unexpected status of collector: ", resp.Status)
+	}
+	return nil
+}
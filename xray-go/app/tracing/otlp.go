@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/xtls/xray-core/core"
+)
+
+// Types below are the OTLP/JSON encoding of ExportTraceServiceRequest.
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Message string `json:"message,omitempty"`
+	Code    int    `json:"code,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+
+	statusCodeOk    = 1
+	statusCodeError = 2
+)
+
+func encodeSpans(serviceName string, spans []*span) ([]byte, error) {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.access.Lock()
+		e := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Status:            otlpStatus{Code: statusCodeOk},
+		}
+		if s.parentID == [8]byte{} {
+			e.Kind = spanKindServer
+		} else {
+			e.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		for _, a := range s.attributes {
+			e.Attributes = append(e.Attributes, otlpKeyValue{Key: a.key, Value: otlpAnyValue{StringValue: a.value}})
+		}
+		if s.err != nil {
+			e.Status = otlpStatus{Code: statusCodeError, Message: s.err.Error()}
+		}
+		s.access.Unlock()
+		encoded = append(encoded, e)
+	}
+
+	return json.Marshal(&otlpTraces{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue{StringValue: serviceName}}},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "xray", Version: core.Version()},
+				Spans: encoded,
+			}},
+		}},
+	})
+}
@@ -0,0 +1,198 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/features/extension"
+)
+
+const (
+	defaultInterval = 5 * time.Second
+	// maxPendingSpans is the number of ended spans kept until the next export,
+	// beyond which new spans are dropped.
+	maxPendingSpans = 16384
+)
+
+// Tracer is an implementation of extension.Tracer, which exports ended spans
+// periodically.
+type Tracer struct {
+	ctx         context.Context
+	serviceName string
+	exporters   []exporter
+	exportTask  *task.Periodic
+
+	access  sync.Mutex
+	pending []*span
+	dropped int
+}
+
+func New(ctx context.Context, config *Config) (*Tracer, error) {
+	t := &Tracer{
+		ctx:         ctx,
+		serviceName: config.ServiceName,
+	}
+	if t.serviceName == "" {
+		t.serviceName = "xray"
+	}
+	if config.File != "" {
+		t.exporters = append(t.exporters, &fileExporter{file: config.File})
+	}
+	if config.Collector != "" {
+		t.exporters = append(t.exporters, newHTTPExporter(config.Collector))
+	}
+	if len(t.exporters) == 0 {
+		return nil, errors.New("tracing must have a file or a collector")
+	}
+
+	interval := time.Duration(config.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	t.exportTask = &task.Periodic{
+		Interval: interval,
+		Execute: func() error {
+			t.export()
+			return nil
+		},
+	}
+	return t, nil
+}
+
+// StartTrace implements extension.Tracer.
+func (t *Tracer) StartTrace(name string) session.Span {
+	s := &span{
+		tracer: t,
+		name:   name,
+		start:  time.Now(),
+	}
+	rand.Read(s.traceID[:])
+	rand.Read(s.spanID[:])
+	return s
+}
+
+func (t *Tracer) add(s *span) {
+	t.access.Lock()
+	defer t.access.Unlock()
+
+	if len(t.pending) >= maxPendingSpans {
+		t.dropped++
+		return
+	}
+	t.pending = append(t.pending, s)
+}
+
+// export sends the spans ended since the last export to all exporters.
+func (t *Tracer) export() {
+	t.access.Lock()
+	spans, dropped := t.pending, t.dropped
+	t.pending, t.dropped = nil, 0
+	t.access.Unlock()
+
+	if dropped > 0 {
+		errors.LogWarning(t.ctx, "dropped ", dropped, " spans over the limit of pending spans")
+	}
+	if len(spans) == 0 {
+		return
+	}
+	data, err := encodeSpans(t.serviceName, spans)
+	if err != nil {
+		errors.LogWarningInner(t.ctx, err, "failed to encode spans")
+		return
+	}
+	for _, e := range t.exporters {
+		if err := e.export(data); err != nil {
+			errors.LogWarningInner(t.ctx, err, "failed to export ", len(spans), " spans")
+		}
+	}
+}
+
+// Type implements common.HasType.
+func (*Tracer) Type() interface{} {
+	return extension.TracerType()
+}
+
+// Start implements common.Runnable.
+func (t *Tracer) Start() error {
+	return t.exportTask.Start()
+}
+
+// Close implements common.Closable.
+func (t *Tracer) Close() error {
+	t.exportTask.Close()
+	t.export()
+	return nil
+}
+
+type attribute struct {
+	key   string
+	value string
+}
+
+// span is an implementation of session.Span.
+type span struct {
+	tracer   *Tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	start    time.Time
+
+	access     sync.Mutex
+	attributes []attribute
+	end        time.Time
+	err        error
+}
+
+// StartChild implements session.Span.
+func (s *span) StartChild(name string) session.Span {
+	child := &span{
+		tracer:   s.tracer,
+		traceID:  s.traceID,
+		parentID: s.spanID,
+		name:     name,
+		start:    time.Now(),
+	}
+	rand.Read(child.spanID[:])
+	return child
+}
+
+// SetAttribute implements session.Span.
+func (s *span) SetAttribute(key string, value string) {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	for i := range s.attributes {
+		if s.attributes[i].key == key {
+			s.attributes[i].value = value
+			return
+		}
+	}
+	s.attributes = append(s.attributes, attribute{key: key, value: value})
+}
+
+// End implements session.Span. Calls after the first are ignored.
+func (s *span) End(err error) {
+	s.access.Lock()
+	if !s.end.IsZero() {
+		s.access.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.err = err
+	s.access.Unlock()
+
+	s.tracer.add(s)
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return New(ctx, config.(*Config))
+	}))
+}
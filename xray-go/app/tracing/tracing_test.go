@@ -0,0 +1,149 @@
+package tracing_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	. "github.com/xtls/xray-core/app/tracing"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/session"
+)
+
+type exportedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Attributes   []struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	} `json:"attributes"`
+	Status struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+	} `json:"status"`
+}
+
+type exportRequest struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []exportedSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+func (r *exportRequest) spans() map[string]exportedSpan {
+	spans := make(map[string]exportedSpan)
+	for _, rs := range r.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, s := range ss.Spans {
+				spans[s.Name] = s
+			}
+		}
+	}
+	return spans
+}
+
+func traceSession(tracer *Tracer) {
+	root := tracer.StartTrace("session")
+	root.SetAttribute("inbound", "in")
+	ctx := session.ContextWithSpan(context.Background(), root)
+
+	dialCtx, dial := session.StartSpan(ctx, "dial")
+	_, dns := session.StartSpan(dialCtx, "dns")
+	dns.End(errors.New("no record"))
+	dial.End(nil)
+	root.End(nil)
+}
+
+func checkSpans(t *testing.T, spans map[string]exportedSpan) {
+	t.Helper()
+
+	root, dial, dns := spans["session"], spans["dial"], spans["dns"]
+	if len(spans) != 3 || root.TraceID == "" || dial.TraceID != root.TraceID || dns.TraceID != root.TraceID {
+		t.Fatal("unexpected spans: ", spans)
+	}
+	if root.ParentSpanID != "" || dial.ParentSpanID != root.SpanID || dns.ParentSpanID != dial.SpanID {
+		t.Error("unexpected parents of spans: ", spans)
+	}
+	if len(root.Attributes) != 1 || root.Attributes[0].Key != "inbound" || root.Attributes[0].Value.StringValue != "in" {
+		t.Error("unexpected attributes: ", root.Attributes)
+	}
+	if dns.Status.Code != 2 || dns.Status.Message != "no record" || dial.Status.Code != 1 {
+		t.Error("unexpected status: ", dns.Status, dial.Status)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	var access sync.Mutex
+	var requests []exportRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Error("unexpected content type: ", r.Header.Get("Content-Type"))
+		}
+		b, err := io.ReadAll(r.Body)
+		common.Must(err)
+		var request exportRequest
+		common.Must(json.Unmarshal(b, &request))
+		access.Lock()
+		requests = append(requests, request)
+		access.Unlock()
+	}))
+	defer collector.Close()
+
+	tracer, err := New(context.Background(), &Config{Collector: collector.URL})
+	common.Must(err)
+	common.Must(tracer.Start())
+	traceSession(tracer)
+	common.Must(tracer.Close())
+
+	access.Lock()
+	defer access.Unlock()
+	if len(requests) != 1 {
+		t.Fatal("unexpected number of exports: ", len(requests))
+	}
+	checkSpans(t, requests[0].spans())
+}
+
+func TestFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "spans.json")
+	tracer, err := New(context.Background(), &Config{File: file})
+	common.Must(err)
+	common.Must(tracer.Start())
+	traceSession(tracer)
+	common.Must(tracer.Close())
+
+	f, err := os.Open(file)
+	common.Must(err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("no spans exported to file")
+	}
+	var request exportRequest
+	common.Must(json.Unmarshal(scanner.Bytes(), &request))
+	checkSpans(t, request.spans())
+	if scanner.Scan() {
+		t.Error("unexpected line in file: ", scanner.Text())
+	}
+}
+
+func TestUntraced(t *testing.T) {
+	ctx, span := session.StartSpan(context.Background(), "dial")
+	if session.SpanFromContext(ctx) != nil {
+		t.Error("unexpected span in untraced context")
+	}
+	span.SetAttribute("key", "value")
+	span.End(nil)
+}
@@ -109,6 +109,11 @@ func (err *Error) AtError() *Error {
 	return err.atSeverity(log.Severity_Error)
 }
 
+// Caller returns the package path that created this error, relative to the module root.
+func (err *Error) Caller() string {
+	return err.caller
+}
+
 // String returns the string representation of this error.
 func (err *Error) String() string {
 	return err.Error()
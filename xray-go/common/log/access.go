@@ -3,6 +3,7 @@ package log
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/xtls/xray-core/common/serial"
 )
@@ -20,6 +21,19 @@ const (
 	AccessRejected = AccessStatus("rejected")
 )
 
+// AccessPhase is when an access message is recorded in a session.
+type AccessPhase int
+
+const (
+	// AccessOnce is a message recorded only once, such as a rejection.
+	AccessOnce AccessPhase = iota
+	// AccessOpen is a message recorded when a session opens, which is
+	// recorded again as AccessClose when the session closes.
+	AccessOpen
+	// AccessClose is a message recorded when a session closes.
+	AccessClose
+)
+
 type AccessMessage struct {
 	From   interface{}
 	To     interface{}
@@ -27,6 +41,16 @@ type AccessMessage struct {
 	Reason interface{}
 	Email  string
 	Detour string
+
+	InboundTag  string
+	OutboundTag string
+	// Domain is the sniffed domain of the session.
+	Domain string
+	Phase  AccessPhase
+	// Traffic and duration of the session, set when it closes.
+	Uplink   int64
+	Downlink int64
+	Duration time.Duration
 }
 
 func (m *AccessMessage) String() string {
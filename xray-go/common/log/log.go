@@ -34,6 +34,23 @@ func Record(msg Message) {
 
 var logHandler syncHandler
 
+// AccessCloseHandler is a Handler that may record access messages when
+// sessions close, with their traffic and duration.
+type AccessCloseHandler interface {
+	Handler
+	RecordsAccessClose() bool
+}
+
+// RecordsAccessClose returns whether the current log handler records access
+// messages when sessions close, so that their traffic needs to be counted.
+func RecordsAccessClose() bool {
+	logHandler.RLock()
+	defer logHandler.RUnlock()
+
+	h, ok := logHandler.Handler.(AccessCloseHandler)
+	return ok && h.RecordsAccessClose()
+}
+
 // RegisterHandler registers a new handler as current log handler. Previous registered handler will be discarded.
 func RegisterHandler(handler Handler) {
 	if handler == nil {
@@ -128,7 +128,7 @@ func (w *consoleLogWriter) Close() error {
 }
 
 type fileLogWriter struct {
-	file   *os.File
+	file   io.WriteCloser
 	logger *log.Logger
 }
 
@@ -143,9 +143,19 @@ func (w *fileLogWriter) Close() error {
 
 // CreateStdoutLogWriter returns a LogWriterCreator that creates LogWriter for stdout.
 func CreateStdoutLogWriter() WriterCreator {
+	return createStdoutLogWriter(log.Ldate | log.Ltime | log.Lmicroseconds)
+}
+
+// CreateRawStdoutLogWriter returns a LogWriterCreator that creates LogWriter
+// for stdout, which writes messages without timestamps.
+func CreateRawStdoutLogWriter() WriterCreator {
+	return createStdoutLogWriter(0)
+}
+
+func createStdoutLogWriter(flag int) WriterCreator {
 	return func() Writer {
 		return &consoleLogWriter{
-			logger: log.New(os.Stdout, "", log.Ldate|log.Ltime|log.Lmicroseconds),
+			logger: log.New(os.Stdout, "", flag),
 		}
 	}
 }
@@ -159,21 +169,44 @@ func CreateStderrLogWriter() WriterCreator {
 	}
 }
 
+// FileLogOptions are the options of a LogWriter for a file.
+type FileLogOptions struct {
+	// Raw is whether messages are written without timestamps.
+	Raw bool
+	// Rotation rotates the file if not nil.
+	Rotation *Rotation
+}
+
 // CreateFileLogWriter returns a LogWriterCreator that creates LogWriter for the given file.
 func CreateFileLogWriter(path string) (WriterCreator, error) {
+	return CreateFileLogWriterWithOptions(path, FileLogOptions{})
+}
+
+// CreateFileLogWriterWithOptions is CreateFileLogWriter with options.
+func CreateFileLogWriterWithOptions(path string, options FileLogOptions) (WriterCreator, error) {
+	flag := log.Ldate | log.Ltime | log.Lmicroseconds
+	if options.Raw {
+		flag = 0
+	}
 	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
 	if err != nil {
 		return nil, err
 	}
 	file.Close()
 	return func() Writer {
-		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
+		var file io.WriteCloser
+		var err error
+		if options.Rotation != nil {
+			file, err = openRotatingFile(path, *options.Rotation)
+		} else {
+			file, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
+		}
 		if err != nil {
 			return nil
 		}
 		return &fileLogWriter{
 			file:   file,
-			logger: log.New(file, "", log.Ldate|log.Ltime|log.Lmicroseconds),
+			logger: log.New(file, "", flag),
 		}
 	}, nil
 }
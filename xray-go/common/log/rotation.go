@@ -0,0 +1,251 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the format of the time suffixed to the names of rotated
+// log files, which sorts them by time.
+const rotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+// Rotation is the policy to rotate a log file. Zero values disable rotation by
+// size, by time, and the limit of retained files.
+type Rotation struct {
+	// MaxSize is the size in bytes over which the log file is rotated.
+	MaxSize int64
+	// Interval is the period, aligned to UTC, at the end of which the log file
+	// is rotated.
+	Interval time.Duration
+	// MaxFiles is the maximum number of rotated files retained.
+	MaxFiles int
+	// Compress is whether rotated files are compressed with gzip.
+	Compress bool
+}
+
+// rotatingFile is a log file rotated by a Rotation. Rotated files are named
+// after the log file, suffixed with the time of rotation.
+type rotatingFile struct {
+	path     string
+	rotation Rotation
+	now      func() time.Time
+
+	file   *os.File
+	size   int64
+	period time.Time
+
+	// cleanup serializes the compression and removal of rotated files in the
+	// background, and cleanups waits for them on close.
+	cleanup  sync.Mutex
+	cleanups sync.WaitGroup
+}
+
+func openRotatingFile(path string, rotation Rotation) (*rotatingFile, error) {
+	f := &rotatingFile{
+		path:     path,
+		rotation: rotation,
+		now:      time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	// A file left from an earlier run belongs to the period it was last
+	// written in, so that it is rotated if that period is over.
+	if f.size > 0 {
+		f.period = f.periodOf(info.ModTime())
+	} else {
+		f.period = f.periodOf(f.now())
+	}
+	return nil
+}
+
+func (f *rotatingFile) periodOf(t time.Time) time.Time {
+	if f.rotation.Interval <= 0 {
+		return time.Time{}
+	}
+	return t.Truncate(f.rotation.Interval)
+}
+
+func (f *rotatingFile) shouldRotate(size int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.rotation.MaxSize > 0 && f.size+int64(size) > f.rotation.MaxSize {
+		return true
+	}
+	return f.rotation.Interval > 0 && !f.periodOf(f.now()).Equal(f.period)
+}
+
+// Write implements io.Writer.
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	if f.shouldRotate(len(p)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	rotated := f.rotatedPath()
+	if err := os.Rename(f.path, rotated); err != nil {
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	// Failing to compress or remove rotated files doesn't stop logging, and
+	// they are retried at the next rotation. Compression is done in the
+	// background, not to hold up the messages being logged.
+	if !f.rotation.Compress {
+		f.removeOld()
+		return nil
+	}
+	f.cleanups.Add(1)
+	go func() {
+		defer f.cleanups.Done()
+		f.cleanup.Lock()
+		defer f.cleanup.Unlock()
+		f.compressRotated()
+		f.removeOld()
+	}()
+	return nil
+}
+
+func (f *rotatingFile) rotatedPath() string {
+	t := f.now()
+	for {
+		rotated := f.path + "." + t.Format(rotatedTimeFormat)
+		_, err := os.Stat(rotated)
+		if os.IsNotExist(err) {
+			_, err = os.Stat(rotated + ".gz")
+		}
+		if os.IsNotExist(err) {
+			return rotated
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// rotatedFiles returns the rotated files of the log file, from the oldest.
+func (f *rotatingFile) rotatedFiles() ([]string, error) {
+	dir, base := filepath.Split(f.path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base+".") {
+			continue
+		}
+		suffix := strings.TrimSuffix(strings.TrimPrefix(name, base+"."), ".gz")
+		if _, err := time.Parse(rotatedTimeFormat, suffix); err != nil {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// compressRotated compresses the rotated files not compressed yet.
+func (f *rotatingFile) compressRotated() {
+	files, err := f.rotatedFiles()
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".gz") {
+			compressFile(file)
+		}
+	}
+}
+
+func (f *rotatingFile) removeOld() error {
+	if f.rotation.MaxFiles <= 0 {
+		return nil
+	}
+	files, err := f.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for len(files) > f.rotation.MaxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// Close implements io.Closer.
+func (f *rotatingFile) Close() error {
+	f.cleanups.Wait()
+	return f.file.Close()
+}
+
+// compressFile replaces the file at path with its gzip compression.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		zw.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
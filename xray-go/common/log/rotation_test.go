@@ -0,0 +1,117 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	must(t, err)
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		must(t, err)
+		r = zr
+	}
+	b, err := io.ReadAll(r)
+	must(t, err)
+	return string(b)
+}
+
+func TestRotateBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := openRotatingFile(path, Rotation{MaxSize: 10, MaxFiles: 2, Compress: true})
+	must(t, err)
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Rotated files are compressed in the background.
+	f.cleanups.Wait()
+
+	if s := readFile(t, path); s != "fourth\n" {
+		t.Error("unexpected content of log file: ", s)
+	}
+	rotated, err := f.rotatedFiles()
+	must(t, err)
+	if len(rotated) != 2 {
+		t.Fatal("expected 2 rotated files, but actually ", rotated)
+	}
+	for i, expected := range []string{"second\n", "third\n"} {
+		if !strings.HasSuffix(rotated[i], ".gz") {
+			t.Error("expected compressed file, but actually ", rotated[i])
+		}
+		if s := readFile(t, rotated[i]); s != expected {
+			t.Error("unexpected content of ", rotated[i], ": ", s)
+		}
+	}
+}
+
+func TestRotateByTime(t *testing.T) {
+	now := time.Date(2026, 10, 17, 23, 59, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "error.log")
+	f, err := openRotatingFile(path, Rotation{Interval: 24 * time.Hour})
+	must(t, err)
+	defer f.Close()
+	f.now = func() time.Time { return now }
+	f.period = f.periodOf(now)
+
+	_, err = f.Write([]byte("today\n"))
+	must(t, err)
+	now = now.Add(30 * time.Second)
+	_, err = f.Write([]byte("still today\n"))
+	must(t, err)
+	now = now.Add(time.Minute)
+	_, err = f.Write([]byte("tomorrow\n"))
+	must(t, err)
+
+	if s := readFile(t, path); s != "tomorrow\n" {
+		t.Error("unexpected content of log file: ", s)
+	}
+	rotated := path + "." + now.Format(rotatedTimeFormat)
+	if s := readFile(t, rotated); s != "today\nstill today\n" {
+		t.Error("unexpected content of rotated file: ", s)
+	}
+}
+
+func TestRotateLeftFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error.log")
+	must(t, os.WriteFile(path, []byte("yesterday\n"), 0o600))
+	yesterday := time.Now().Add(-24 * time.Hour)
+	must(t, os.Chtimes(path, yesterday, yesterday))
+
+	f, err := openRotatingFile(path, Rotation{Interval: 24 * time.Hour})
+	must(t, err)
+	defer f.Close()
+	_, err = f.Write([]byte("today\n"))
+	must(t, err)
+
+	if s := readFile(t, path); s != "today\n" {
+		t.Error("unexpected content of log file: ", s)
+	}
+	rotated, err := f.rotatedFiles()
+	must(t, err)
+	if len(rotated) != 1 || readFile(t, rotated[0]) != "yesterday\n" {
+		t.Error("unexpected rotated files: ", rotated)
+	}
+}
@@ -92,3 +92,25 @@ func CopyFile(dst string, src string) error {
 	_, err = f.Write(bytes)
 	return err
 }
+
+// WriteFileAtomic writes data to a temporary file in the directory of path,
+// and renames it to path, so that the file is never left half written.
+func WriteFileAtomic(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
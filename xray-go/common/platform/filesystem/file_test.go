@@ -1,6 +1,7 @@
 package filesystem_test
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -30,3 +31,34 @@ func TestStatAssetRejectsInvalidPath(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	for _, data := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != data {
+			t.Errorf("expected %q, but got %q", data, b)
+		}
+	}
+
+	// The temporary files are removed.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Error("expected only the written file, but got ", len(entries), " files")
+	}
+
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "state.json"), nil); err == nil {
+		t.Error("expected error of missing directory")
+	}
+}
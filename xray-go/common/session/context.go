@@ -28,6 +28,7 @@ const (
 	mitmServerNameKey         ctx.SessionKey = 12 // used by TLS dialer
 
 	streamSettingsKey ctx.SessionKey = 13
+	spanKey           ctx.SessionKey = 14 // tracing span of the current stage
 )
 
 func ContextWithInbound(ctx context.Context, inbound *Inbound) context.Context {
@@ -202,3 +203,26 @@ func ContextWithStreamSettings(ctx context.Context, streamSettings any) context.
 func StreamSettingsFromContext(ctx context.Context) any {
 	return ctx.Value(streamSettingsKey)
 }
+
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey, span)
+}
+
+func SpanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey).(Span); ok {
+		return span
+	}
+	return nil
+}
+
+// StartSpan starts a span as a child of the span in ctx, and returns the
+// context with the new span. If the session is not traced, it returns ctx and
+// a span doing nothing.
+func StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, noopSpan{}
+	}
+	span := parent.StartChild(name)
+	return ContextWithSpan(ctx, span), span
+}
@@ -122,3 +122,19 @@ func (c *Content) Attribute(name string) string {
 	}
 	return c.Attributes[name]
 }
+
+// Span is a timed stage of a traced session.
+type Span interface {
+	// StartChild starts a span of a stage within this span.
+	StartChild(name string) Span
+	// SetAttribute sets an attribute of the span.
+	SetAttribute(key string, value string)
+	// End ends the span, which has failed if err is not nil.
+	End(err error)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) StartChild(string) Span      { return noopSpan{} }
+func (noopSpan) SetAttribute(string, string) {}
+func (noopSpan) End(error)                   {}
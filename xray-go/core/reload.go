@@ -0,0 +1,228 @@
+package core
+
+import (
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/features"
+	"github.com/xtls/xray-core/features/inbound"
+	"github.com/xtls/xray-core/features/outbound"
+	"google.golang.org/protobuf/proto"
+)
+
+// typedMessageEqual compares two TypedMessages by their decoded content, so
+// the result does not depend on how the messages were serialized.
+func typedMessageEqual(a, b *serial.TypedMessage) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Type != b.Type {
+		return false
+	}
+	ai, err := a.GetInstance()
+	if err != nil {
+		return false
+	}
+	bi, err := b.GetInstance()
+	if err != nil {
+		return false
+	}
+	return proto.Equal(ai, bi)
+}
+
+func inboundConfigEqual(a, b *InboundHandlerConfig) bool {
+	return a.Tag == b.Tag &&
+		typedMessageEqual(a.ReceiverSettings, b.ReceiverSettings) &&
+		typedMessageEqual(a.ProxySettings, b.ProxySettings)
+}
+
+func outboundConfigEqual(a, b *OutboundHandlerConfig) bool {
+	return a.Tag == b.Tag &&
+		typedMessageEqual(a.SenderSettings, b.SenderSettings) &&
+		typedMessageEqual(a.ProxySettings, b.ProxySettings)
+}
+
+// handlerDiff lists the tags of handlers to remove and the configs of handlers
+// to add, so that handlers whose config is unchanged keep serving.
+type handlerDiff[T any] struct {
+	remove []string
+	add    []T
+}
+
+func diffHandlers[T any](old, new []T, tag func(T) string, equal func(a, b T) bool) (*handlerDiff[T], error) {
+	oldTagged := make(map[string]T)
+	var oldUntagged, newUntagged []T
+	for _, c := range old {
+		if t := tag(c); len(t) > 0 {
+			oldTagged[t] = c
+		} else {
+			oldUntagged = append(oldUntagged, c)
+		}
+	}
+
+	diff := &handlerDiff[T]{}
+	newTags := make(map[string]bool)
+	for _, c := range new {
+		t := tag(c)
+		if len(t) == 0 {
+			newUntagged = append(newUntagged, c)
+			continue
+		}
+		newTags[t] = true
+		if o, found := oldTagged[t]; found {
+			if equal(o, c) {
+				continue
+			}
+			diff.remove = append(diff.remove, t)
+		}
+		diff.add = append(diff.add, c)
+	}
+	for _, c := range old {
+		if t := tag(c); len(t) > 0 && !newTags[t] {
+			diff.remove = append(diff.remove, t)
+		}
+	}
+
+	// Handlers without a tag cannot be removed from the managers.
+	if len(oldUntagged) != len(newUntagged) {
+		return nil, errors.New("handlers without tag cannot be added or removed on reload")
+	}
+	for i := range oldUntagged {
+		if !equal(oldUntagged[i], newUntagged[i]) {
+			return nil, errors.New("handlers without tag cannot be changed on reload")
+		}
+	}
+	return diff, nil
+}
+
+// appReload is a pending change of an app config on a running instance.
+type appReload struct {
+	feature features.Reloadable
+	config  proto.Message
+}
+
+func (s *Instance) diffApps(config *Config) ([]appReload, error) {
+	oldApps := make(map[string]*serial.TypedMessage)
+	for _, app := range s.config.App {
+		oldApps[app.Type] = app
+	}
+
+	var reloads []appReload
+	for _, app := range config.App {
+		old, found := oldApps[app.Type]
+		if !found {
+			return nil, errors.New("adding ", app.Type, " requires a restart")
+		}
+		delete(oldApps, app.Type)
+		if typedMessageEqual(old, app) {
+			continue
+		}
+		feature, ok := s.appFeatures[app.Type].(features.Reloadable)
+		if !ok {
+			return nil, errors.New("changing ", app.Type, " requires a restart")
+		}
+		settings, err := app.GetInstance()
+		if err != nil {
+			return nil, err
+		}
+		reloads = append(reloads, appReload{
+			feature: feature,
+			config:  settings,
+		})
+	}
+	for t := range oldApps {
+		return nil, errors.New("removing ", t, " requires a restart")
+	}
+	return reloads, nil
+}
+
+// Reload applies config to the running instance without restarting it. It
+// adds, removes or replaces only the inbound and outbound handlers whose
+// config changed, and reloads app features such as routing and DNS in place.
+// Handlers and connections that are not affected keep serving.
+//
+// The whole config is checked before anything is applied, and Reload fails if
+// it contains changes that need a restart, such as adding or removing an app
+// or changing a handler without tag. If applying a change fails, the instance
+// may be left with part of the new config.
+func (s *Instance) Reload(config *Config) error {
+	s.statusLock.Lock()
+	defer s.statusLock.Unlock()
+
+	if !s.running {
+		return errors.New("instance is not running")
+	}
+
+	reloads, err := s.diffApps(config)
+	if err != nil {
+		return err
+	}
+	inbounds, err := diffHandlers(s.config.Inbound, config.Inbound,
+		func(c *InboundHandlerConfig) string { return c.Tag }, inboundConfigEqual)
+	if err != nil {
+		return errors.New("failed to reload inbounds").Base(err)
+	}
+	outbounds, err := diffHandlers(s.config.Outbound, config.Outbound,
+		func(c *OutboundHandlerConfig) string { return c.Tag }, outboundConfigEqual)
+	if err != nil {
+		return errors.New("failed to reload outbounds").Base(err)
+	}
+
+	// Outbounds go first, so that reloaded routing rules can refer to them.
+	ohm := s.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	for _, tag := range outbounds.remove {
+		handler := ohm.GetHandler(tag)
+		if err := ohm.RemoveHandler(s.ctx, tag); err != nil {
+			return errors.New("failed to remove outbound ", tag).Base(err)
+		}
+		common.Close(handler)
+	}
+	for _, c := range outbounds.add {
+		if err := AddOutboundHandler(s, c); err != nil {
+			return errors.New("failed to add outbound ", c.Tag).Base(err)
+		}
+	}
+	if len(config.Outbound) > 0 {
+		if err := setDefaultOutbound(ohm, config.Outbound[0].Tag); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range reloads {
+		if err := r.feature.Reload(r.config); err != nil {
+			return errors.New("failed to reload ", serial.GetMessageType(r.config)).Base(err)
+		}
+	}
+
+	ihm := s.GetFeature(inbound.ManagerType()).(inbound.Manager)
+	for _, tag := range inbounds.remove {
+		if err := ihm.RemoveHandler(s.ctx, tag); err != nil {
+			return errors.New("failed to remove inbound ", tag).Base(err)
+		}
+	}
+	for _, c := range inbounds.add {
+		if err := AddInboundHandler(s, c); err != nil {
+			return errors.New("failed to add inbound ", c.Tag).Base(err)
+		}
+	}
+
+	s.config = config
+	errors.LogWarning(s.ctx, "Xray ", Version(), " reloaded")
+	return nil
+}
+
+// setDefaultOutbound makes the handler with tag the default one. Handlers
+// without a tag are left as they are.
+func setDefaultOutbound(ohm outbound.Manager, tag string) error {
+	if len(tag) == 0 {
+		return nil
+	}
+	setter, ok := ohm.(outbound.DefaultHandlerSetter)
+	if !ok {
+		return errors.New("outbound manager cannot set the default outbound")
+	}
+	if err := setter.SetDefaultHandler(tag); err != nil {
+		return errors.New("failed to set default outbound ", tag).Base(err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/log"
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	. "github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/inbound"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/proxy/blackhole"
+	"github.com/xtls/xray-core/proxy/dokodemo"
+	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/testing/servers/tcp"
+)
+
+func reloadTestConfig(port net.Port, outboundTags []string, ruleTarget string) *Config {
+	config := &Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						RuleTag:    "rule",
+						TargetTag:  &router.RoutingRule_Tag{Tag: ruleTarget},
+						InboundTag: []string{"in"},
+					},
+				},
+			}),
+		},
+		Inbound: []*InboundHandlerConfig{
+			{
+				Tag: "in",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortList: &net.PortList{
+						Range: []*net.PortRange{net.SinglePortRange(port)},
+					},
+					Listen: net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					RewriteAddress:  net.NewIPOrDomain(net.LocalHostIP),
+					RewritePort:     uint32(port),
+					AllowedNetworks: []net.Network{net.Network_TCP},
+				}),
+			},
+		},
+	}
+	for _, tag := range outboundTags {
+		settings := serial.ToTypedMessage(&freedom.Config{})
+		if tag == "block" {
+			settings = serial.ToTypedMessage(&blackhole.Config{})
+		}
+		config.Outbound = append(config.Outbound, &OutboundHandlerConfig{
+			Tag:           tag,
+			ProxySettings: settings,
+		})
+	}
+	return config
+}
+
+func TestXrayReload(t *testing.T) {
+	port := tcp.PickPort()
+
+	server, err := New(reloadTestConfig(port, []string{"direct", "block"}, "direct"))
+	common.Must(err)
+	common.Must(server.Start())
+	defer server.Close()
+
+	ihm := server.GetFeature(inbound.ManagerType()).(inbound.Manager)
+	ohm := server.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	r := server.GetFeature(routing.RouterType()).(routing.Router)
+
+	in, err := ihm.GetHandler(context.Background(), "in")
+	common.Must(err)
+	block := ohm.GetHandler("block")
+
+	common.Must(server.Reload(reloadTestConfig(port, []string{"block", "proxy"}, "proxy")))
+
+	if h, err := ihm.GetHandler(context.Background(), "in"); err != nil || h != in {
+		t.Error("unchanged inbound was replaced")
+	}
+	if ohm.GetHandler("block") != block {
+		t.Error("unchanged outbound was replaced")
+	}
+	if ohm.GetHandler("direct") != nil {
+		t.Error("removed outbound still exists")
+	}
+	if ohm.GetHandler("proxy") == nil {
+		t.Error("added outbound does not exist")
+	}
+	if h := ohm.GetDefaultHandler(); h == nil || h.Tag() != "block" {
+		t.Error("default outbound is not the first one in new config")
+	}
+
+	if rules := r.ListRule(); len(rules) != 1 || rules[0].GetOutboundTag() != "proxy" {
+		t.Error("routing rules were not reloaded: ", rules)
+	}
+
+	config := reloadTestConfig(port, []string{"block", "proxy"}, "proxy")
+	config.App = append(config.App, serial.ToTypedMessage(&log.Config{}))
+	if err := server.Reload(config); err == nil {
+		t.Error("expected error when adding an app on reload")
+	}
+}
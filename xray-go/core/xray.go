@@ -87,6 +87,10 @@ type Instance struct {
 	running                    bool
 	resolveLock                sync.Mutex
 
+	// config and the features created from its apps, keyed by config type, are kept for Reload.
+	config      *Config
+	appFeatures map[string]features.Feature
+
 	ctx context.Context
 }
 
@@ -190,6 +194,8 @@ func initInstanceWithConfig(config *Config, server *Instance) (bool, error) {
 	if err := platform.ReloadEnvSettings(); err != nil {
 		return true, errors.New("failed to reload environment settings").Base(err)
 	}
+	server.config = config
+	server.appFeatures = make(map[string]features.Feature)
 	server.ctx = context.WithValue(server.ctx, "cone",
 		platform.NewEnvFlag(platform.UseCone).GetValue(func() string { return "" }) != "true")
 
@@ -206,6 +212,7 @@ func initInstanceWithConfig(config *Config, server *Instance) (bool, error) {
 			if err := server.AddFeature(feature); err != nil {
 				return true, err
 			}
+			server.appFeatures[appSettings.Type] = feature
 		}
 	}
 
@@ -5,6 +5,7 @@ import (
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/features"
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 // IPOption is an object for IP query options.
@@ -24,6 +25,18 @@ type Client interface {
 	LookupIP(domain string, option IPOption) ([]net.IP, uint32, error)
 }
 
+// RecordClient is a Client that also looks up records of any type.
+//
+// xray:api:beta
+type RecordClient interface {
+	Client
+
+	// LookupRecords returns the answer records of the given type for the given domain, with the CNAME
+	// records leading to them. It returns RCodeError if the query fails, and ErrEmptyResponse if no
+	// record is found.
+	LookupRecords(domain string, qType dnsmessage.Type) ([]dnsmessage.Resource, uint32, error)
+}
+
 // ClientType returns the type of Client interface. Can be used for implementing common.HasType.
 //
 // xray:api:beta
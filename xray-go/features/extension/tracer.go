@@ -0,0 +1,18 @@
+package extension
+
+import (
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features"
+)
+
+// Tracer is a feature that records timed spans of sessions.
+type Tracer interface {
+	features.Feature
+
+	// StartTrace starts the root span of the trace of a new session.
+	StartTrace(name string) session.Span
+}
+
+func TracerType() interface{} {
+	return (*Tracer)(nil)
+}
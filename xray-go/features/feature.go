@@ -2,6 +2,7 @@ package features
 
 import (
 	"github.com/xtls/xray-core/common"
+	"google.golang.org/protobuf/proto"
 )
 
 // Feature is the interface for Xray features. All features must implement this interface.
@@ -10,3 +11,10 @@ type Feature interface {
 	common.HasType
 	common.Runnable
 }
+
+// Reloadable is a Feature that can apply a changed config of its own type in place, so that a running instance
+// does not need to be restarted.
+type Reloadable interface {
+	Feature
+	Reload(config proto.Message) error
+}
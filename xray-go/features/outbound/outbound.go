@@ -43,6 +43,24 @@ type Manager interface {
 	ListHandlers(ctx context.Context) []Handler
 }
 
+// HandlerGroupReplacer is implemented by Managers that can replace a group of
+// handlers at once.
+type HandlerGroupReplacer interface {
+	// ReplaceHandlers removes all handlers whose tag starts with prefix and adds
+	// handlers in their place, so that no selector sees only part of either
+	// group. The removed handlers are closed, and so are the given handlers
+	// which cannot be added.
+	ReplaceHandlers(ctx context.Context, prefix string, handlers []Handler) error
+}
+
+// DefaultHandlerSetter is implemented by Managers that can change the default
+// handler in place.
+type DefaultHandlerSetter interface {
+	// SetDefaultHandler makes the handler with the given tag the default one,
+	// without removing any handler.
+	SetDefaultHandler(tag string) error
+}
+
 // ManagerType returns the type of Manager interface. Can be used to implement common.HasType.
 //
 // xray:api:stable
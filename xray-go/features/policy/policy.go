@@ -38,6 +38,16 @@ type Buffer struct {
 	PerConnection int32
 }
 
+// Limit contains settings for traffic limits of a user or an inbound.
+type Limit struct {
+	// Rate of uplink traffic, in bytes per second. 0 for unlimited.
+	UplinkRate uint64
+	// Rate of downlink traffic, in bytes per second. 0 for unlimited.
+	DownlinkRate uint64
+	// Total traffic in bytes, after which connections are closed. 0 for unlimited.
+	Quota uint64
+}
+
 // SystemStats contains stat policy settings on system level.
 type SystemStats struct {
 	// Whether or not to enable stat counter for uplink traffic in inbound handlers.
@@ -54,6 +64,8 @@ type SystemStats struct {
 type System struct {
 	Stats  SystemStats
 	Buffer Buffer
+	// Traffic limits of inbounds, by tag.
+	InboundLimits map[string]Limit
 }
 
 // Session is session based settings for controlling Xray requests. It contains various settings (or limits) that may differ for different users in the context.
@@ -61,6 +73,7 @@ type Session struct {
 	Timeouts Timeout // Timeout settings
 	Stats    Stats
 	Buffer   Buffer
+	Limit    Limit
 }
 
 // Manager is a feature that provides Policy for the given user by its id or level.
@@ -76,6 +89,21 @@ type Manager interface {
 	ForSystem() System
 }
 
+// RateLimiter limits the rate of traffic of all connections it is used by.
+type RateLimiter interface {
+	// WaitN blocks until n bytes are allowed to pass, or ctx is done.
+	WaitN(ctx context.Context, n int) error
+}
+
+// RateLimiterManager is implemented by Managers that keep RateLimiters for
+// users and inbounds.
+type RateLimiterManager interface {
+	// GetOrRegisterRateLimiter returns the RateLimiter with the given name, or
+	// registers one if there is none. The RateLimiter allows rate bytes per
+	// second, also for the connections already using it.
+	GetOrRegisterRateLimiter(name string, rate uint64) RateLimiter
+}
+
 // ManagerType returns the type of Manager interface. Can be used to implement common.HasType.
 //
 // xray:api:stable
@@ -20,6 +20,15 @@ type Dispatcher interface {
 	DispatchLink(ctx context.Context, dest net.Destination, link *transport.Link) error
 }
 
+// UserSessionCloser is a Dispatcher that can close the sessions of a user.
+//
+// xray:api:beta
+type UserSessionCloser interface {
+	// CloseUserSessions interrupts the sessions dispatched for the user with the given email, and
+	// returns their number.
+	CloseUserSessions(email string) int
+}
+
 // DispatcherType returns the type of Dispatcher interface. Can be used to implement common.HasType.
 //
 // xray:api:stable
@@ -36,6 +36,10 @@ type Route interface {
 	GetRuleTag() string
 }
 
+// StatsChannel is the name of the stats channel that the routing decision of
+// every dispatched connection is published to, as a Route.
+const StatsChannel = "routing"
+
 // RouterType return the type of Router interface. Can be used to implement common.HasType.
 //
 // xray:api:stable
@@ -32,6 +32,8 @@ type NameServerConfig struct {
 	ServeExpiredTTL *uint32    `json:"serveExpiredTTL"`
 	FinalQuery      bool       `json:"finalQuery"`
 	UnexpectedIPs   StringList `json:"unexpectedIPs"`
+	DNSSEC          bool       `json:"dnssec"`
+	TrustAnchors    StringList `json:"trustAnchors"`
 }
 
 // UnmarshalJSON implements encoding/json.Unmarshaler.UnmarshalJSON
@@ -58,6 +60,8 @@ func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
 		ServeExpiredTTL *uint32    `json:"serveExpiredTTL"`
 		FinalQuery      bool       `json:"finalQuery"`
 		UnexpectedIPs   StringList `json:"unexpectedIPs"`
+		DNSSEC          bool       `json:"dnssec"`
+		TrustAnchors    StringList `json:"trustAnchors"`
 	}
 	if err := json.Unmarshal(data, &advanced); err == nil {
 		c.Address = advanced.Address
@@ -75,6 +79,8 @@ func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
 		c.ServeExpiredTTL = advanced.ServeExpiredTTL
 		c.FinalQuery = advanced.FinalQuery
 		c.UnexpectedIPs = advanced.UnexpectedIPs
+		c.DNSSEC = advanced.DNSSEC
+		c.TrustAnchors = advanced.TrustAnchors
 		return nil
 	}
 
@@ -83,16 +89,24 @@ func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
 
 func (c *NameServerConfig) Build() (*dns.NameServer, error) {
 	if c.Address == nil {
-		return nil, errors.New("nameserver address is not specified")
+		return nil, withField("address", errors.New("nameserver address is not specified"))
 	}
 
 	domainRules, err := geodata.ParseDomainRules(c.Domains, geodata.Domain_Substr)
 	if err != nil {
-		return nil, err
+		return nil, withField("domains", err)
+	}
+
+	for _, anchor := range c.TrustAnchors {
+		if _, err := dns.ParseTrustAnchor(anchor); err != nil {
+			return nil, withField("trustAnchors", err)
+		}
 	}
 
+	expectedIPsField := "expectedIPs"
 	if len(c.ExpectedIPs) == 0 {
 		c.ExpectedIPs = c.ExpectIPs
+		expectedIPsField = "expectIPs"
 	}
 
 	actPrior := false
@@ -117,18 +131,18 @@ func (c *NameServerConfig) Build() (*dns.NameServer, error) {
 
 	expectedIPRules, err := geodata.ParseIPRules(newExpectedIPs)
 	if err != nil {
-		return nil, err
+		return nil, withField(expectedIPsField, err)
 	}
 
 	unexpectedIPRules, err := geodata.ParseIPRules(newUnexpectedIPs)
 	if err != nil {
-		return nil, err
+		return nil, withField("unexpectedIPs", err)
 	}
 
 	var myClientIP []byte
 	if c.ClientIP != nil {
 		if !c.ClientIP.Family().IsIP() {
-			return nil, errors.New("not an IP address:", c.ClientIP.String())
+			return nil, withField("clientIp", errors.New("not an IP address:", c.ClientIP.String()))
 		}
 		myClientIP = []byte(c.ClientIP.IP())
 	}
@@ -153,6 +167,8 @@ func (c *NameServerConfig) Build() (*dns.NameServer, error) {
 		FinalQuery:      c.FinalQuery,
 		UnexpectedIp:    unexpectedIPRules,
 		ActUnprior:      actUnprior,
+		Dnssec:          c.DNSSEC,
+		TrustAnchor:     c.TrustAnchors,
 	}, nil
 }
 
@@ -170,6 +186,8 @@ type DNSConfig struct {
 	DisableFallbackIfMatch bool                `json:"disableFallbackIfMatch"`
 	EnableParallelQuery    bool                `json:"enableParallelQuery"`
 	UseSystemHosts         bool                `json:"useSystemHosts"`
+	CacheFile              string              `json:"cacheFile"`
+	CacheSaveInterval      uint32              `json:"cacheSaveInterval"`
 }
 
 type HostAddress struct {
@@ -276,15 +294,21 @@ func (c *DNSConfig) Build() (*dns.Config, error) {
 		DisableFallbackIfMatch: c.DisableFallbackIfMatch,
 		EnableParallelQuery:    c.EnableParallelQuery,
 		QueryStrategy:          resolveQueryStrategy(c.QueryStrategy),
+		CacheFile:              c.CacheFile,
+		CacheSaveInterval:      c.CacheSaveInterval,
 	}
 
 	if c.ClientIP != nil {
 		if !c.ClientIP.Family().IsIP() {
-			return nil, errors.New("not an IP address:", c.ClientIP.String())
+			return nil, withField("clientIp", errors.New("not an IP address:", c.ClientIP.String()))
 		}
 		config.ClientIp = []byte(c.ClientIP.IP())
 	}
 
+	if c.CacheSaveInterval > 0 && c.CacheFile == "" {
+		return nil, withField("cacheSaveInterval", errors.New("cacheFile is not specified"))
+	}
+
 	// Build PolicyID
 	policyMap := map[string]uint32{}
 	nextPolicyID := uint32(1)
@@ -307,6 +331,13 @@ func (c *DNSConfig) Build() (*dns.Config, error) {
 			sb.WriteString("skip=0|")
 		}
 
+		// DNSSEC
+		if nsc.DNSSEC {
+			sb.WriteString("dnssec=1|")
+		} else {
+			sb.WriteString("dnssec=0|")
+		}
+
 		// QueryStrategy
 		sb.WriteString("qs=")
 		sb.WriteString(strings.ToLower(strings.TrimSpace(nsc.QueryStrategy)))
@@ -363,7 +394,7 @@ func (c *DNSConfig) Build() (*dns.Config, error) {
 	if c.Hosts != nil {
 		staticHosts, err := c.Hosts.Build()
 		if err != nil {
-			return nil, errors.New("failed to build hosts").Base(err)
+			return nil, errors.New("failed to build hosts").Base(withField("hosts", err))
 		}
 		config.StaticHosts = append(config.StaticHosts, staticHosts...)
 	}
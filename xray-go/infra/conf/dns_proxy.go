@@ -1,6 +1,7 @@
 package conf
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/xtls/xray-core/common/errors"
@@ -10,11 +11,26 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// svcParamKeys are the names of SvcParamKeys in RFC 9460 and its extensions.
+var svcParamKeys = map[string]uint32{
+	"mandatory":            0,
+	"alpn":                 1,
+	"no-default-alpn":      2,
+	"port":                 3,
+	"ipv4hint":             4,
+	"ech":                  5,
+	"ipv6hint":             6,
+	"dohpath":              7,
+	"ohttp":                8,
+	"tls-supported-groups": 9,
+}
+
 type DNSOutboundRuleConfig struct {
-	Action string      `json:"action"`
-	QType  *PortList   `json:"qType"`
-	Domain *StringList `json:"domain"`
-	RCode  uint32      `json:"rCode"`
+	Action         string      `json:"action"`
+	QType          *PortList   `json:"qType"`
+	Domain         *StringList `json:"domain"`
+	RCode          uint32      `json:"rCode"`
+	StripSvcParams *StringList `json:"stripSvcParams"`
 }
 
 func (c *DNSOutboundRuleConfig) Build() (*dns.DNSRuleConfig, error) {
@@ -54,6 +70,22 @@ func (c *DNSOutboundRuleConfig) Build() (*dns.DNSRuleConfig, error) {
 	}
 	rule.RCode = c.RCode
 
+	if c.StripSvcParams != nil {
+		for _, name := range *c.StripSvcParams {
+			name = strings.ToLower(name)
+			key, found := svcParamKeys[name]
+			if !found {
+				// Keys without names are in the form of "keyNNNNN".
+				n, err := strconv.ParseUint(strings.TrimPrefix(name, "key"), 10, 16)
+				if err != nil || !strings.HasPrefix(name, "key") {
+					return nil, errors.New("unknown SvcParamKey: ", name)
+				}
+				key = uint32(n)
+			}
+			rule.StripSvcParam = append(rule.StripSvcParam, key)
+		}
+	}
+
 	return rule, nil
 }
 
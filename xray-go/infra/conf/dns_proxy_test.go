@@ -122,9 +122,47 @@ func TestDnsProxyConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			Input: `{
+				"rules": [{
+					"action": "hijack",
+					"qType": 65,
+					"stripSvcParams": ["ech", "IPv6Hint", "key65280"]
+				}]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &dns.Config{
+				RewriteServer: &net.Endpoint{},
+				Rule: []*dns.DNSRuleConfig{
+					{
+						Action:        dns.RuleAction_Hijack,
+						QType:         []int32{65},
+						StripSvcParam: []uint32{5, 6, 65280},
+					},
+				},
+			},
+		},
 	})
 }
 
+func TestDnsProxyConfigRejectsUnknownSvcParam(t *testing.T) {
+	creator := func() Buildable {
+		return new(DNSOutboundConfig)
+	}
+
+	for _, name := range []string{"ech-config", "key65536", "key"} {
+		_, err := loadJSON(creator)(`{
+			"rules": [{
+				"action": "hijack",
+				"stripSvcParams": ["` + name + `"]
+			}]
+		}`)
+		if err == nil || !strings.Contains(err.Error(), `unknown SvcParamKey`) {
+			t.Error("expected unknown SvcParamKey error of ", name, ", but got ", err)
+		}
+	}
+}
+
 // todo: remove legacy
 func TestDnsProxyConfigLegacyCompatibility(t *testing.T) {
 	creator := func() Buildable {
@@ -104,6 +104,44 @@ func TestDNSConfigParsing(t *testing.T) {
 				DisableFallback: true,
 			},
 		},
+		{
+			Input: `{
+				"servers": [{
+					"address": "8.8.8.8",
+					"dnssec": true,
+					"trustAnchors": [". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"]
+				}]
+			}`,
+			Parser: parserCreator(),
+			Output: &dns.Config{
+				NameServer: []*dns.NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{8, 8, 8, 8},
+								},
+							},
+							Network: net.Network_UDP,
+						},
+						Dnssec:      true,
+						TrustAnchor: []string{". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"},
+						PolicyID:    1,
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"cacheFile": "dns_cache.json",
+				"cacheSaveInterval": 60
+			}`,
+			Parser: parserCreator(),
+			Output: &dns.Config{
+				CacheFile:         "dns_cache.json",
+				CacheSaveInterval: 60,
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -122,3 +160,20 @@ func TestDNSConfigParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestDNSConfigParsingInvalid(t *testing.T) {
+	for _, input := range []string{
+		`{"cacheSaveInterval": 60}`,
+		`{"cacheFile": "dns_cache.json", "cacheSaveInterval": -1}`,
+		`{"servers": [{"address": "8.8.8.8", "dnssec": true, "trustAnchors": ["not a record"]}]}`,
+		`{"servers": [{"address": "8.8.8.8", "dnssec": true, "trustAnchors": [". IN A 127.0.0.1"]}]}`,
+	} {
+		config := new(DNSConfig)
+		if err := json.Unmarshal([]byte(input), config); err != nil {
+			continue
+		}
+		if _, err := config.Build(); err == nil {
+			t.Error("expected error for ", input)
+		}
+	}
+}
@@ -11,8 +11,9 @@ import (
 )
 
 type FakeDNSPoolElementConfig struct {
-	IPPool  string `json:"ipPool"`
-	LRUSize int64  `json:"poolSize"`
+	IPPool    string `json:"ipPool"`
+	LRUSize   int64  `json:"poolSize"`
+	StateFile string `json:"stateFile"`
 }
 
 type FakeDNSConfig struct {
@@ -52,15 +53,23 @@ func (f *FakeDNSConfig) Build() (*fakedns.FakeDnsPoolMulti, error) {
 
 	if f.pool != nil {
 		fakeDNSPool.Pools = append(fakeDNSPool.Pools, &fakedns.FakeDnsPool{
-			IpPool:  f.pool.IPPool,
-			LruSize: f.pool.LRUSize,
+			IpPool:    f.pool.IPPool,
+			LruSize:   f.pool.LRUSize,
+			StateFile: f.pool.StateFile,
 		})
 		return &fakeDNSPool, nil
 	}
 
 	if f.pools != nil {
+		stateFiles := make(map[string]bool)
 		for _, v := range f.pools {
-			fakeDNSPool.Pools = append(fakeDNSPool.Pools, &fakedns.FakeDnsPool{IpPool: v.IPPool, LruSize: v.LRUSize})
+			if v.StateFile != "" {
+				if stateFiles[v.StateFile] {
+					return nil, errors.New("state file shared by fake DNS pools: ", v.StateFile)
+				}
+				stateFiles[v.StateFile] = true
+			}
+			fakeDNSPool.Pools = append(fakeDNSPool.Pools, &fakedns.FakeDnsPool{IpPool: v.IPPool, LruSize: v.LRUSize, StateFile: v.StateFile})
 		}
 		return &fakeDNSPool, nil
 	}
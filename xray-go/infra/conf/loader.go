@@ -46,9 +46,12 @@ func (v *JSONConfigLoader) LoadWithID(raw []byte, id string) (interface{}, error
 	id = strings.ToLower(id)
 	config, err := v.cache.CreateConfig(id)
 	if err != nil {
-		return nil, err
+		return nil, withField(v.idKey, err)
 	}
 	if err := json.Unmarshal(raw, config); err != nil {
+		if len(v.configKey) > 0 {
+			return nil, withField(v.configKey, err)
+		}
 		return nil, err
 	}
 	return config, nil
@@ -2,8 +2,10 @@ package conf
 
 import (
 	"strings"
+	"time"
 
 	"github.com/xtls/xray-core/app/log"
+	"github.com/xtls/xray-core/common/errors"
 	clog "github.com/xtls/xray-core/common/log"
 )
 
@@ -21,11 +23,58 @@ type LogConfig struct {
 	LogLevel    string `json:"loglevel"`
 	DNSLog      bool   `json:"dnsLog"`
 	MaskAddress string `json:"maskAddress"`
+	// AccessFormat is "text" by default, or "json".
+	AccessFormat string             `json:"accessFormat"`
+	Rotation     *LogRotationConfig `json:"rotation"`
 }
 
-func (v *LogConfig) Build() *log.Config {
+// LogRotationConfig is the rotation of log files.
+type LogRotationConfig struct {
+	// MaxSize is the size in MB over which a log file is rotated.
+	MaxSize uint64 `json:"maxSize"`
+	// Interval is "hourly", "daily", "weekly" or a duration like "12h".
+	Interval string `json:"interval"`
+	MaxFiles uint32 `json:"maxFiles"`
+	Compress bool   `json:"compress"`
+}
+
+func (c *LogRotationConfig) Build() (*log.Rotation, error) {
+	config := &log.Rotation{
+		MaxSize:  c.MaxSize * 1024 * 1024,
+		MaxFiles: c.MaxFiles,
+		Compress: c.Compress,
+	}
+
+	var interval time.Duration
+	switch strings.ToLower(c.Interval) {
+	case "":
+	case "hourly":
+		interval = time.Hour
+	case "daily":
+		interval = 24 * time.Hour
+	case "weekly":
+		interval = 7 * 24 * time.Hour
+	default:
+		d, err := time.ParseDuration(c.Interval)
+		if err != nil {
+			return nil, errors.New("invalid rotation interval: ", c.Interval).Base(err)
+		}
+		if d < time.Second {
+			return nil, errors.New("rotation interval must be at least 1s: ", c.Interval)
+		}
+		interval = d
+	}
+	config.Interval = uint64(interval / time.Second)
+
+	if config.MaxSize == 0 && config.Interval == 0 {
+		return nil, errors.New("either maxSize or interval of rotation must be set")
+	}
+	return config, nil
+}
+
+func (v *LogConfig) Build() (*log.Config, error) {
 	if v == nil {
-		return nil
+		return nil, nil
 	}
 	config := &log.Config{
 		ErrorLogType:  log.LogType_Console,
@@ -35,12 +84,16 @@ func (v *LogConfig) Build() *log.Config {
 
 	if v.AccessLog == "none" {
 		config.AccessLogType = log.LogType_None
+	} else if v.AccessLog == "event" {
+		config.AccessLogType = log.LogType_Event
 	} else if len(v.AccessLog) > 0 {
 		config.AccessLogPath = v.AccessLog
 		config.AccessLogType = log.LogType_File
 	}
 	if v.ErrorLog == "none" {
 		config.ErrorLogType = log.LogType_None
+	} else if v.ErrorLog == "event" {
+		config.ErrorLogType = log.LogType_Event
 	} else if len(v.ErrorLog) > 0 {
 		config.ErrorLogPath = v.ErrorLog
 		config.ErrorLogType = log.LogType_File
@@ -61,5 +114,15 @@ func (v *LogConfig) Build() *log.Config {
 		config.ErrorLogLevel = clog.Severity_Warning
 	}
 	config.MaskAddress = v.MaskAddress
-	return config
+	if strings.ToLower(v.AccessFormat) == "json" {
+		config.AccessLogFormat = log.LogFormat_Json
+	}
+	if v.Rotation != nil {
+		rotation, err := v.Rotation.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Rotation = rotation
+	}
+	return config, nil
 }
@@ -0,0 +1,59 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/xtls/xray-core/app/log"
+	"github.com/xtls/xray-core/common"
+	clog "github.com/xtls/xray-core/common/log"
+	. "github.com/xtls/xray-core/infra/conf"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestLogConfig(t *testing.T) {
+	config := new(LogConfig)
+	common.Must(json.Unmarshal([]byte(`{
+		"access": "/var/log/xray/access.log",
+		"loglevel": "info",
+		"accessFormat": "json",
+		"rotation": {
+			"maxSize": 100,
+			"interval": "daily",
+			"maxFiles": 7,
+			"compress": true
+		}
+	}`), config))
+	actual, err := config.Build()
+	common.Must(err)
+
+	expected := &log.Config{
+		ErrorLogType:    log.LogType_Console,
+		ErrorLogLevel:   clog.Severity_Info,
+		AccessLogType:   log.LogType_File,
+		AccessLogPath:   "/var/log/xray/access.log",
+		AccessLogFormat: log.LogFormat_Json,
+		Rotation: &log.Rotation{
+			MaxSize:  100 * 1024 * 1024,
+			Interval: 24 * 60 * 60,
+			MaxFiles: 7,
+			Compress: true,
+		},
+	}
+	if !proto.Equal(actual, expected) {
+		t.Error("expected ", expected, ", but actually ", actual)
+	}
+}
+
+func TestLogRotationConfigInvalid(t *testing.T) {
+	for _, input := range []*LogRotationConfig{
+		{},
+		{MaxFiles: 7},
+		{Interval: "monthly"},
+		{Interval: "1ms"},
+	} {
+		if _, err := input.Build(); err == nil {
+			t.Error("expected error for ", input)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package conf
 
 import (
 	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/common/errors"
 )
 
 type Policy struct {
@@ -13,6 +14,33 @@ type Policy struct {
 	StatsUserDownlink bool    `json:"statsUserDownlink"`
 	StatsUserOnline   bool    `json:"statsUserOnline"`
 	BufferSize        *int32  `json:"bufferSize"`
+	LimitPolicy
+}
+
+// LimitPolicy is the traffic limits of a user level, or an inbound.
+type LimitPolicy struct {
+	UplinkRate   Bandwidth `json:"uplinkRate"`
+	DownlinkRate Bandwidth `json:"downlinkRate"`
+	Quota        uint64    `json:"quota"`
+}
+
+func (l *LimitPolicy) Build() (*policy.Policy_Limit, error) {
+	uplink, err := l.UplinkRate.Bps()
+	if err != nil {
+		return nil, errors.New("invalid uplinkRate").Base(err)
+	}
+	downlink, err := l.DownlinkRate.Bps()
+	if err != nil {
+		return nil, errors.New("invalid downlinkRate").Base(err)
+	}
+	if uplink == 0 && downlink == 0 && l.Quota == 0 {
+		return nil, nil
+	}
+	return &policy.Policy_Limit{
+		UplinkRate:   uplink,
+		DownlinkRate: downlink,
+		Quota:        l.Quota,
+	}, nil
 }
 
 func (t *Policy) Build() (*policy.Policy, error) {
@@ -49,6 +77,12 @@ func (t *Policy) Build() (*policy.Policy, error) {
 		}
 	}
 
+	limit, err := t.LimitPolicy.Build()
+	if err != nil {
+		return nil, err
+	}
+	p.Limit = limit
+
 	return p, nil
 }
 
@@ -57,17 +91,36 @@ type SystemPolicy struct {
 	StatsInboundDownlink  bool `json:"statsInboundDownlink"`
 	StatsOutboundUplink   bool `json:"statsOutboundUplink"`
 	StatsOutboundDownlink bool `json:"statsOutboundDownlink"`
+
+	InboundLimits map[string]*LimitPolicy `json:"inboundLimits"`
 }
 
 func (p *SystemPolicy) Build() (*policy.SystemPolicy, error) {
-	return &policy.SystemPolicy{
+	config := &policy.SystemPolicy{
 		Stats: &policy.SystemPolicy_Stats{
 			InboundUplink:    p.StatsInboundUplink,
 			InboundDownlink:  p.StatsInboundDownlink,
 			OutboundUplink:   p.StatsOutboundUplink,
 			OutboundDownlink: p.StatsOutboundDownlink,
 		},
-	}, nil
+	}
+	for tag, l := range p.InboundLimits {
+		if l == nil {
+			continue
+		}
+		limit, err := l.Build()
+		if err != nil {
+			return nil, errors.New("invalid limits of inbound ", tag).Base(err)
+		}
+		if limit == nil {
+			continue
+		}
+		if config.InboundLimit == nil {
+			config.InboundLimit = make(map[string]*policy.Policy_Limit)
+		}
+		config.InboundLimit[tag] = limit
+	}
+	return config, nil
 }
 
 type PolicyConfig struct {
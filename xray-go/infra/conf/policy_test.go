@@ -1,10 +1,13 @@
 package conf_test
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/xtls/xray-core/app/policy"
 	"github.com/xtls/xray-core/common"
 	. "github.com/xtls/xray-core/infra/conf"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestBufferSize(t *testing.T) {
@@ -38,3 +41,45 @@ func TestBufferSize(t *testing.T) {
 		}
 	}
 }
+
+func TestLimitPolicy(t *testing.T) {
+	var c PolicyConfig
+	common.Must(json.Unmarshal([]byte(`{
+		"levels": {
+			"0": {"uplinkRate": "8 mbps", "downlinkRate": "16 mbps", "quota": 1073741824},
+			"1": {"handshake": 4}
+		},
+		"system": {
+			"inboundLimits": {"in": {"downlinkRate": "800 kbps"}}
+		}
+	}`), &c))
+	config, err := c.Build()
+	common.Must(err)
+
+	expected := &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			0: {
+				Timeout: &policy.Policy_Timeout{},
+				Stats:   &policy.Policy_Stats{},
+				Limit:   &policy.Policy_Limit{UplinkRate: 1024 * 1024, DownlinkRate: 2 * 1024 * 1024, Quota: 1073741824},
+			},
+			1: {
+				Timeout: &policy.Policy_Timeout{Handshake: &policy.Second{Value: 4}},
+				Stats:   &policy.Policy_Stats{},
+			},
+		},
+		System: &policy.SystemPolicy{
+			Stats: &policy.SystemPolicy_Stats{},
+			InboundLimit: map[string]*policy.Policy_Limit{
+				"in": {DownlinkRate: 100 * 1024},
+			},
+		},
+	}
+	if !proto.Equal(config, expected) {
+		t.Error("expected ", expected, ", but actually ", config)
+	}
+
+	if _, err := (&Policy{LimitPolicy: LimitPolicy{UplinkRate: "10 parsecs"}}).Build(); err == nil {
+		t.Error("expected error for invalid rate")
+	}
+}
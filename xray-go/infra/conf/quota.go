@@ -0,0 +1,110 @@
+package conf
+
+import (
+	"github.com/robfig/cron/v3"
+	"github.com/xtls/xray-core/app/quota"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/core"
+	"google.golang.org/protobuf/proto"
+)
+
+// quotaResetCron converts the reset period of quotas to a cron expression.
+func quotaResetCron(reset string) string {
+	switch reset {
+	case "daily", "weekly", "monthly":
+		return "@" + reset
+	}
+	return reset
+}
+
+type QuotaUserConfig struct {
+	Email string `json:"email"`
+	Quota uint64 `json:"quota"`
+	Reset string `json:"reset"`
+}
+
+type QuotaConfig struct {
+	Reset     string             `json:"reset"`
+	StateFile string             `json:"stateFile"`
+	Users     []*QuotaUserConfig `json:"users"`
+}
+
+func (c *QuotaConfig) Build() (proto.Message, error) {
+	config := &quota.Config{
+		StateFile: c.StateFile,
+	}
+
+	emails := make(map[string]bool, len(c.Users))
+	for _, u := range c.Users {
+		if u.Email == "" {
+			return nil, errors.New("empty email of quota user")
+		}
+		if emails[u.Email] {
+			return nil, errors.New("duplicated quota user: ", u.Email)
+		}
+		emails[u.Email] = true
+		if u.Quota == 0 {
+			return nil, errors.New("empty quota of user ", u.Email)
+		}
+
+		reset := u.Reset
+		if reset == "" {
+			reset = c.Reset
+		}
+		reset = quotaResetCron(reset)
+		if _, err := cron.ParseStandard(reset); err != nil {
+			return nil, errors.New("invalid quota reset of user ", u.Email).Base(err)
+		}
+
+		config.Users = append(config.Users, &quota.User{
+			Email:     u.Email,
+			Quota:     u.Quota,
+			ResetCron: reset,
+		})
+	}
+
+	return config, nil
+}
+
+// checkStats checks that the traffic of quota users is counted, which needs
+// stats and the user traffic stats in the policies of their levels. Users not
+// in any inbound, such as those to be added by the API, are at level 0.
+func (c *QuotaConfig) checkStats(config *Config, inbounds []*core.InboundHandlerConfig) error {
+	if len(c.Users) == 0 {
+		return nil
+	}
+	if config.Stats == nil {
+		return errors.New(`quota requires "stats"`)
+	}
+
+	levels := make(map[string]uint32)
+	for _, in := range inbounds {
+		settings, err := in.ProxySettings.GetInstance()
+		if err != nil {
+			return err
+		}
+		var users []*protocol.User
+		switch s := settings.(type) {
+		case interface{ GetUsers() []*protocol.User }:
+			users = s.GetUsers()
+		case interface{ GetUser() []*protocol.User }:
+			users = s.GetUser()
+		}
+		for _, u := range users {
+			levels[u.Email] = u.Level
+		}
+	}
+
+	for _, u := range c.Users {
+		level := levels[u.Email]
+		var p *Policy
+		if config.Policy != nil {
+			p = config.Policy.Levels[level]
+		}
+		if p == nil || !p.StatsUserUplink || !p.StatsUserDownlink {
+			return errors.New("quota of user ", u.Email, " requires statsUserUplink and statsUserDownlink in policy of level ", level)
+		}
+	}
+	return nil
+}
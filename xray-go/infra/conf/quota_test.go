@@ -0,0 +1,116 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/xtls/xray-core/app/quota"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestQuotaConfig(t *testing.T) {
+	creator := func() Buildable {
+		return new(QuotaConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"reset": "monthly",
+				"stateFile": "quota.json",
+				"users": [
+					{"email": "a", "quota": 1073741824},
+					{"email": "b", "quota": 1024, "reset": "0 0 * * 1"}
+				]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &quota.Config{
+				StateFile: "quota.json",
+				Users: []*quota.User{
+					{Email: "a", Quota: 1073741824, ResetCron: "@monthly"},
+					{Email: "b", Quota: 1024, ResetCron: "0 0 * * 1"},
+				},
+			},
+		},
+	})
+}
+
+func TestQuotaConfigInvalid(t *testing.T) {
+	for _, input := range []*QuotaConfig{
+		{Reset: "daily", Users: []*QuotaUserConfig{{Quota: 1}}},
+		{Reset: "daily", Users: []*QuotaUserConfig{{Email: "a"}}},
+		{Users: []*QuotaUserConfig{{Email: "a", Quota: 1}}},
+		{Reset: "hourly", Users: []*QuotaUserConfig{{Email: "a", Quota: 1}}},
+		{Reset: "daily", Users: []*QuotaUserConfig{{Email: "a", Quota: 1}, {Email: "a", Quota: 2}}},
+	} {
+		if _, err := input.Build(); err == nil {
+			t.Error("expected error for ", input)
+		}
+	}
+}
+
+func TestQuotaConfigStats(t *testing.T) {
+	const inbounds = `"inbounds": [{
+		"protocol": "vless",
+		"port": 1234,
+		"settings": {
+			"clients": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "email": "a", "level": 1}],
+			"decryption": "none"
+		}
+	}],
+	"quota": {"reset": "daily", "users": [{"email": "a", "quota": 1}]}`
+
+	for _, tc := range []struct {
+		input string
+		valid bool
+	}{
+		{
+			input: `{
+				"stats": {},
+				"policy": {"levels": {"1": {"statsUserUplink": true, "statsUserDownlink": true}}},
+				` + inbounds + `
+			}`,
+			valid: true,
+		},
+		{
+			input: `{
+				"policy": {"levels": {"1": {"statsUserUplink": true, "statsUserDownlink": true}}},
+				` + inbounds + `
+			}`,
+		},
+		{
+			input: `{
+				"stats": {},
+				"policy": {"levels": {"0": {"statsUserUplink": true, "statsUserDownlink": true}}},
+				` + inbounds + `
+			}`,
+		},
+		{
+			input: `{
+				"stats": {},
+				"policy": {"levels": {"1": {"statsUserUplink": true}}},
+				` + inbounds + `
+			}`,
+		},
+		{
+			// Users not in any inbound are at level 0.
+			input: `{
+				"stats": {},
+				"policy": {"levels": {"1": {"statsUserUplink": true, "statsUserDownlink": true}}},
+				"quota": {"reset": "daily", "users": [{"email": "b", "quota": 1}]}
+			}`,
+		},
+	} {
+		config := new(Config)
+		if err := json.Unmarshal([]byte(tc.input), config); err != nil {
+			t.Fatal(err)
+		}
+		_, err := config.Build()
+		if tc.valid && err != nil {
+			t.Error("unexpected error for ", tc.input, ": ", err)
+		}
+		if !tc.valid && err == nil {
+			t.Error("expected error for ", tc.input)
+		}
+	}
+}
@@ -28,10 +28,10 @@ type BalancingRule struct {
 // Build builds the balancing rule
 func (r *BalancingRule) Build() (*router.BalancingRule, error) {
 	if r.Tag == "" {
-		return nil, errors.New("empty balancer tag")
+		return nil, withField("tag", errors.New("empty balancer tag"))
 	}
 	if len(r.Selectors) == 0 {
-		return nil, errors.New("empty selector list")
+		return nil, withField("selector", errors.New("empty selector list"))
 	}
 
 	r.Strategy.Type = strings.ToLower(r.Strategy.Type)
@@ -40,7 +40,7 @@ func (r *BalancingRule) Build() (*router.BalancingRule, error) {
 		r.Strategy.Type = strategyRandom
 	case strategyRandom, strategyLeastLoad, strategyLeastPing, strategyRoundRobin:
 	default:
-		return nil, errors.New("unknown balancing strategy: " + r.Strategy.Type)
+		return nil, withField("strategy.type", errors.New("unknown balancing strategy: "+r.Strategy.Type))
 	}
 
 	settings := []byte("{}")
@@ -49,7 +49,7 @@ func (r *BalancingRule) Build() (*router.BalancingRule, error) {
 	}
 	rawConfig, err := strategyConfigLoader.LoadWithID(settings, r.Strategy.Type)
 	if err != nil {
-		return nil, errors.New("failed to parse to strategy config.").Base(err)
+		return nil, errors.New("failed to parse to strategy config.").Base(withField("strategy", err))
 	}
 	var ts proto.Message
 	if builder, ok := rawConfig.(Buildable); ok {
@@ -129,27 +129,29 @@ type WebhookRuleConfig struct {
 	Headers       map[string]string `json:"headers"`
 }
 
+// RawFieldRule is the JSON form of a routing rule.
+type RawFieldRule struct {
+	RouterRule
+	Domain     *StringList        `json:"domain"`
+	Domains    *StringList        `json:"domains"`
+	IP         *StringList        `json:"ip"`
+	Port       *PortList          `json:"port"`
+	Network    *NetworkList       `json:"network"`
+	SourceIP   *StringList        `json:"sourceIP"`
+	Source     *StringList        `json:"source"`
+	SourcePort *PortList          `json:"sourcePort"`
+	User       *StringList        `json:"user"`
+	VlessRoute *PortList          `json:"vlessRoute"`
+	InboundTag *StringList        `json:"inboundTag"`
+	Protocols  *StringList        `json:"protocol"`
+	Attributes map[string]string  `json:"attrs"`
+	LocalIP    *StringList        `json:"localIP"`
+	LocalPort  *PortList          `json:"localPort"`
+	Process    *StringList        `json:"process"`
+	Webhook    *WebhookRuleConfig `json:"webhook"`
+}
+
 func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
-	type RawFieldRule struct {
-		RouterRule
-		Domain     *StringList        `json:"domain"`
-		Domains    *StringList        `json:"domains"`
-		IP         *StringList        `json:"ip"`
-		Port       *PortList          `json:"port"`
-		Network    *NetworkList       `json:"network"`
-		SourceIP   *StringList        `json:"sourceIP"`
-		Source     *StringList        `json:"source"`
-		SourcePort *PortList          `json:"sourcePort"`
-		User       *StringList        `json:"user"`
-		VlessRoute *PortList          `json:"vlessRoute"`
-		InboundTag *StringList        `json:"inboundTag"`
-		Protocols  *StringList        `json:"protocol"`
-		Attributes map[string]string  `json:"attrs"`
-		LocalIP    *StringList        `json:"localIP"`
-		LocalPort  *PortList          `json:"localPort"`
-		Process    *StringList        `json:"process"`
-		Webhook    *WebhookRuleConfig `json:"webhook"`
-	}
 	rawFieldRule := new(RawFieldRule)
 	err := json.Unmarshal(msg, rawFieldRule)
 	if err != nil {
@@ -174,7 +176,7 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 	if rawFieldRule.Domain != nil {
 		rules, err := geodata.ParseDomainRules(*rawFieldRule.Domain, geodata.Domain_Substr)
 		if err != nil {
-			return nil, err
+			return nil, withField("domain", err)
 		}
 		rule.Domain = rules
 	}
@@ -182,7 +184,7 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 	if rawFieldRule.Domains != nil {
 		rules, err := geodata.ParseDomainRules(*rawFieldRule.Domains, geodata.Domain_Substr)
 		if err != nil {
-			return nil, err
+			return nil, withField("domains", err)
 		}
 		rule.Domain = rules
 	}
@@ -190,7 +192,7 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 	if rawFieldRule.IP != nil {
 		rules, err := geodata.ParseIPRules(*rawFieldRule.IP)
 		if err != nil {
-			return nil, err
+			return nil, withField("ip", err)
 		}
 		rule.Ip = rules
 	}
@@ -203,14 +205,16 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		rule.Networks = rawFieldRule.Network.Build()
 	}
 
+	sourceIPField := "sourceIP"
 	if rawFieldRule.SourceIP == nil {
 		rawFieldRule.SourceIP = rawFieldRule.Source
+		sourceIPField = "source"
 	}
 
 	if rawFieldRule.SourceIP != nil {
 		rules, err := geodata.ParseIPRules(*rawFieldRule.SourceIP)
 		if err != nil {
-			return nil, err
+			return nil, withField(sourceIPField, err)
 		}
 		rule.SourceIp = rules
 	}
@@ -222,7 +226,7 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 	if rawFieldRule.LocalIP != nil {
 		rules, err := geodata.ParseIPRules(*rawFieldRule.LocalIP)
 		if err != nil {
-			return nil, err
+			return nil, withField("localIP", err)
 		}
 		rule.LocalIp = rules
 	}
@@ -0,0 +1,327 @@
+package conf
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/infra/conf/cfgcommon/duration"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of the document made by JSONSchema.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+type jsonSchema map[string]interface{}
+
+func stringOrArraySchema() jsonSchema {
+	return jsonSchema{"oneOf": []interface{}{
+		jsonSchema{"type": "string"},
+		jsonSchema{"type": "array", "items": jsonSchema{"type": "string"}},
+	}}
+}
+
+// nameSchema matches one of names, ignoring case like the parser. The names
+// are also given as examples, for editors to offer them.
+func nameSchema(names []string) jsonSchema {
+	return jsonSchema{
+		"type":     "string",
+		"pattern":  anyCasePattern(names),
+		"examples": names,
+	}
+}
+
+func anyCasePattern(names []string) string {
+	alternatives := make([]string, 0, len(names))
+	for _, name := range names {
+		var b strings.Builder
+		for _, r := range name {
+			if lower, upper := unicode.ToLower(r), unicode.ToUpper(r); lower != upper {
+				b.WriteString("[" + string(lower) + string(upper) + "]")
+			} else {
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		alternatives = append(alternatives, b.String())
+	}
+	return "^(" + strings.Join(alternatives, "|") + ")$"
+}
+
+func integerOrStringSchema() jsonSchema {
+	return jsonSchema{"type": []string{"integer", "string"}}
+}
+
+var (
+	// customSchemas describes the types that decode themselves from JSON. A
+	// nil function means the type decodes from its own fields.
+	customSchemas map[reflect.Type]func(g *schemaGenerator, t reflect.Type) jsonSchema
+
+	// schemaExtensions refine the schema generated from the fields of a
+	// struct, for fields whose shape is decided by a registry rather than by
+	// Go types.
+	schemaExtensions map[reflect.Type]func(g *schemaGenerator, s jsonSchema)
+)
+
+// transportProtocols lists the values of "network" accepted by
+// TransportProtocol.Build, leaving out the removed ones.
+var transportProtocols = []string{
+	"raw", "tcp", "xhttp", "splithttp", "kcp", "mkcp", "grpc", "ws", "websocket", "httpupgrade", "hysteria",
+}
+
+func init() {
+	// Set in init, as the functions refer back to the maps.
+	customSchemas = map[reflect.Type]func(g *schemaGenerator, t reflect.Type) jsonSchema{
+		reflect.TypeOf(StringList{}):  func(*schemaGenerator, reflect.Type) jsonSchema { return stringOrArraySchema() },
+		reflect.TypeOf(NetworkList{}): func(*schemaGenerator, reflect.Type) jsonSchema { return stringOrArraySchema() },
+		reflect.TypeOf(Address{}): func(*schemaGenerator, reflect.Type) jsonSchema {
+			return jsonSchema{"type": "string"}
+		},
+		reflect.TypeOf(PortRange{}):  func(*schemaGenerator, reflect.Type) jsonSchema { return integerOrStringSchema() },
+		reflect.TypeOf(PortList{}):   func(*schemaGenerator, reflect.Type) jsonSchema { return integerOrStringSchema() },
+		reflect.TypeOf(Int32Range{}): func(*schemaGenerator, reflect.Type) jsonSchema { return integerOrStringSchema() },
+		reflect.TypeOf(duration.Duration(0)): func(*schemaGenerator, reflect.Type) jsonSchema {
+			return jsonSchema{"type": "string"}
+		},
+		reflect.TypeOf(TransportProtocol("")): func(*schemaGenerator, reflect.Type) jsonSchema {
+			return nameSchema(transportProtocols)
+		},
+		reflect.TypeOf(HappyEyeballsConfig{}): nil,
+		reflect.TypeOf(NameServerConfig{}): func(g *schemaGenerator, t reflect.Type) jsonSchema {
+			return jsonSchema{"oneOf": []interface{}{
+				jsonSchema{"type": "string"},
+				g.structSchema(t),
+			}}
+		},
+		reflect.TypeOf(HostAddress{}): func(*schemaGenerator, reflect.Type) jsonSchema { return stringOrArraySchema() },
+		reflect.TypeOf(HostsWrapper{}): func(g *schemaGenerator, t reflect.Type) jsonSchema {
+			return jsonSchema{
+				"type":                 "object",
+				"additionalProperties": g.schemaOf(reflect.TypeOf(HostAddress{})),
+			}
+		},
+		reflect.TypeOf(FakeDNSConfig{}): func(g *schemaGenerator, t reflect.Type) jsonSchema {
+			pool := g.schemaOf(reflect.TypeOf(FakeDNSPoolElementConfig{}))
+			return jsonSchema{"oneOf": []interface{}{
+				pool,
+				jsonSchema{"type": "array", "items": pool},
+			}}
+		},
+	}
+
+	schemaExtensions = map[reflect.Type]func(g *schemaGenerator, s jsonSchema){
+		reflect.TypeOf(InboundDetourConfig{}): func(g *schemaGenerator, s jsonSchema) {
+			g.dispatch(s, inboundConfigLoader)
+		},
+		reflect.TypeOf(OutboundDetourConfig{}): func(g *schemaGenerator, s jsonSchema) {
+			g.dispatch(s, outboundConfigLoader)
+		},
+		reflect.TypeOf(StrategyConfig{}): func(g *schemaGenerator, s jsonSchema) {
+			g.dispatch(s, strategyConfigLoader)
+		},
+		reflect.TypeOf(FinalMask{}): func(g *schemaGenerator, s jsonSchema) {
+			for key, loader := range map[string]*JSONConfigLoader{"tcp": tcpmaskLoader, "udp": udpmaskLoader} {
+				mask := g.structSchema(reflect.TypeOf(Mask{}))
+				g.dispatch(mask, loader)
+				s["properties"].(jsonSchema)[key] = jsonSchema{"type": "array", "items": mask}
+			}
+		},
+		reflect.TypeOf(TCPConfig{}): func(g *schemaGenerator, s jsonSchema) {
+			header := jsonSchema{"type": "object", "properties": jsonSchema{}}
+			g.dispatch(header, tcpHeaderLoader)
+			s["properties"].(jsonSchema)["header"] = header
+		},
+		reflect.TypeOf(RouterConfig{}): func(g *schemaGenerator, s jsonSchema) {
+			s["properties"].(jsonSchema)["rules"] = jsonSchema{
+				"type":  "array",
+				"items": g.schemaOf(reflect.TypeOf(RawFieldRule{})),
+			}
+		},
+	}
+}
+
+var (
+	rawMessageType      = reflect.TypeOf(json.RawMessage{})
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+type schemaGenerator struct {
+	defs  jsonSchema
+	names map[reflect.Type]string
+	err   error
+}
+
+// JSONSchema returns a JSON Schema document for the JSON form of Config. It is
+// generated from the config types and from the registered protocols,
+// transports, finalmasks and balancing strategies, so it follows the parser.
+// Fields that are decoded later by the protocols, such as users, are left
+// free-form.
+func JSONSchema() (map[string]interface{}, error) {
+	g := &schemaGenerator{
+		defs:  make(jsonSchema),
+		names: make(map[reflect.Type]string),
+	}
+	root := g.schemaOf(reflect.TypeOf(Config{}))
+	if g.err != nil {
+		return nil, g.err
+	}
+	doc := map[string]interface{}{
+		"$schema": JSONSchemaDraft,
+		"title":   "Xray config",
+		"$defs":   g.defs,
+	}
+	for k, v := range root {
+		doc[k] = v
+	}
+	return doc, nil
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if custom, found := customSchemas[t]; found {
+		if custom == nil {
+			return g.ref(t)
+		}
+		return custom(g, t)
+	}
+	if t == rawMessageType {
+		return jsonSchema{}
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		g.fail(errors.New("no JSON schema for ", t.String(), ", which decodes itself from JSON"))
+		return jsonSchema{}
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return jsonSchema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return jsonSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return jsonSchema{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return jsonSchema{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return jsonSchema{"type": "number"}
+	case reflect.String:
+		return jsonSchema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Encoded as base64 by encoding/json.
+			return jsonSchema{"type": "string"}
+		}
+		return jsonSchema{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return jsonSchema{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if len(t.Name()) == 0 {
+			return g.structSchema(t)
+		}
+		return g.ref(t)
+	case reflect.Interface:
+		return jsonSchema{}
+	default:
+		g.fail(errors.New("no JSON schema for ", t.String()))
+		return jsonSchema{}
+	}
+}
+
+func (g *schemaGenerator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+// ref puts the schema of the named struct t into $defs, once, and refers to it.
+func (g *schemaGenerator) ref(t reflect.Type) jsonSchema {
+	name, found := g.names[t]
+	if !found {
+		name = t.Name()
+		if _, taken := g.defs[name]; taken {
+			name = strings.ReplaceAll(t.String(), ".", "_")
+		}
+		g.names[t] = name
+		// Reserve the name first, so that recursive types refer to it.
+		g.defs[name] = jsonSchema{}
+		g.defs[name] = g.structSchema(t)
+	}
+	return jsonSchema{"$ref": "#/$defs/" + name}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) jsonSchema {
+	properties := make(jsonSchema)
+	g.addFields(properties, t)
+	s := jsonSchema{"type": "object", "properties": properties}
+	if extend, found := schemaExtensions[t]; found {
+		extend(g, s)
+	}
+	return s
+}
+
+// addFields adds the JSON fields of struct t to properties the same way as
+// encoding/json, where fields of embedded structs are promoted.
+func (g *schemaGenerator) addFields(properties jsonSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && len(name) == 0 {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(properties, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		if _, found := properties[name]; !found {
+			properties[name] = g.schemaOf(f.Type)
+		}
+	}
+}
+
+// dispatch describes an object whose settings depend on the ID registered in
+// loader, such as the protocol of an inbound.
+func (g *schemaGenerator) dispatch(s jsonSchema, loader *JSONConfigLoader) {
+	ids := make([]string, 0, len(loader.cache))
+	for id := range loader.cache {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	properties := s["properties"].(jsonSchema)
+	properties[loader.idKey] = nameSchema(ids)
+
+	cases := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		settings := g.schemaOf(reflect.TypeOf(loader.cache[id]()))
+		if len(loader.configKey) > 0 {
+			settings = jsonSchema{"properties": jsonSchema{loader.configKey: settings}}
+		}
+		cases = append(cases, jsonSchema{
+			"if": jsonSchema{
+				"properties": jsonSchema{loader.idKey: jsonSchema{"pattern": anyCasePattern([]string{id})}},
+				"required":   []string{loader.idKey},
+			},
+			"then": settings,
+		})
+	}
+	s["allOf"] = cases
+}
@@ -0,0 +1,288 @@
+package conf
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// schemaValidator checks a JSON value against the subset of JSON Schema used
+// by JSONSchema.
+type schemaValidator struct {
+	defs map[string]interface{}
+}
+
+// check returns the path of the first part of value that does not match
+// schema, or "" if it matches.
+func (v *schemaValidator) check(schema map[string]interface{}, value interface{}, path string) string {
+	mismatch := path
+	if len(mismatch) == 0 {
+		mismatch = "$"
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		return v.check(v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{}), value, path)
+	}
+	if t, ok := schema["type"]; ok {
+		types, ok := t.([]interface{})
+		if !ok {
+			types = []interface{}{t}
+		}
+		matched := false
+		for _, t := range types {
+			if jsonTypeOf(value, t.(string)) {
+				matched = true
+			}
+		}
+		if !matched {
+			return mismatch
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+			}
+		}
+		if !found {
+			return mismatch
+		}
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		return mismatch
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if str, ok := value.(string); ok && !regexp.MustCompile(pattern).MatchString(str) {
+			return mismatch
+		}
+	}
+	if min, ok := schema["minimum"].(float64); ok {
+		if n, ok := value.(float64); ok && n < min {
+			return mismatch
+		}
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, key := range required {
+				if _, found := object[key.(string)]; !found {
+					return mismatch
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, field := range object {
+			s, found := properties[key]
+			if !found {
+				s, found = schema["additionalProperties"]
+			}
+			if found {
+				if p := v.check(s.(map[string]interface{}), field, joinPath(path, key)); p != "" {
+					return p
+				}
+			}
+		}
+	}
+	if array, ok := value.([]interface{}); ok {
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range array {
+				if p := v.check(items, item, joinPath(path, indexField(i))); p != "" {
+					return p
+				}
+			}
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, s := range oneOf {
+			if v.check(s.(map[string]interface{}), value, path) == "" {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return mismatch
+		}
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, s := range allOf {
+			if p := v.check(s.(map[string]interface{}), value, path); p != "" {
+				return p
+			}
+		}
+	}
+	if cond, ok := schema["if"].(map[string]interface{}); ok && v.check(cond, value, path) == "" {
+		if then, ok := schema["then"].(map[string]interface{}); ok {
+			return v.check(then, value, path)
+		}
+	}
+	return ""
+}
+
+func jsonTypeOf(value interface{}, t string) bool {
+	switch value := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || (t == "integer" && value == float64(int64(value)))
+	case string:
+		return t == "string"
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	}
+	return false
+}
+
+func loadJSONSchema(t *testing.T) (map[string]interface{}, *schemaValidator) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Round trip, so that the schema looks the same as to other tools.
+	b, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc, &schemaValidator{defs: doc["$defs"].(map[string]interface{})}
+}
+
+func TestJSONSchemaTransportProtocols(t *testing.T) {
+	for _, p := range transportProtocols {
+		if _, err := TransportProtocol(p).Build(); err != nil {
+			t.Error("transport protocol ", p, " in schema is not accepted: ", err)
+		}
+	}
+}
+
+func TestJSONSchemaAcceptsValidConfigs(t *testing.T) {
+	schema, v := loadJSONSchema(t)
+
+	configs := []string{
+		`{}`,
+		`{
+			"log": {"loglevel": "debug"},
+			"dns": {
+				"servers": ["1.1.1.1", {"address": "8.8.8.8", "port": 53, "domains": ["geosite:google"]}],
+				"hosts": {"example.com": "127.0.0.1", "example.org": ["1.2.3.4", "::1"]}
+			},
+			"fakedns": [{"ipPool": "198.18.0.0/15", "poolSize": 65535}],
+			"inbounds": [{
+				"port": "1080-1081",
+				"listen": "127.0.0.1",
+				"protocol": "socks",
+				"settings": {"auth": "noauth", "udp": true},
+				"sniffing": {"enabled": true, "destOverride": ["http", "tls"]}
+			}, {
+				"port": 8080,
+				"protocol": "http",
+				"streamSettings": {
+					"network": "raw",
+					"rawSettings": {"header": {"type": "http", "request": {"path": "/"}}}
+				}
+			}],
+			"outbounds": [{
+				"protocol": "freedom",
+				"tag": "direct",
+				"settings": {"domainStrategy": "UseIP"},
+				"streamSettings": {"sockopt": {"happyEyeballs": {"tryDelayMs": 250}}}
+			}, {
+				"protocol": "VLESS",
+				"tag": "proxy",
+				"settings": {"address": "example.com", "port": 443, "id": "27848739-7e62-4138-9fd3-098a63964b6b", "encryption": "none"},
+				"streamSettings": {
+					"network": "xhttp",
+					"security": "tls",
+					"tlsSettings": {"serverName": "example.com", "alpn": ["h2"]},
+					"finalmask": {"udp": [{"type": "salamander", "settings": {"password": "a"}}]}
+				}
+			}],
+			"routing": {
+				"domainStrategy": "IPIfNonMatch",
+				"rules": [
+					{"outboundTag": "direct", "domain": ["example.com"], "port": "80,443"},
+					{"balancerTag": "b", "network": "tcp,udp", "ip": "10.0.0.0/8"}
+				],
+				"balancers": [{"tag": "b", "selector": ["proxy"], "strategy": {"type": "leastPing"}}]
+			}
+		}`,
+	}
+	for _, c := range configs {
+		config := new(Config)
+		if err := json.Unmarshal([]byte(c), config); err != nil {
+			t.Fatal("test config is not accepted by parser: ", err)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(c), &value); err != nil {
+			t.Fatal(err)
+		}
+		if p := v.check(schema, value, ""); p != "" {
+			t.Error("valid config is rejected by schema at ", p, ": ", c)
+		}
+	}
+}
+
+func TestJSONSchemaRejectsInvalidConfigs(t *testing.T) {
+	schema, v := loadJSONSchema(t)
+
+	configs := []string{
+		`{"log": {"loglevel": 1}}`,
+		`{"inbounds": [{"port": true, "protocol": "socks"}]}`,
+		`{"inbounds": [{"protocol": "unknown"}]}`,
+		`{"inbounds": [{"protocol": "socks", "settings": {"udp": "yes"}}]}`,
+		`{"outbounds": [{"protocol": "freedom", "settings": {"domainStrategy": 1}}]}`,
+		`{"outbounds": [{"protocol": "freedom", "streamSettings": {"network": "quic"}}]}`,
+		`{"outbounds": [{"protocol": "freedom", "streamSettings": {"finalmask": {"tcp": [{"type": "unknown"}]}}}]}`,
+		`{"routing": {"rules": [{"outboundTag": "direct", "domain": 1}]}}`,
+		`{"routing": {"balancers": [{"tag": "b", "strategy": {"type": "unknown"}}]}}`,
+		`{"dns": {"servers": [1]}}`,
+	}
+	for _, c := range configs {
+		var value interface{}
+		if err := json.Unmarshal([]byte(c), &value); err != nil {
+			t.Fatal(err)
+		}
+		if v.check(schema, value, "") == "" {
+			t.Error("invalid config is accepted by schema: ", c)
+		}
+	}
+}
+
+func TestJSONSchemaRegistries(t *testing.T) {
+	schema, _ := loadJSONSchema(t)
+	defs := schema["$defs"].(map[string]interface{})
+
+	namesOf := func(def string, key string) map[string]bool {
+		s := defs[def].(map[string]interface{})["properties"].(map[string]interface{})[key].(map[string]interface{})
+		ids := make(map[string]bool)
+		for _, id := range s["examples"].([]interface{}) {
+			ids[id.(string)] = true
+		}
+		return ids
+	}
+	for _, tc := range []struct {
+		def    string
+		loader *JSONConfigLoader
+	}{
+		{"InboundDetourConfig", inboundConfigLoader},
+		{"OutboundDetourConfig", outboundConfigLoader},
+		{"StrategyConfig", strategyConfigLoader},
+	} {
+		ids := namesOf(tc.def, tc.loader.idKey)
+		for id := range tc.loader.cache {
+			if !ids[id] {
+				t.Error(id, " is missing in ", tc.def)
+			}
+			if _, err := tc.loader.LoadWithID([]byte("{}"), id); err != nil {
+				t.Error("failed to load empty settings of ", id, ": ", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package serial
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf"
+	json_reader "github.com/xtls/xray-core/infra/conf/json"
+)
+
+// DecodeOutboundsFromJSONString decodes the outbounds given as a JSON string,
+// which is an outbound object, an array of them, or a config whose
+// "outbounds" are taken.
+func DecodeOutboundsFromJSONString(jsonString string) ([]conf.OutboundDetourConfig, error) {
+	data, err := io.ReadAll(&json_reader.Reader{Reader: strings.NewReader(jsonString)})
+	if err != nil {
+		return nil, errors.New("failed to read outbounds").Base(err)
+	}
+	data = bytes.TrimSpace(data)
+
+	var outbounds []conf.OutboundDetourConfig
+	if bytes.HasPrefix(data, []byte("[")) {
+		err = json.Unmarshal(data, &outbounds)
+	} else {
+		var fields map[string]json.RawMessage
+		if err = json.Unmarshal(data, &fields); err == nil {
+			if raw, found := fields["outbounds"]; found {
+				err = json.Unmarshal(raw, &outbounds)
+			} else {
+				outbounds = make([]conf.OutboundDetourConfig, 1)
+				err = json.Unmarshal(data, &outbounds[0])
+			}
+		}
+	}
+	if err != nil {
+		return nil, errors.New("failed to decode outbounds").Base(err)
+	}
+	if len(outbounds) == 0 {
+		return nil, errors.New("no outbound is given")
+	}
+	return outbounds, nil
+}
+
+// BuildOutboundsFromJSONString builds the outbounds given as a JSON string, in
+// any of the forms accepted by DecodeOutboundsFromJSONString.
+func BuildOutboundsFromJSONString(jsonString string) ([]*core.OutboundHandlerConfig, error) {
+	outbounds, err := DecodeOutboundsFromJSONString(jsonString)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]*core.OutboundHandlerConfig, 0, len(outbounds))
+	for i := range outbounds {
+		config, err := outbounds[i].Build()
+		if err != nil {
+			return nil, errors.New("failed to build outbound ", i).Base(err)
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
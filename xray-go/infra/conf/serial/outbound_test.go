@@ -0,0 +1,51 @@
+package serial_test
+
+import (
+	"testing"
+
+	"github.com/xtls/xray-core/infra/conf/serial"
+)
+
+func TestBuildOutboundsFromJSONString(t *testing.T) {
+	testCases := []struct {
+		Input string
+		Tags  []string
+	}{
+		{
+			Input: `{"protocol": "freedom", "tag": "direct"}`,
+			Tags:  []string{"direct"},
+		},
+		{
+			Input: `[
+				// comment
+				{"protocol": "freedom", "tag": "direct"},
+				{"protocol": "blackhole"}
+			]`,
+			Tags: []string{"direct", ""},
+		},
+		{
+			Input: `{"outbounds": [{"protocol": "blackhole", "tag": "block"}], "log": {"loglevel": "none"}}`,
+			Tags:  []string{"block"},
+		},
+	}
+	for _, testCase := range testCases {
+		outbounds, err := serial.BuildOutboundsFromJSONString(testCase.Input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(outbounds) != len(testCase.Tags) {
+			t.Fatal("expected ", len(testCase.Tags), " outbounds, but actually ", len(outbounds))
+		}
+		for i, outbound := range outbounds {
+			if outbound.Tag != testCase.Tags[i] {
+				t.Error("expected tag ", testCase.Tags[i], ", but actually ", outbound.Tag)
+			}
+		}
+	}
+
+	for _, input := range []string{`[]`, `{"protocol": "unknown"}`, `"freedom"`} {
+		if _, err := serial.BuildOutboundsFromJSONString(input); err == nil {
+			t.Error("expected error for ", input)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package serial
+
+import (
+	"strings"
+
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+// ValidateConfigFiles merges config files the same way as BuildConfig and
+// returns every problem found in the result. A file that cannot be read or
+// decoded is reported as a single issue.
+func ValidateConfigFiles(files []*core.ConfigSource) []*conf.ConfigIssue {
+	c, err := mergeConfigs(files)
+	if err != nil {
+		return []*conf.ConfigIssue{conf.NewErrorIssue(err)}
+	}
+	return c.Validate()
+}
+
+// ValidateJSONString returns every problem found in a config given as a JSON
+// string.
+func ValidateJSONString(jsonString string) []*conf.ConfigIssue {
+	c, err := DecodeJSONConfig(strings.NewReader(jsonString))
+	if err != nil {
+		return []*conf.ConfigIssue{conf.NewErrorIssue(err)}
+	}
+	return c.Validate()
+}
@@ -0,0 +1,664 @@
+package sharelink
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	xnet "github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+func parseVLESS(u *url.URL) (object, error) {
+	address, port, err := server(u, 0)
+	if err != nil {
+		return nil, err
+	}
+	if u.User == nil {
+		return nil, errors.New("VLESS id is missing in share link")
+	}
+	query := u.Query()
+	settings := object{
+		"address":    address,
+		"port":       port,
+		"id":         u.User.Username(),
+		"encryption": "none",
+	}
+	settings.set("encryption", query.Get("encryption"))
+	settings.set("flow", query.Get("flow"))
+	stream, err := parseStream(query, "none")
+	if err != nil {
+		return nil, err
+	}
+	return newOutbound("vless", u.Fragment, settings, stream), nil
+}
+
+func formatVLESS(outbound *conf.OutboundDetourConfig) (string, error) {
+	settings := new(conf.VLessOutboundConfig)
+	if err := decodeSettings(outbound, settings); err != nil {
+		return "", err
+	}
+	address, port := settings.Address, settings.Port
+	account := struct {
+		ID         string `json:"id"`
+		Flow       string `json:"flow"`
+		Encryption string `json:"encryption"`
+	}{settings.Id, settings.Flow, settings.Encryption}
+	if address == nil && len(settings.Vnext) > 0 {
+		if err := singleServer("VLESS", len(settings.Vnext), len(settings.Vnext[0].Users)); err != nil {
+			return "", err
+		}
+		address, port = settings.Vnext[0].Address, settings.Vnext[0].Port
+		if err := json.Unmarshal(settings.Vnext[0].Users[0], &account); err != nil {
+			return "", errors.New("invalid VLESS user").Base(err)
+		}
+	}
+
+	query := url.Values{}
+	query.Set("encryption", account.Encryption)
+	if account.Flow != "" {
+		query.Set("flow", account.Flow)
+	}
+	if err := formatStream(outbound.StreamSetting, query); err != nil {
+		return "", err
+	}
+	return formatURL("vless", url.User(account.ID), address, port, query, outbound.Tag)
+}
+
+// vmessLink is the JSON encoded in vmess:// links, in the format started by
+// v2rayN.
+type vmessLink struct {
+	Version     flexString `json:"v"`
+	Name        string     `json:"ps"`
+	Address     string     `json:"add"`
+	Port        flexString `json:"port"`
+	ID          string     `json:"id"`
+	AlterID     flexString `json:"aid"`
+	Security    string     `json:"scy,omitempty"`
+	Network     string     `json:"net"`
+	Type        string     `json:"type"`
+	Host        string     `json:"host"`
+	Path        string     `json:"path"`
+	TLS         string     `json:"tls"`
+	SNI         string     `json:"sni,omitempty"`
+	ALPN        string     `json:"alpn,omitempty"`
+	Fingerprint string     `json:"fp,omitempty"`
+}
+
+// flexString is a string which may also be given as a JSON number, as done by
+// some clients for vmess:// links.
+type flexString string
+
+// UnmarshalJSON implements encoding/json.Unmarshaler.UnmarshalJSON
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = flexString(str)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*s = flexString(number)
+	return nil
+}
+
+func parseVMess(rest string) (object, error) {
+	data, err := decodeBase64(rest)
+	if err != nil {
+		return nil, err
+	}
+	link := new(vmessLink)
+	if err := json.Unmarshal(data, link); err != nil {
+		return nil, errors.New("invalid VMess share link").Base(err)
+	}
+	port, err := strconv.ParseUint(string(link.Port), 10, 16)
+	if err != nil {
+		return nil, errors.New("invalid server port in share link: ", link.Port)
+	}
+	if link.Address == "" {
+		return nil, errors.New("server address is missing in share link")
+	}
+
+	// "type" is the header type of raw transport, or the mode of others.
+	mode := link.Type
+	if mode == "none" {
+		mode = ""
+	}
+	query := url.Values{}
+	query.Set("type", link.Network)
+	query.Set("host", link.Host)
+	query.Set("path", link.Path)
+	switch strings.ToLower(link.Network) {
+	case "grpc":
+		query.Set("serviceName", link.Path)
+		query.Set("mode", mode)
+	case "xhttp", "splithttp":
+		query.Set("mode", mode)
+	default:
+		query.Set("headerType", mode)
+	}
+	query.Set("security", link.TLS)
+	query.Set("sni", link.SNI)
+	query.Set("alpn", link.ALPN)
+	query.Set("fp", link.Fingerprint)
+
+	settings := object{
+		"address": link.Address,
+		"port":    uint16(port),
+		"id":      link.ID,
+	}
+	settings.set("security", link.Security)
+	stream, err := parseStream(query, "none")
+	if err != nil {
+		return nil, err
+	}
+	return newOutbound("vmess", link.Name, settings, stream), nil
+}
+
+func formatVMess(outbound *conf.OutboundDetourConfig) (string, error) {
+	settings := new(conf.VMessOutboundConfig)
+	if err := decodeSettings(outbound, settings); err != nil {
+		return "", err
+	}
+	address, port := settings.Address, settings.Port
+	account := &conf.VMessAccount{ID: settings.ID, Security: settings.Security}
+	if address == nil && len(settings.Receivers) > 0 {
+		if err := singleServer("VMess", len(settings.Receivers), len(settings.Receivers[0].Users)); err != nil {
+			return "", err
+		}
+		address, port = settings.Receivers[0].Address, settings.Receivers[0].Port
+		if err := json.Unmarshal(settings.Receivers[0].Users[0], account); err != nil {
+			return "", errors.New("invalid VMess user").Base(err)
+		}
+	}
+	if address == nil {
+		return "", errors.New("server address is not set")
+	}
+
+	query := url.Values{}
+	if err := formatStream(outbound.StreamSetting, query); err != nil {
+		return "", err
+	}
+	link := &vmessLink{
+		Version:     "2",
+		Name:        outbound.Tag,
+		Address:     address.String(),
+		Port:        flexString(strconv.Itoa(int(port))),
+		ID:          account.ID,
+		AlterID:     "0",
+		Security:    account.Security,
+		Network:     query.Get("type"),
+		Type:        query.Get("headerType"),
+		Host:        query.Get("host"),
+		Path:        query.Get("path"),
+		TLS:         query.Get("security"),
+		SNI:         query.Get("sni"),
+		ALPN:        query.Get("alpn"),
+		Fingerprint: query.Get("fp"),
+	}
+	if !address.Family().IsDomain() {
+		link.Address = address.IP().String()
+	}
+	switch link.Network {
+	case "grpc":
+		link.Path = query.Get("serviceName")
+		link.Type = query.Get("mode")
+	case "xhttp":
+		link.Type = query.Get("mode")
+	}
+	if link.Type == "" {
+		link.Type = "none"
+	}
+	if link.TLS == "none" {
+		link.TLS = ""
+	}
+	data, err := json.Marshal(link)
+	if err != nil {
+		return "", err
+	}
+	return "vmess://" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+func parseTrojan(u *url.URL) (object, error) {
+	address, port, err := server(u, 0)
+	if err != nil {
+		return nil, err
+	}
+	if u.User == nil {
+		return nil, errors.New("Trojan password is missing in share link")
+	}
+	settings := object{
+		"address":  address,
+		"port":     port,
+		"password": u.User.Username(),
+	}
+	stream, err := parseStream(u.Query(), "tls")
+	if err != nil {
+		return nil, err
+	}
+	return newOutbound("trojan", u.Fragment, settings, stream), nil
+}
+
+func formatTrojan(outbound *conf.OutboundDetourConfig) (string, error) {
+	settings := new(conf.TrojanClientConfig)
+	if err := decodeSettings(outbound, settings); err != nil {
+		return "", err
+	}
+	address, port, password := settings.Address, settings.Port, settings.Password
+	if address == nil && len(settings.Servers) > 0 {
+		if err := singleServer("Trojan", len(settings.Servers), 1); err != nil {
+			return "", err
+		}
+		address, port, password = settings.Servers[0].Address, settings.Servers[0].Port, settings.Servers[0].Password
+	}
+
+	query := url.Values{}
+	if err := formatStream(outbound.StreamSetting, query); err != nil {
+		return "", err
+	}
+	return formatURL("trojan", url.User(password), address, port, query, outbound.Tag)
+}
+
+func parseShadowsocks(u *url.URL) (object, error) {
+	if u.User == nil {
+		// The legacy form, which encodes "method:password@address:port" as a
+		// whole.
+		data, err := decodeBase64(u.Host)
+		if err != nil {
+			return nil, err
+		}
+		legacy, err := url.Parse("ss://" + string(data))
+		if err != nil || legacy.User == nil {
+			return nil, errors.New("invalid Shadowsocks share link")
+		}
+		u.User, u.Host = legacy.User, legacy.Host
+	}
+	address, port, err := server(u, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	method := u.User.Username()
+	password, found := u.User.Password()
+	if !found {
+		// SIP002 encodes the user info in base64, except for the 2022
+		// methods.
+		data, err := decodeBase64(method)
+		if err != nil {
+			return nil, err
+		}
+		method, password, found = strings.Cut(string(data), ":")
+		if !found {
+			return nil, errors.New("Shadowsocks password is missing in share link")
+		}
+	}
+
+	query := u.Query()
+	if query.Get("plugin") != "" {
+		return nil, errors.New("Shadowsocks plugins in share link are not supported")
+	}
+	settings := object{
+		"address":  address,
+		"port":     port,
+		"method":   method,
+		"password": password,
+	}
+	var stream object
+	if query.Get("type") != "" || query.Get("security") != "" {
+		if stream, err = parseStream(query, "none"); err != nil {
+			return nil, err
+		}
+	}
+	return newOutbound("shadowsocks", u.Fragment, settings, stream), nil
+}
+
+func formatShadowsocks(outbound *conf.OutboundDetourConfig) (string, error) {
+	settings := new(conf.ShadowsocksClientConfig)
+	if err := decodeSettings(outbound, settings); err != nil {
+		return "", err
+	}
+	address, port, method, password := settings.Address, settings.Port, settings.Cipher, settings.Password
+	if address == nil && len(settings.Servers) > 0 {
+		if err := singleServer("Shadowsocks", len(settings.Servers), 1); err != nil {
+			return "", err
+		}
+		s := settings.Servers[0]
+		address, port, method, password = s.Address, s.Port, s.Cipher, s.Password
+	}
+
+	user := url.User(base64.RawURLEncoding.EncodeToString([]byte(method + ":" + password)))
+	if strings.HasPrefix(method, "2022-") {
+		user = url.UserPassword(method, password)
+	}
+	query := url.Values{}
+	if outbound.StreamSetting != nil {
+		if err := formatStream(outbound.StreamSetting, query); err != nil {
+			return "", err
+		}
+	}
+	return formatURL("ss", user, address, port, query, outbound.Tag)
+}
+
+func parseHysteria(u *url.URL) (object, error) {
+	address, port, err := server(u, 443)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	if isTrue(query.Get("insecure")) && query.Get("pinSHA256") == "" {
+		return nil, errors.New(`"insecure" in share link is removed, use "pinSHA256" instead`)
+	}
+
+	hysteria := object{"version": 2}
+	if u.User != nil {
+		auth := u.User.Username()
+		if password, found := u.User.Password(); found {
+			auth += ":" + password
+		}
+		hysteria.set("auth", auth)
+	}
+	tls := object{}
+	tls.set("serverName", query.Get("sni"))
+	if alpn := splitList(query.Get("alpn")); alpn != nil {
+		tls["alpn"] = alpn
+	}
+	tls.set("pinnedPeerCertSha256", query.Get("pinSHA256"))
+	stream := object{
+		"network":          "hysteria",
+		"hysteriaSettings": hysteria,
+		"security":         "tls",
+		"tlsSettings":      tls,
+	}
+	switch obfs := query.Get("obfs"); obfs {
+	case "":
+	case "salamander":
+		stream["finalmask"] = object{
+			"udp": []object{{
+				"type":     "salamander",
+				"settings": object{"password": query.Get("obfs-password")},
+			}},
+		}
+	default:
+		return nil, errors.New("unsupported Hysteria obfs in share link: ", obfs)
+	}
+
+	settings := object{
+		"version": 2,
+		"address": address,
+		"port":    port,
+	}
+	return newOutbound("hysteria", u.Fragment, settings, stream), nil
+}
+
+func formatHysteria(outbound *conf.OutboundDetourConfig) (string, error) {
+	settings := new(conf.HysteriaClientConfig)
+	if err := decodeSettings(outbound, settings); err != nil {
+		return "", err
+	}
+	stream := outbound.StreamSetting
+	if stream == nil {
+		stream = new(conf.StreamConfig)
+	}
+
+	query := url.Values{}
+	var user *url.Userinfo
+	if stream.HysteriaSettings != nil && stream.HysteriaSettings.Auth != "" {
+		user = url.User(stream.HysteriaSettings.Auth)
+	}
+	if tls := stream.TLSSettings; tls != nil {
+		if tls.ServerName != "" {
+			query.Set("sni", tls.ServerName)
+		}
+		if tls.ALPN != nil {
+			query.Set("alpn", strings.Join(*tls.ALPN, ","))
+		}
+		if tls.PinnedPeerCertSha256 != "" {
+			query.Set("insecure", "1")
+			query.Set("pinSHA256", tls.PinnedPeerCertSha256)
+		}
+	}
+	if stream.FinalMask != nil {
+		for _, mask := range stream.FinalMask.Udp {
+			if mask.Type != "salamander" {
+				return "", errors.New("finalmask ", mask.Type, " has no Hysteria share link")
+			}
+			salamander := new(conf.Salamander)
+			if mask.Settings != nil {
+				if err := json.Unmarshal(*mask.Settings, salamander); err != nil {
+					return "", errors.New("invalid salamander settings").Base(err)
+				}
+			}
+			query.Set("obfs", "salamander")
+			query.Set("obfs-password", salamander.Password)
+		}
+	}
+	return formatURL("hysteria2", user, settings.Address, settings.Port, query, outbound.Tag)
+}
+
+// parseAccount returns the username and password of u, which some clients
+// encode in base64.
+func parseAccount(u *url.URL) (string, string) {
+	if u.User == nil {
+		return "", ""
+	}
+	username := u.User.Username()
+	password, found := u.User.Password()
+	if !found {
+		if data, err := decodeBase64(username); err == nil {
+			if decoded, password, found := strings.Cut(string(data), ":"); found {
+				return decoded, password
+			}
+		}
+	}
+	return username, password
+}
+
+func parseSocks(u *url.URL) (object, error) {
+	address, port, err := server(u, 1080)
+	if err != nil {
+		return nil, err
+	}
+	settings := object{
+		"address": address,
+		"port":    port,
+	}
+	username, password := parseAccount(u)
+	settings.set("user", username)
+	settings.set("pass", password)
+	return newOutbound("socks", u.Fragment, settings, nil), nil
+}
+
+func formatSocks(outbound *conf.OutboundDetourConfig) (string, error) {
+	settings := new(conf.SocksClientConfig)
+	if err := decodeSettings(outbound, settings); err != nil {
+		return "", err
+	}
+	address, port := settings.Address, settings.Port
+	account := &conf.SocksAccount{Username: settings.Username, Password: settings.Password}
+	if address == nil && len(settings.Servers) > 0 {
+		if err := singleServer("SOCKS", len(settings.Servers), max(len(settings.Servers[0].Users), 1)); err != nil {
+			return "", err
+		}
+		address, port = settings.Servers[0].Address, settings.Servers[0].Port
+		if len(settings.Servers[0].Users) > 0 {
+			if err := json.Unmarshal(settings.Servers[0].Users[0], account); err != nil {
+				return "", errors.New("invalid SOCKS user").Base(err)
+			}
+		}
+	}
+
+	var user *url.Userinfo
+	if account.Username != "" {
+		user = url.UserPassword(account.Username, account.Password)
+	}
+	return formatURL("socks", user, address, port, nil, outbound.Tag)
+}
+
+func parseHTTP(u *url.URL) (object, error) {
+	defaultPort := uint16(80)
+	if u.Scheme == "https" {
+		defaultPort = 443
+	}
+	address, port, err := server(u, defaultPort)
+	if err != nil {
+		return nil, err
+	}
+	settings := object{
+		"address": address,
+		"port":    port,
+	}
+	username, password := parseAccount(u)
+	settings.set("user", username)
+	settings.set("pass", password)
+
+	var stream object
+	if u.Scheme == "https" {
+		tls := object{}
+		tls.set("serverName", u.Query().Get("sni"))
+		stream = object{
+			"security":    "tls",
+			"tlsSettings": tls,
+		}
+	}
+	return newOutbound("http", u.Fragment, settings, stream), nil
+}
+
+func formatHTTP(outbound *conf.OutboundDetourConfig) (string, error) {
+	settings := new(conf.HTTPClientConfig)
+	if err := decodeSettings(outbound, settings); err != nil {
+		return "", err
+	}
+	address, port := settings.Address, settings.Port
+	account := &conf.HTTPAccount{Username: settings.Username, Password: settings.Password}
+	if address == nil && len(settings.Servers) > 0 {
+		if err := singleServer("HTTP", len(settings.Servers), max(len(settings.Servers[0].Users), 1)); err != nil {
+			return "", err
+		}
+		address, port = settings.Servers[0].Address, settings.Servers[0].Port
+		if len(settings.Servers[0].Users) > 0 {
+			if err := json.Unmarshal(settings.Servers[0].Users[0], account); err != nil {
+				return "", errors.New("invalid HTTP user").Base(err)
+			}
+		}
+	}
+
+	scheme := "http"
+	query := url.Values{}
+	if stream := outbound.StreamSetting; stream != nil {
+		switch strings.ToLower(stream.Security) {
+		case "", "none":
+		case "tls":
+			scheme = "https"
+			if stream.TLSSettings != nil && stream.TLSSettings.ServerName != "" {
+				query.Set("sni", stream.TLSSettings.ServerName)
+			}
+		default:
+			return "", errors.New("security ", stream.Security, " has no HTTP share link")
+		}
+	}
+	var user *url.Userinfo
+	if account.Username != "" {
+		user = url.UserPassword(account.Username, account.Password)
+	}
+	return formatURL(scheme, user, address, port, query, outbound.Tag)
+}
+
+func parseWireGuard(u *url.URL) (object, error) {
+	address, port, err := server(u, 51820)
+	if err != nil {
+		return nil, err
+	}
+	if u.User == nil {
+		return nil, errors.New("WireGuard secret key is missing in share link")
+	}
+	query := u.Query()
+
+	peer := object{
+		"publicKey": query.Get("publickey"),
+		"endpoint":  net.JoinHostPort(address, strconv.Itoa(int(port))),
+	}
+	peer.set("preSharedKey", query.Get("presharedkey"))
+	if keepAlive := query.Get("keepalive"); keepAlive != "" {
+		value, err := strconv.ParseUint(keepAlive, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid WireGuard keepalive in share link: ", keepAlive)
+		}
+		peer["keepAlive"] = value
+	}
+	settings := object{
+		"secretKey": u.User.Username(),
+		"peers":     []object{peer},
+	}
+	if addresses := splitList(query.Get("address")); addresses != nil {
+		settings["address"] = addresses
+	}
+	if mtu := query.Get("mtu"); mtu != "" {
+		value, err := strconv.ParseInt(mtu, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid WireGuard MTU in share link: ", mtu)
+		}
+		settings["mtu"] = value
+	}
+	if reserved := splitList(query.Get("reserved")); reserved != nil {
+		// Not []byte, which would be encoded in base64.
+		values := make([]uint64, 0, len(reserved))
+		for _, r := range reserved {
+			value, err := strconv.ParseUint(strings.TrimSpace(r), 10, 8)
+			if err != nil {
+				return nil, errors.New("invalid WireGuard reserved in share link: ", r)
+			}
+			values = append(values, value)
+		}
+		settings["reserved"] = values
+	}
+	return newOutbound("wireguard", u.Fragment, settings, nil), nil
+}
+
+func formatWireGuard(outbound *conf.OutboundDetourConfig) (string, error) {
+	settings := new(conf.WireGuardConfig)
+	if err := decodeSettings(outbound, settings); err != nil {
+		return "", err
+	}
+	if len(settings.Peers) != 1 {
+		return "", errors.New("WireGuard settings should have one peer to be shared")
+	}
+	peer := settings.Peers[0]
+	host, portString, err := net.SplitHostPort(peer.Endpoint)
+	if err != nil {
+		return "", errors.New("invalid WireGuard endpoint: ", peer.Endpoint).Base(err)
+	}
+	port, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil {
+		return "", errors.New("invalid WireGuard endpoint: ", peer.Endpoint).Base(err)
+	}
+
+	query := url.Values{}
+	query.Set("publickey", peer.PublicKey)
+	if peer.PreSharedKey != "" {
+		query.Set("presharedkey", peer.PreSharedKey)
+	}
+	if peer.KeepAlive != 0 {
+		query.Set("keepalive", strconv.FormatUint(uint64(peer.KeepAlive), 10))
+	}
+	if len(settings.Address) > 0 {
+		query.Set("address", strings.Join(settings.Address, ","))
+	}
+	if settings.MTU != 0 {
+		query.Set("mtu", strconv.Itoa(int(settings.MTU)))
+	}
+	if len(settings.Reserved) > 0 {
+		reserved := make([]string, 0, len(settings.Reserved))
+		for _, r := range settings.Reserved {
+			reserved = append(reserved, strconv.Itoa(int(r)))
+		}
+		query.Set("reserved", strings.Join(reserved, ","))
+	}
+	address := &conf.Address{Address: xnet.ParseAddress(host)}
+	return formatURL("wireguard", url.User(settings.SecretKey), address, uint16(port), query, outbound.Tag)
+}
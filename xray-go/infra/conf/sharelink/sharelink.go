@@ -0,0 +1,202 @@
+// Package sharelink converts between share links, the URIs commonly used to
+// pass proxy servers between clients, and outbound configs.
+//
+// Only what a link can carry survives a conversion: the server and its
+// credentials, the transport with its host, path and mode, and TLS or REALITY
+// with their client settings. Other settings are dropped by Format.
+package sharelink
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+// object is a JSON object of config, holding only the settings given by a
+// link.
+type object map[string]interface{}
+
+// set sets key to value, unless value is empty.
+func (o object) set(key string, value string) {
+	if value != "" {
+		o[key] = value
+	}
+}
+
+// Parse converts link into an outbound config. The name of the link becomes
+// the tag of the outbound.
+func Parse(link string) (*conf.OutboundDetourConfig, error) {
+	data, err := ParseJSON(link)
+	if err != nil {
+		return nil, err
+	}
+	outbound := new(conf.OutboundDetourConfig)
+	if err := json.Unmarshal(data, outbound); err != nil {
+		return nil, errors.New("failed to decode outbound of share link").Base(err)
+	}
+	return outbound, nil
+}
+
+// ParseJSON converts link into the JSON of an outbound config, leaving out the
+// settings not given by the link.
+func ParseJSON(link string) ([]byte, error) {
+	outbound, err := parse(strings.TrimSpace(link))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(outbound)
+}
+
+func parse(link string) (object, error) {
+	scheme, rest, found := strings.Cut(link, "://")
+	if !found {
+		return nil, errors.New("invalid share link: ", link)
+	}
+	scheme = strings.ToLower(scheme)
+	if scheme == "vmess" {
+		return parseVMess(rest)
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, errors.New("invalid share link").Base(err)
+	}
+	switch scheme {
+	case "vless":
+		return parseVLESS(u)
+	case "trojan":
+		return parseTrojan(u)
+	case "ss":
+		return parseShadowsocks(u)
+	case "hysteria2", "hy2":
+		return parseHysteria(u)
+	case "socks", "socks5":
+		return parseSocks(u)
+	case "http", "https":
+		return parseHTTP(u)
+	case "wireguard", "wg":
+		return parseWireGuard(u)
+	}
+	return nil, errors.New("unsupported share link scheme: ", scheme)
+}
+
+// Format converts outbound into a share link. The tag of the outbound becomes
+// the name of the link.
+func Format(outbound *conf.OutboundDetourConfig) (string, error) {
+	switch strings.ToLower(outbound.Protocol) {
+	case "vless":
+		return formatVLESS(outbound)
+	case "vmess":
+		return formatVMess(outbound)
+	case "trojan":
+		return formatTrojan(outbound)
+	case "shadowsocks":
+		return formatShadowsocks(outbound)
+	case "hysteria":
+		return formatHysteria(outbound)
+	case "socks":
+		return formatSocks(outbound)
+	case "http":
+		return formatHTTP(outbound)
+	case "wireguard":
+		return formatWireGuard(outbound)
+	}
+	return "", errors.New("protocol ", outbound.Protocol, " has no share link")
+}
+
+func newOutbound(protocol string, tag string, settings object, stream object) object {
+	outbound := object{
+		"protocol": protocol,
+		"settings": settings,
+	}
+	outbound.set("tag", tag)
+	if len(stream) > 0 {
+		outbound["streamSettings"] = stream
+	}
+	return outbound
+}
+
+// server returns the address and port of u, or defaultPort if u has no port
+// and defaultPort is not 0.
+func server(u *url.URL, defaultPort uint16) (string, uint16, error) {
+	address := u.Hostname()
+	if address == "" {
+		return "", 0, errors.New("server address is missing in share link")
+	}
+	if u.Port() == "" {
+		if defaultPort == 0 {
+			return "", 0, errors.New("server port is missing in share link")
+		}
+		return address, defaultPort, nil
+	}
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	if err != nil {
+		return "", 0, errors.New("invalid server port in share link: ", u.Port())
+	}
+	return address, uint16(port), nil
+}
+
+func decodeSettings(outbound *conf.OutboundDetourConfig, settings interface{}) error {
+	if outbound.Settings == nil {
+		return nil
+	}
+	if err := json.Unmarshal(*outbound.Settings, settings); err != nil {
+		return errors.New("invalid ", outbound.Protocol, " settings").Base(err)
+	}
+	return nil
+}
+
+func formatURL(scheme string, user *url.Userinfo, address *conf.Address, port uint16, query url.Values, tag string) (string, error) {
+	if address == nil {
+		return "", errors.New("server address is not set")
+	}
+	host := address.String()
+	if !address.Family().IsDomain() {
+		host = address.IP().String()
+	}
+	u := &url.URL{
+		Scheme:   scheme,
+		User:     user,
+		Host:     net.JoinHostPort(host, strconv.Itoa(int(port))),
+		RawQuery: query.Encode(),
+		Fragment: tag,
+	}
+	return u.String(), nil
+}
+
+// singleServer checks that settings of the outbound give one server with one
+// user, as a link holds only one.
+func singleServer(protocol string, servers int, users int) error {
+	if servers != 1 || users != 1 {
+		return errors.New(protocol, " settings should have one server with one user to be shared")
+	}
+	return nil
+}
+
+// decodeBase64 decodes s in any of the base64 encodings found in links.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := encoding.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, errors.New("invalid base64 in share link: ", s)
+}
+
+func isTrue(value string) bool {
+	return value == "1" || strings.EqualFold(value, "true")
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,231 @@
+package sharelink
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+// httpHeader is the part of the HTTP header of raw transport carried by links.
+type httpHeader struct {
+	Type    string `json:"type"`
+	Request *struct {
+		Path    *conf.StringList            `json:"path"`
+		Headers map[string]*conf.StringList `json:"headers"`
+	} `json:"request"`
+}
+
+// parseStream converts the transport and security parameters of a link into
+// stream settings. security is used if the link does not give one.
+func parseStream(query url.Values, security string) (object, error) {
+	stream := object{}
+	host, path := query.Get("host"), query.Get("path")
+
+	network := strings.ToLower(query.Get("type"))
+	switch network {
+	case "", "raw", "tcp":
+		network = "raw"
+		if query.Get("headerType") == "http" {
+			request := object{"path": []string{"/"}}
+			if path != "" {
+				request["path"] = splitList(path)
+			}
+			if host != "" {
+				request["headers"] = object{"Host": splitList(host)}
+			}
+			stream["rawSettings"] = object{"header": object{"type": "http", "request": request}}
+		}
+	case "ws", "websocket":
+		network = "ws"
+		ws := object{}
+		ws.set("host", host)
+		ws.set("path", path)
+		stream["wsSettings"] = ws
+	case "httpupgrade":
+		httpUpgrade := object{}
+		httpUpgrade.set("host", host)
+		httpUpgrade.set("path", path)
+		stream["httpupgradeSettings"] = httpUpgrade
+	case "xhttp", "splithttp":
+		network = "xhttp"
+		xhttp := object{}
+		xhttp.set("host", host)
+		xhttp.set("path", path)
+		xhttp.set("mode", query.Get("mode"))
+		if extra := query.Get("extra"); extra != "" {
+			if !json.Valid([]byte(extra)) {
+				return nil, errors.New("invalid xhttp extra in share link: ", extra)
+			}
+			xhttp["extra"] = json.RawMessage(extra)
+		}
+		stream["xhttpSettings"] = xhttp
+	case "grpc":
+		grpc := object{}
+		grpc.set("serviceName", query.Get("serviceName"))
+		grpc.set("authority", query.Get("authority"))
+		if query.Get("mode") == "multi" {
+			grpc["multiMode"] = true
+		}
+		stream["grpcSettings"] = grpc
+	case "kcp", "mkcp":
+		network = "kcp"
+	default:
+		return nil, errors.New("unsupported transport in share link: ", network)
+	}
+	stream["network"] = network
+
+	if s := query.Get("security"); s != "" {
+		security = strings.ToLower(s)
+	}
+	switch security {
+	case "", "none":
+	case "tls":
+		if isTrue(query.Get("allowInsecure")) || isTrue(query.Get("insecure")) {
+			return nil, errors.New(`"allowInsecure" in share link is removed, use "pcs" or "vcn" instead`)
+		}
+		tls := object{}
+		tls.set("serverName", query.Get("sni"))
+		tls.set("fingerprint", query.Get("fp"))
+		if alpn := splitList(query.Get("alpn")); alpn != nil {
+			tls["alpn"] = alpn
+		}
+		tls.set("pinnedPeerCertSha256", query.Get("pcs"))
+		tls.set("verifyPeerCertByName", query.Get("vcn"))
+		tls.set("echConfigList", query.Get("ech"))
+		stream["security"] = "tls"
+		stream["tlsSettings"] = tls
+	case "reality":
+		reality := object{}
+		reality.set("serverName", query.Get("sni"))
+		reality.set("fingerprint", query.Get("fp"))
+		reality.set("publicKey", query.Get("pbk"))
+		reality.set("shortId", query.Get("sid"))
+		reality.set("spiderX", query.Get("spx"))
+		reality.set("mldsa65Verify", query.Get("pqv"))
+		stream["security"] = "reality"
+		stream["realitySettings"] = reality
+	default:
+		return nil, errors.New("unsupported security in share link: ", security)
+	}
+	return stream, nil
+}
+
+// formatStream adds the transport and security parameters of stream to query.
+func formatStream(stream *conf.StreamConfig, query url.Values) error {
+	if stream == nil {
+		stream = new(conf.StreamConfig)
+	}
+	set := func(key string, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	network := "tcp"
+	if stream.Network != nil {
+		network = strings.ToLower(string(*stream.Network))
+	}
+	switch network {
+	case "raw", "tcp":
+		network = "tcp"
+		settings := stream.RAWSettings
+		if settings == nil {
+			settings = stream.TCPSettings
+		}
+		if settings != nil && len(settings.HeaderConfig) > 0 {
+			header := new(httpHeader)
+			if err := json.Unmarshal(settings.HeaderConfig, header); err != nil {
+				return errors.New("invalid raw header").Base(err)
+			}
+			if header.Type == "http" {
+				query.Set("headerType", "http")
+				if request := header.Request; request != nil {
+					if request.Path != nil {
+						set("path", strings.Join(*request.Path, ","))
+					}
+					for name, values := range request.Headers {
+						if strings.EqualFold(name, "Host") && values != nil {
+							set("host", strings.Join(*values, ","))
+						}
+					}
+				}
+			}
+		}
+	case "ws", "websocket":
+		network = "ws"
+		if settings := stream.WSSettings; settings != nil {
+			set("host", settings.Host)
+			set("path", settings.Path)
+		}
+	case "httpupgrade":
+		if settings := stream.HTTPUPGRADESettings; settings != nil {
+			set("host", settings.Host)
+			set("path", settings.Path)
+		}
+	case "xhttp", "splithttp":
+		network = "xhttp"
+		settings := stream.XHTTPSettings
+		if settings == nil {
+			settings = stream.SplitHTTPSettings
+		}
+		if settings != nil {
+			set("host", settings.Host)
+			set("path", settings.Path)
+			set("mode", settings.Mode)
+			if len(settings.Extra) > 0 {
+				extra := new(bytes.Buffer)
+				if err := json.Compact(extra, settings.Extra); err != nil {
+					return errors.New("invalid xhttp extra").Base(err)
+				}
+				query.Set("extra", extra.String())
+			}
+		}
+	case "grpc":
+		if settings := stream.GRPCSettings; settings != nil {
+			set("serviceName", settings.ServiceName)
+			set("authority", settings.Authority)
+			if settings.MultiMode {
+				query.Set("mode", "multi")
+			}
+		}
+	case "kcp", "mkcp":
+		network = "kcp"
+	default:
+		return errors.New("transport ", network, " has no share link")
+	}
+	query.Set("type", network)
+
+	switch security := strings.ToLower(stream.Security); security {
+	case "", "none":
+		query.Set("security", "none")
+	case "tls":
+		query.Set("security", "tls")
+		if settings := stream.TLSSettings; settings != nil {
+			set("sni", settings.ServerName)
+			set("fp", settings.Fingerprint)
+			if settings.ALPN != nil {
+				set("alpn", strings.Join(*settings.ALPN, ","))
+			}
+			set("pcs", settings.PinnedPeerCertSha256)
+			set("vcn", settings.VerifyPeerCertByName)
+			set("ech", settings.ECHConfigList)
+		}
+	case "reality":
+		query.Set("security", "reality")
+		if settings := stream.REALITYSettings; settings != nil {
+			set("sni", settings.ServerName)
+			set("fp", settings.Fingerprint)
+			set("pbk", settings.PublicKey)
+			set("sid", settings.ShortId)
+			set("spx", settings.SpiderX)
+			set("pqv", settings.Mldsa65Verify)
+		}
+	default:
+		return errors.New("security ", security, " has no share link")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package conf
+
+import (
+	"net/url"
+
+	"github.com/robfig/cron/v3"
+	"github.com/xtls/xray-core/app/subscription"
+	"github.com/xtls/xray-core/common/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+type SubscriptionSourceConfig struct {
+	URL       string `json:"url"`
+	TagPrefix string `json:"tagPrefix"`
+}
+
+func (c *SubscriptionSourceConfig) Build() (*subscription.Source, error) {
+	u, err := url.ParseRequestURI(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("invalid subscription url: ", c.URL)
+	}
+	if c.TagPrefix == "" {
+		return nil, errors.New("empty tag prefix of subscription ", c.URL)
+	}
+	return &subscription.Source{
+		Url:       c.URL,
+		TagPrefix: c.TagPrefix,
+	}, nil
+}
+
+type SubscriptionConfig struct {
+	Cron     *string                     `json:"cron"`
+	Outbound string                      `json:"outbound"`
+	Sources  []*SubscriptionSourceConfig `json:"sources"`
+}
+
+func (c *SubscriptionConfig) Build() (proto.Message, error) {
+	config := &subscription.Config{}
+
+	if c.Cron != nil {
+		if _, err := cron.ParseStandard(*c.Cron); err != nil {
+			return nil, errors.New("invalid subscription cron").Base(err)
+		}
+		config.Cron = *c.Cron
+	}
+
+	config.Outbound = c.Outbound
+
+	sources := make([]*subscription.Source, 0, len(c.Sources))
+	for _, source := range c.Sources {
+		built, err := source.Build()
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range sources {
+			if other.TagPrefix == built.TagPrefix {
+				return nil, errors.New("duplicated tag prefix of subscription: ", built.TagPrefix)
+			}
+		}
+		sources = append(sources, built)
+	}
+	config.Sources = sources
+
+	return config, nil
+}
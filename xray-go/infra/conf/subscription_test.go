@@ -0,0 +1,51 @@
+package conf_test
+
+import (
+	"testing"
+
+	"github.com/xtls/xray-core/app/subscription"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestSubscriptionConfig(t *testing.T) {
+	creator := func() Buildable {
+		return new(SubscriptionConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"cron": "0 */6 * * *",
+				"outbound": "direct",
+				"sources": [
+					{"url": "https://example.com/sub", "tagPrefix": "a-"},
+					{"url": "http://127.0.0.1:8080/sub", "tagPrefix": "b-"}
+				]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &subscription.Config{
+				Cron:     "0 */6 * * *",
+				Outbound: "direct",
+				Sources: []*subscription.Source{
+					{Url: "https://example.com/sub", TagPrefix: "a-"},
+					{Url: "http://127.0.0.1:8080/sub", TagPrefix: "b-"},
+				},
+			},
+		},
+	})
+}
+
+func TestSubscriptionConfigInvalid(t *testing.T) {
+	for _, input := range []*SubscriptionConfig{
+		{Sources: []*SubscriptionSourceConfig{{URL: "ftp://example.com/sub", TagPrefix: "a-"}}},
+		{Sources: []*SubscriptionSourceConfig{{URL: "https://example.com/sub"}}},
+		{Sources: []*SubscriptionSourceConfig{
+			{URL: "https://example.com/a", TagPrefix: "a-"},
+			{URL: "https://example.com/b", TagPrefix: "a-"},
+		}},
+	} {
+		if _, err := input.Build(); err == nil {
+			t.Error("expected error for ", input)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package conf
+
+import (
+	"net/url"
+
+	"github.com/xtls/xray-core/app/tracing"
+	"github.com/xtls/xray-core/common/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+type TracingConfig struct {
+	File        string `json:"file"`
+	Collector   string `json:"collector"`
+	ServiceName string `json:"serviceName"`
+	Interval    uint32 `json:"interval"`
+}
+
+func (c *TracingConfig) Build() (proto.Message, error) {
+	if c.File == "" && c.Collector == "" {
+		return nil, errors.New("tracing must have a file or a collector")
+	}
+	if c.Collector != "" {
+		u, err := url.ParseRequestURI(c.Collector)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.New("invalid tracing collector: ", c.Collector)
+		}
+	}
+	return &tracing.Config{
+		File:        c.File,
+		Collector:   c.Collector,
+		ServiceName: c.ServiceName,
+		Interval:    c.Interval,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package conf_test
+
+import (
+	"testing"
+
+	"github.com/xtls/xray-core/app/tracing"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestTracingConfig(t *testing.T) {
+	creator := func() Buildable {
+		return new(TracingConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"file": "spans.json",
+				"collector": "http://127.0.0.1:4318/v1/traces",
+				"serviceName": "edge",
+				"interval": 10
+			}`,
+			Parser: loadJSON(creator),
+			Output: &tracing.Config{
+				File:        "spans.json",
+				Collector:   "http://127.0.0.1:4318/v1/traces",
+				ServiceName: "edge",
+				Interval:    10,
+			},
+		},
+	})
+}
+
+func TestTracingConfigInvalid(t *testing.T) {
+	for _, input := range []*TracingConfig{
+		{},
+		{Collector: "127.0.0.1:4318"},
+		{Collector: "grpc://127.0.0.1:4317"},
+	} {
+		if _, err := input.Build(); err == nil {
+			t.Error("expected error for ", input)
+		}
+	}
+}
@@ -922,7 +922,7 @@ func (c *Mask) Build(tcp bool) (proto.Message, error) {
 	}
 	ts, err := rawConfig.(Buildable).Build()
 	if err != nil {
-		return nil, err
+		return nil, withField("settings", err)
 	}
 	return ts, nil
 }
@@ -71,13 +71,15 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 	if c.Address != nil {
 		config.Address = c.Address.Build()
 	}
+	networkField := "network"
 	if c.Method != nil {
 		c.Network = c.Method
+		networkField = "method"
 	}
 	if c.Network != nil {
 		protocol, err := c.Network.Build()
 		if err != nil {
-			return nil, err
+			return nil, withField(networkField, err)
 		}
 		config.ProtocolName = protocol
 	}
@@ -91,51 +93,55 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 		}
 		ts, err := tlsSettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build TLS config.").Base(err)
+			return nil, errors.New("Failed to build TLS config.").Base(withField("tlsSettings", err))
 		}
 		tm := serial.ToTypedMessage(ts)
 		config.SecuritySettings = append(config.SecuritySettings, tm)
 		config.SecurityType = tm.Type
 	case "reality":
 		if config.ProtocolName != "tcp" && config.ProtocolName != "splithttp" && config.ProtocolName != "grpc" {
-			return nil, errors.New("REALITY only supports RAW, XHTTP and gRPC for now.")
+			return nil, withField(networkField, errors.New("REALITY only supports RAW, XHTTP and gRPC for now."))
 		}
 		if c.REALITYSettings == nil {
-			return nil, errors.New(`REALITY: Empty "realitySettings".`)
+			return nil, withField("realitySettings", errors.New(`REALITY: Empty "realitySettings".`))
 		}
 		ts, err := c.REALITYSettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build REALITY config.").Base(err)
+			return nil, errors.New("Failed to build REALITY config.").Base(withField("realitySettings", err))
 		}
 		tm := serial.ToTypedMessage(ts)
 		config.SecuritySettings = append(config.SecuritySettings, tm)
 		config.SecurityType = tm.Type
 	case "xtls":
-		return nil, errors.PrintRemovedFeatureError(`Legacy XTLS`, `xtls-rprx-vision with TLS or REALITY`)
+		return nil, withField("security", errors.PrintRemovedFeatureError(`Legacy XTLS`, `xtls-rprx-vision with TLS or REALITY`))
 	default:
-		return nil, errors.New(`Unknown security "` + c.Security + `".`)
+		return nil, withField("security", errors.New(`Unknown security "`+c.Security+`".`))
 	}
 
+	tcpField := "tcpSettings"
 	if c.RAWSettings != nil {
 		c.TCPSettings = c.RAWSettings
+		tcpField = "rawSettings"
 	}
 	if c.TCPSettings != nil {
 		ts, err := c.TCPSettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build RAW config.").Base(err)
+			return nil, errors.New("Failed to build RAW config.").Base(withField(tcpField, err))
 		}
 		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
 			ProtocolName: "tcp",
 			Settings:     serial.ToTypedMessage(ts),
 		})
 	}
+	splitHTTPField := "splithttpSettings"
 	if c.XHTTPSettings != nil {
 		c.SplitHTTPSettings = c.XHTTPSettings
+		splitHTTPField = "xhttpSettings"
 	}
 	if c.SplitHTTPSettings != nil {
 		hs, err := c.SplitHTTPSettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build XHTTP config.").Base(err)
+			return nil, errors.New("Failed to build XHTTP config.").Base(withField(splitHTTPField, err))
 		}
 		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
 			ProtocolName: "splithttp",
@@ -145,7 +151,7 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 	if c.KCPSettings != nil {
 		ts, err := c.KCPSettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build mKCP config.").Base(err)
+			return nil, errors.New("Failed to build mKCP config.").Base(withField("kcpSettings", err))
 		}
 		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
 			ProtocolName: "mkcp",
@@ -155,7 +161,7 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 	if c.GRPCSettings != nil {
 		gs, err := c.GRPCSettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build gRPC config.").Base(err)
+			return nil, errors.New("Failed to build gRPC config.").Base(withField("grpcSettings", err))
 		}
 		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
 			ProtocolName: "grpc",
@@ -165,7 +171,7 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 	if c.WSSettings != nil {
 		ts, err := c.WSSettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build WebSocket config.").Base(err)
+			return nil, errors.New("Failed to build WebSocket config.").Base(withField("wsSettings", err))
 		}
 		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
 			ProtocolName: "websocket",
@@ -175,7 +181,7 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 	if c.HTTPUPGRADESettings != nil {
 		hs, err := c.HTTPUPGRADESettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build HTTPUpgrade config.").Base(err)
+			return nil, errors.New("Failed to build HTTPUpgrade config.").Base(withField("httpupgradeSettings", err))
 		}
 		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
 			ProtocolName: "httpupgrade",
@@ -185,7 +191,7 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 	if c.HysteriaSettings != nil {
 		hs, err := c.HysteriaSettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build Hysteria config.").Base(err)
+			return nil, errors.New("Failed to build Hysteria config.").Base(withField("hysteriaSettings", err))
 		}
 		config.TransportSettings = append(config.TransportSettings, &internet.TransportConfig{
 			ProtocolName: "hysteria",
@@ -195,23 +201,23 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 	if c.SocketSettings != nil {
 		ss, err := c.SocketSettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build sockopt.").Base(err)
+			return nil, errors.New("Failed to build sockopt.").Base(withField("sockopt", err))
 		}
 		config.SocketSettings = ss
 	}
 
 	if c.FinalMask != nil {
-		for _, mask := range c.FinalMask.Tcp {
+		for i, mask := range c.FinalMask.Tcp {
 			u, err := mask.Build(true)
 			if err != nil {
-				return nil, errors.New("failed to build mask with type ", mask.Type).Base(err)
+				return nil, errors.New("failed to build mask with type ", mask.Type).Base(withField("finalmask.tcp"+indexField(i), err))
 			}
 			config.Tcpmasks = append(config.Tcpmasks, serial.ToTypedMessage(u))
 		}
-		for _, mask := range c.FinalMask.Udp {
+		for i, mask := range c.FinalMask.Udp {
 			u, err := mask.Build(false)
 			if err != nil {
-				return nil, errors.New("failed to build mask with type ", mask.Type).Base(err)
+				return nil, errors.New("failed to build mask with type ", mask.Type).Base(withField("finalmask.udp"+indexField(i), err))
 			}
 			config.Udpmasks = append(config.Udpmasks, serial.ToTypedMessage(u))
 		}
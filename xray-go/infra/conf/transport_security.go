@@ -56,8 +56,10 @@ func (c *REALITYConfig) Build() (proto.Message, error) {
 	config.MasterKeyLog = c.MasterKeyLog
 	config.Show = c.Show
 	var err error
+	destField := "dest"
 	if c.Target != nil {
 		c.Dest = c.Target
+		destField = "target"
 	}
 	if c.Dest != nil {
 		var i uint16
@@ -86,29 +88,29 @@ func (c *REALITYConfig) Build() (proto.Message, error) {
 			}
 		}
 		if c.Type == "" {
-			return nil, errors.New(`please fill in a valid value for "target"`)
+			return nil, withField(destField, errors.New(`please fill in a valid value for "target"`))
 		}
 		if c.Xver > 2 {
-			return nil, errors.New(`invalid PROXY protocol version, "xver" only accepts 0, 1, 2`)
+			return nil, withField("xver", errors.New(`invalid PROXY protocol version, "xver" only accepts 0, 1, 2`))
 		}
 		if len(c.ServerNames) == 0 {
-			return nil, errors.New(`empty "serverNames"`)
+			return nil, withField("serverNames", errors.New(`empty "serverNames"`))
 		}
 		if c.PrivateKey == "" {
-			return nil, errors.New(`empty "privateKey"`)
+			return nil, withField("privateKey", errors.New(`empty "privateKey"`))
 		}
 		if config.PrivateKey, err = base64.RawURLEncoding.DecodeString(c.PrivateKey); err != nil || len(config.PrivateKey) != 32 {
-			return nil, errors.New(`invalid "privateKey": `, c.PrivateKey)
+			return nil, withField("privateKey", errors.New(`invalid "privateKey": `, c.PrivateKey))
 		}
 		if c.MinClientVer != "" {
 			config.MinClientVer = make([]byte, 3)
 			var u uint64
 			for i, s := range strings.Split(c.MinClientVer, ".") {
 				if i == 3 {
-					return nil, errors.New(`invalid "minClientVer": `, c.MinClientVer)
+					return nil, withField("minClientVer", errors.New(`invalid "minClientVer": `, c.MinClientVer))
 				}
 				if u, err = strconv.ParseUint(s, 10, 8); err != nil {
-					return nil, errors.New(`"minClientVer[`, i, `]" should be less than 256`)
+					return nil, withField("minClientVer", errors.New(`"minClientVer[`, i, `]" should be less than 256`))
 				} else {
 					config.MinClientVer[i] = byte(u)
 				}
@@ -123,26 +125,26 @@ func (c *REALITYConfig) Build() (proto.Message, error) {
 			var u uint64
 			for i, s := range strings.Split(c.MaxClientVer, ".") {
 				if i == 3 {
-					return nil, errors.New(`invalid "maxClientVer": `, c.MaxClientVer)
+					return nil, withField("maxClientVer", errors.New(`invalid "maxClientVer": `, c.MaxClientVer))
 				}
 				if u, err = strconv.ParseUint(s, 10, 8); err != nil {
-					return nil, errors.New(`"maxClientVer[`, i, `]" should be less than 256`)
+					return nil, withField("maxClientVer", errors.New(`"maxClientVer[`, i, `]" should be less than 256`))
 				} else {
 					config.MaxClientVer[i] = byte(u)
 				}
 			}
 		}
 		if len(c.ShortIds) == 0 {
-			return nil, errors.New(`empty "shortIds"`)
+			return nil, withField("shortIds", errors.New(`empty "shortIds"`))
 		}
 		config.ShortIds = make([][]byte, len(c.ShortIds))
 		for i, s := range c.ShortIds {
 			if len(s) > 16 {
-				return nil, errors.New(`too long "shortIds[`, i, `]": `, s)
+				return nil, withField("shortIds"+indexField(i), errors.New(`too long "shortIds[`, i, `]": `, s))
 			}
 			config.ShortIds[i] = make([]byte, 8)
 			if _, err = hex.Decode(config.ShortIds[i], []byte(s)); err != nil {
-				return nil, errors.New(`invalid "shortIds[`, i, `]": `, s)
+				return nil, withField("shortIds"+indexField(i), errors.New(`invalid "shortIds[`, i, `]": `, s))
 			}
 		}
 		config.Dest = s
@@ -153,10 +155,10 @@ func (c *REALITYConfig) Build() (proto.Message, error) {
 
 		if c.Mldsa65Seed != "" {
 			if c.Mldsa65Seed == c.PrivateKey {
-				return nil, errors.New(`"mldsa65Seed" and "privateKey" can not be the same value: `, c.Mldsa65Seed)
+				return nil, withField("mldsa65Seed", errors.New(`"mldsa65Seed" and "privateKey" can not be the same value: `, c.Mldsa65Seed))
 			}
 			if config.Mldsa65Seed, err = base64.RawURLEncoding.DecodeString(c.Mldsa65Seed); err != nil || len(config.Mldsa65Seed) != 32 {
-				return nil, errors.New(`invalid "mldsa65Seed": `, c.Mldsa65Seed)
+				return nil, withField("mldsa65Seed", errors.New(`invalid "mldsa65Seed": `, c.Mldsa65Seed))
 			}
 		}
 
@@ -179,43 +181,43 @@ func (c *REALITYConfig) Build() (proto.Message, error) {
 	} else {
 		config.Fingerprint = strings.ToLower(c.Fingerprint)
 		if config.Fingerprint == "unsafe" || config.Fingerprint == "hellogolang" {
-			return nil, errors.New(`invalid "fingerprint": `, config.Fingerprint)
+			return nil, withField("fingerprint", errors.New(`invalid "fingerprint": `, config.Fingerprint))
 		}
 		if tls.GetFingerprint(config.Fingerprint) == nil {
-			return nil, errors.New(`unknown "fingerprint": `, config.Fingerprint)
+			return nil, withField("fingerprint", errors.New(`unknown "fingerprint": `, config.Fingerprint))
 		}
 		if len(c.ServerNames) != 0 {
-			return nil, errors.New(`non-empty "serverNames", please use "serverName" instead`)
+			return nil, withField("serverNames", errors.New(`non-empty "serverNames", please use "serverName" instead`))
 		}
 		if c.Password != "" {
 			c.PublicKey = c.Password
 		}
 		if c.PublicKey == "" {
-			return nil, errors.New(`empty "password"`)
+			return nil, withField("password", errors.New(`empty "password"`))
 		}
 		if config.PublicKey, err = base64.RawURLEncoding.DecodeString(c.PublicKey); err != nil || len(config.PublicKey) != 32 {
-			return nil, errors.New(`invalid "password": `, c.PublicKey)
+			return nil, withField("password", errors.New(`invalid "password": `, c.PublicKey))
 		}
 		if len(c.ShortIds) != 0 {
-			return nil, errors.New(`non-empty "shortIds", please use "shortId" instead`)
+			return nil, withField("shortIds", errors.New(`non-empty "shortIds", please use "shortId" instead`))
 		}
 		if len(c.ShortId) > 16 {
-			return nil, errors.New(`too long "shortId": `, c.ShortId)
+			return nil, withField("shortId", errors.New(`too long "shortId": `, c.ShortId))
 		}
 		config.ShortId = make([]byte, 8)
 		if _, err = hex.Decode(config.ShortId, []byte(c.ShortId)); err != nil {
-			return nil, errors.New(`invalid "shortId": `, c.ShortId)
+			return nil, withField("shortId", errors.New(`invalid "shortId": `, c.ShortId))
 		}
 		if c.Mldsa65Verify != "" {
 			if config.Mldsa65Verify, err = base64.RawURLEncoding.DecodeString(c.Mldsa65Verify); err != nil || len(config.Mldsa65Verify) != 1952 {
-				return nil, errors.New(`invalid "mldsa65Verify": `, c.Mldsa65Verify)
+				return nil, withField("mldsa65Verify", errors.New(`invalid "mldsa65Verify": `, c.Mldsa65Verify))
 			}
 		}
 		if c.SpiderX == "" {
 			c.SpiderX = "/"
 		}
 		if c.SpiderX[0] != '/' {
-			return nil, errors.New(`invalid "spiderX": `, c.SpiderX)
+			return nil, withField("spiderX", errors.New(`invalid "spiderX": `, c.SpiderX))
 		}
 		config.SpiderY = make([]int64, 10)
 		u, _ := url.Parse(c.SpiderX)
@@ -325,7 +327,7 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 	for idx, certConf := range c.Certs {
 		cert, err := certConf.Build()
 		if err != nil {
-			return nil, err
+			return nil, withField("certificates"+indexField(idx), err)
 		}
 		config.Certificate[idx] = cert
 	}
@@ -339,7 +341,7 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 	if len(config.NextProtocol) > 1 {
 		for _, p := range config.NextProtocol {
 			if tls.IsFromMitm(p) {
-				return nil, errors.New(`only one element is allowed in "alpn" when using "fromMitm" in it`)
+				return nil, withField("alpn", errors.New(`only one element is allowed in "alpn" when using "fromMitm" in it`))
 			}
 		}
 	}
@@ -353,13 +355,13 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 	config.CipherSuites = c.CipherSuites
 	config.Fingerprint = strings.ToLower(c.Fingerprint)
 	if config.Fingerprint != "unsafe" && tls.GetFingerprint(config.Fingerprint) == nil {
-		return nil, errors.New(`unknown "fingerprint": `, config.Fingerprint)
+		return nil, withField("fingerprint", errors.New(`unknown "fingerprint": `, config.Fingerprint))
 	}
 	config.RejectUnknownSni = c.RejectUnknownSNI
 	config.MasterKeyLog = c.MasterKeyLog
 
 	if c.AllowInsecure {
-		return nil, errors.PrintRemovedFeatureError(`"allowInsecure"`, `"pinnedPeerCertSha256"(pcs) and "verifyPeerCertByName"(vcn)`)
+		return nil, withField("allowInsecure", errors.PrintRemovedFeatureError(`"allowInsecure"`, `"pinnedPeerCertSha256"(pcs) and "verifyPeerCertByName"(vcn)`))
 	}
 	if c.PinnedPeerCertSha256 != "" {
 		for v := range strings.SplitSeq(c.PinnedPeerCertSha256, ",") {
@@ -370,10 +372,10 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 			// remove colons for OpenSSL format
 			hashValue, err := hex.DecodeString(strings.ReplaceAll(v, ":", ""))
 			if err != nil {
-				return nil, err
+				return nil, withField("pinnedPeerCertSha256", err)
 			}
 			if len(hashValue) != 32 {
-				return nil, errors.New("incorrect pinnedPeerCertSha256 length: ", v)
+				return nil, withField("pinnedPeerCertSha256", errors.New("incorrect pinnedPeerCertSha256 length: ", v))
 			}
 			config.PinnedPeerCertSha256 = append(config.PinnedPeerCertSha256, hashValue)
 		}
@@ -391,7 +393,7 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 	if c.ECHServerKeys != "" {
 		EchPrivateKey, err := base64.StdEncoding.DecodeString(c.ECHServerKeys)
 		if err != nil {
-			return nil, errors.New("invalid ECH Config", c.ECHServerKeys)
+			return nil, withField("echServerKeys", errors.New("invalid ECH Config", c.ECHServerKeys))
 		}
 		config.EchServerKeys = EchPrivateKey
 	}
@@ -399,7 +401,7 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 	if c.ECHSocketSettings != nil {
 		ss, err := c.ECHSocketSettings.Build()
 		if err != nil {
-			return nil, errors.New("Failed to build ech sockopt.").Base(err)
+			return nil, errors.New("Failed to build ech sockopt.").Base(withField("echSockopt", err))
 		}
 		config.EchSocketSettings = ss
 	}
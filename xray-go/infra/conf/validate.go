@@ -0,0 +1,343 @@
+package conf
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/serial"
+)
+
+const (
+	IssueSeverityError   = "error"
+	IssueSeverityWarning = "warning"
+)
+
+// ConfigIssue is a problem found in a config by Validate. Path is the JSON path
+// of the field it comes from, such as
+// "outbounds[3].streamSettings.tlsSettings.fingerprint", or empty if the
+// problem is not specific to a field.
+type ConfigIssue struct {
+	Path     string `json:"path"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// fieldError marks err as coming from a field of the config being built. It
+// only records the location for Validate and does not change the message.
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (e *fieldError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+func (e *fieldError) Severity() log.Severity {
+	return errors.GetSeverity(e.err)
+}
+
+// withField records that err comes from field, a JSON key or an index such as
+// "[2]", or a path of them, relative to the config being built.
+func withField(field string, err error) error {
+	return &fieldError{field: field, err: err}
+}
+
+func indexField(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
+
+func joinPath(path, field string) string {
+	if len(path) == 0 || strings.HasPrefix(field, "[") {
+		return path + field
+	}
+	return path + "." + field
+}
+
+// jsonFieldPath converts the dotted field path of a JSON decoding error, such
+// as "clients.0.id", into the form used by ConfigIssue.
+func jsonFieldPath(field string) string {
+	var path string
+	for _, f := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(f); err == nil {
+			f = "[" + f + "]"
+		}
+		path = joinPath(path, f)
+	}
+	return path
+}
+
+// errorPath appends the fields recorded along the chain of err to path.
+func errorPath(path string, err error) string {
+	for err != nil {
+		switch e := err.(type) {
+		case *fieldError:
+			path = joinPath(path, e.field)
+		case *json.UnmarshalTypeError:
+			if len(e.Field) > 0 {
+				path = joinPath(path, jsonFieldPath(e.Field))
+			}
+		}
+		inner, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = inner.Unwrap()
+	}
+	return path
+}
+
+// NewErrorIssue creates an issue of severity error for err, located by the
+// fields recorded in it.
+func NewErrorIssue(err error) *ConfigIssue {
+	return &ConfigIssue{
+		Path:     errorPath("", err),
+		Severity: IssueSeverityError,
+		Message:  err.Error(),
+	}
+}
+
+func errorOf[T any](_ T, err error) error {
+	return err
+}
+
+type validator struct {
+	issues []*ConfigIssue
+}
+
+func (v *validator) error(path string, err error) bool {
+	if err == nil {
+		return false
+	}
+	issue := NewErrorIssue(err)
+	issue.Path = errorPath(path, err)
+	v.issues = append(v.issues, issue)
+	return true
+}
+
+func (v *validator) warning(path string, msg ...interface{}) {
+	v.issues = append(v.issues, &ConfigIssue{
+		Path:     path,
+		Severity: IssueSeverityWarning,
+		Message:  serial.Concat(msg...),
+	})
+}
+
+// Validate checks the whole config the same way as Build, but instead of
+// stopping at the first problem it returns all of them. It also warns about
+// references to tags that are not defined in the config. The config must not
+// be used afterwards, as building it may change some fields.
+func (c *Config) Validate() []*ConfigIssue {
+	v := new(validator)
+
+	if len(c.Transport) > 0 {
+		v.error("transport", errors.PrintRemovedFeatureError("Global transport config", "streamSettings in inbounds and outbounds"))
+	}
+	if c.Reverse != nil {
+		v.error("reverse", errors.PrintRemovedFeatureError(`"legacy reverse"`, `"VLESS Reverse Proxy"`))
+	}
+	v.error("", PostProcessConfigureFile(c))
+
+	if c.LogConfig != nil {
+		v.error("log", errorOf(c.LogConfig.Build()))
+	}
+	if c.API != nil {
+		v.error("api", errorOf(c.API.Build()))
+	}
+	if c.Metrics != nil {
+		v.error("metrics", errorOf(c.Metrics.Build()))
+	}
+	if c.Policy != nil {
+		v.error("policy", errorOf(c.Policy.Build()))
+	}
+	if c.Stats != nil {
+		v.error("stats", errorOf(c.Stats.Build()))
+	}
+	if c.FakeDNS != nil {
+		v.error("fakeDns", errorOf(c.FakeDNS.Build()))
+	}
+	if c.Observatory != nil {
+		v.error("observatory", errorOf(c.Observatory.Build()))
+	}
+	if c.BurstObservatory != nil {
+		v.error("burstObservatory", errorOf(c.BurstObservatory.Build()))
+	}
+	if c.Version != nil {
+		v.error("version", errorOf(c.Version.Build()))
+	}
+	if c.Geodata != nil {
+		v.error("geodata", errorOf(c.Geodata.Build()))
+	}
+	if c.Subscription != nil {
+		v.error("subscription", errorOf(c.Subscription.Build()))
+	}
+	if c.Quota != nil {
+		v.error("quota", errorOf(c.Quota.Build()))
+	}
+	if c.Tracing != nil {
+		v.error("tracing", errorOf(c.Tracing.Build()))
+	}
+	if c.DNSConfig != nil {
+		v.validateDNS(c.DNSConfig)
+	}
+
+	inboundTags := make(map[string]bool)
+	for i := range c.InboundConfigs {
+		in := &c.InboundConfigs[i]
+		path := joinPath("inbounds", indexField(i))
+		if len(in.Tag) > 0 && inboundTags[in.Tag] {
+			v.error(joinPath(path, "tag"), errors.New("existing tag found: ", in.Tag))
+		}
+		inboundTags[in.Tag] = true
+		v.validateInbound(path, *in)
+	}
+
+	outboundTags := make(map[string]bool)
+	for i := range c.OutboundConfigs {
+		out := &c.OutboundConfigs[i]
+		path := joinPath("outbounds", indexField(i))
+		if len(out.Tag) > 0 && outboundTags[out.Tag] {
+			v.error(joinPath(path, "tag"), errors.New("existing tag found: ", out.Tag))
+		}
+		outboundTags[out.Tag] = true
+		v.validateOutbound(path, *out)
+	}
+	for i := range c.OutboundConfigs {
+		out := &c.OutboundConfigs[i]
+		path := joinPath("outbounds", indexField(i))
+		if out.ProxySettings != nil && len(out.ProxySettings.Tag) > 0 && !outboundTags[out.ProxySettings.Tag] {
+			v.warning(joinPath(path, "proxySettings.tag"), "outbound ", out.ProxySettings.Tag, " is not defined")
+		}
+		if out.StreamSetting != nil && out.StreamSetting.SocketSettings != nil {
+			if tag := out.StreamSetting.SocketSettings.DialerProxy; len(tag) > 0 && !outboundTags[tag] {
+				v.warning(joinPath(path, "streamSettings.sockopt.dialerProxy"), "outbound ", tag, " is not defined")
+			}
+		}
+	}
+
+	if c.RouterConfig != nil {
+		v.validateRouting(c.RouterConfig, outboundTags)
+	}
+
+	return v.issues
+}
+
+// validateInbound checks the parts of an inbound one by one, so that each of
+// them reports its own problem. Parts with problems are left out of the rest.
+func (v *validator) validateInbound(path string, c InboundDetourConfig) {
+	if c.StreamSetting != nil && !v.validateStream(joinPath(path, "streamSettings"), c.StreamSetting) {
+		c.StreamSetting = nil
+	}
+	if c.SniffingConfig != nil && v.error(joinPath(path, "sniffing"), errorOf(c.SniffingConfig.Build())) {
+		c.SniffingConfig = nil
+	}
+	v.error(path, errorOf(c.buildReceiverSettings()))
+	_, _, err := c.buildProxySettings()
+	v.error(path, err)
+}
+
+// validateOutbound checks the parts of an outbound one by one, like
+// validateInbound.
+func (v *validator) validateOutbound(path string, c OutboundDetourConfig) {
+	streamOK := c.StreamSetting == nil || v.validateStream(joinPath(path, "streamSettings"), c.StreamSetting)
+	if !streamOK {
+		c.StreamSetting = nil
+	}
+	if c.ProxySettings != nil && v.error(joinPath(path, "proxySettings"), errorOf(c.ProxySettings.Build())) {
+		c.ProxySettings = nil
+	}
+	if c.MuxSettings != nil && v.error(joinPath(path, "mux"), errorOf(c.MuxSettings.Build())) {
+		c.MuxSettings = nil
+	}
+	senderSettings, err := c.buildSenderSettings()
+	v.error(path, err)
+	rawConfig, _, err := c.buildProxySettings()
+	if !v.error(path, err) && senderSettings != nil && streamOK {
+		v.error(path, validateOutboundTransportSecurity(rawConfig, senderSettings))
+	}
+}
+
+// validateStream checks sockopt apart from the rest of a streamSettings, and
+// returns whether there is no problem in either.
+func (v *validator) validateStream(path string, c *StreamConfig) bool {
+	ok := true
+	rest := *c
+	if c.SocketSettings != nil {
+		ok = !v.error(joinPath(path, "sockopt"), errorOf(c.SocketSettings.Build()))
+		rest.SocketSettings = nil
+	}
+	return !v.error(path, errorOf(rest.Build())) && ok
+}
+
+func (v *validator) validateDNS(c *DNSConfig) {
+	for i, server := range c.Servers {
+		v.error(joinPath("dns.servers", indexField(i)), errorOf(server.Build()))
+	}
+	rest := *c
+	rest.Servers = nil
+	v.error("dns", errorOf(rest.Build()))
+}
+
+func (v *validator) validateRouting(c *RouterConfig, outboundTags map[string]bool) {
+	balancerTags := make(map[string]bool)
+	for i, balancer := range c.Balancers {
+		path := joinPath("routing.balancers", indexField(i))
+		if v.error(path, errorOf(balancer.Build())) {
+			continue
+		}
+		if balancerTags[balancer.Tag] {
+			v.error(joinPath(path, "tag"), errors.New("duplicate balancer tag: ", balancer.Tag))
+		}
+		balancerTags[balancer.Tag] = true
+
+		if len(balancer.FallbackTag) > 0 && !outboundTags[balancer.FallbackTag] {
+			v.warning(joinPath(path, "fallbackTag"), "outbound ", balancer.FallbackTag, " is not defined")
+		}
+		selected := false
+		for tag := range outboundTags {
+			for _, prefix := range balancer.Selectors {
+				if strings.HasPrefix(tag, prefix) {
+					selected = true
+				}
+			}
+		}
+		if !selected {
+			v.warning(joinPath(path, "selector"), "no outbound is selected")
+		}
+	}
+
+	ruleTags := make(map[string]bool)
+	for i, rawRule := range c.RuleList {
+		path := joinPath("routing.rules", indexField(i))
+		if v.error(path, errorOf(parseRule(rawRule))) {
+			continue
+		}
+		rule := new(RouterRule)
+		if err := json.Unmarshal(rawRule, rule); err != nil {
+			continue
+		}
+		if len(rule.RuleTag) > 0 {
+			if ruleTags[rule.RuleTag] {
+				v.error(joinPath(path, "ruleTag"), errors.New("duplicate ruleTag ", rule.RuleTag))
+			}
+			ruleTags[rule.RuleTag] = true
+		}
+		switch {
+		case len(rule.OutboundTag) > 0:
+			if !outboundTags[rule.OutboundTag] {
+				v.warning(joinPath(path, "outboundTag"), "outbound ", rule.OutboundTag, " is not defined")
+			}
+		case len(rule.BalancerTag) > 0:
+			if !balancerTags[rule.BalancerTag] {
+				v.error(joinPath(path, "balancerTag"), errors.New("balancer ", rule.BalancerTag, " not found"))
+			}
+		}
+	}
+}
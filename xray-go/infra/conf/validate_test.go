@@ -0,0 +1,115 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestConfigValidate(t *testing.T) {
+	input := `{
+		"inbounds": [
+			{
+				"port": 1080,
+				"protocol": "socks",
+				"tag": "in",
+				"sniffing": {"enabled": true, "destOverride": ["unknown"]}
+			},
+			{
+				"protocol": "http",
+				"tag": "in"
+			}
+		],
+		"outbounds": [
+			{
+				"protocol": "freedom",
+				"tag": "direct"
+			},
+			{
+				"protocol": "vless",
+				"tag": "proxy",
+				"settings": {
+					"address": "example.com",
+					"port": 443,
+					"id": "27848739-7e62-4138-9fd3-098a63964b6b",
+					"encryption": "none"
+				},
+				"streamSettings": {
+					"security": "tls",
+					"tlsSettings": {"fingerprint": "unknown"}
+				}
+			},
+			{
+				"protocol": "unknown"
+			},
+			{
+				"protocol": "freedom",
+				"targetStrategy": "unknown",
+				"settings": {"domainStrategy": 1},
+				"streamSettings": {
+					"security": "unknown",
+					"sockopt": {"domainStrategy": "unknown"}
+				},
+				"mux": {"xudpProxyUDP443": "unknown"}
+			}
+		],
+		"routing": {
+			"rules": [
+				{"outboundTag": "missing"},
+				{"balancerTag": "missing"},
+				{"outboundTag": "direct", "ip": ["1.1.1.1/99"]}
+			]
+		}
+	}`
+
+	config := new(Config)
+	if err := json.Unmarshal([]byte(input), config); err != nil {
+		t.Fatal(err)
+	}
+
+	type issue struct {
+		Path     string
+		Severity string
+	}
+	var issues []issue
+	for _, i := range config.Validate() {
+		if i.Message == "" {
+			t.Error("empty message for ", i.Path)
+		}
+		issues = append(issues, issue{i.Path, i.Severity})
+	}
+
+	expected := []issue{
+		{"inbounds[0].sniffing.destOverride", IssueSeverityError},
+		{"inbounds[1].tag", IssueSeverityError},
+		{"inbounds[1].port", IssueSeverityError},
+		{"outbounds[1].streamSettings.tlsSettings.fingerprint", IssueSeverityError},
+		{"outbounds[2].protocol", IssueSeverityError},
+		{"outbounds[3].streamSettings.sockopt", IssueSeverityError},
+		{"outbounds[3].streamSettings.security", IssueSeverityError},
+		{"outbounds[3].mux.xudpProxyUDP443", IssueSeverityError},
+		{"outbounds[3].targetStrategy", IssueSeverityError},
+		{"outbounds[3].settings.domainStrategy", IssueSeverityError},
+		{"routing.rules[0].outboundTag", IssueSeverityWarning},
+		{"routing.rules[1].balancerTag", IssueSeverityError},
+		{"routing.rules[2].ip", IssueSeverityError},
+	}
+	if r := cmp.Diff(issues, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestConfigValidateOK(t *testing.T) {
+	config := new(Config)
+	if err := json.Unmarshal([]byte(`{
+		"outbounds": [{"protocol": "freedom", "tag": "direct"}],
+		"routing": {"rules": [{"outboundTag": "direct", "network": "tcp"}]}
+	}`), config); err != nil {
+		t.Fatal(err)
+	}
+	if issues := config.Validate(); len(issues) != 0 {
+		t.Error("unexpected issues: ", issues)
+	}
+}
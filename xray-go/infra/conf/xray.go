@@ -16,6 +16,7 @@ import (
 	"github.com/xtls/xray-core/common/serial"
 	core "github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/transport/internet"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -75,18 +76,18 @@ func (c *SniffingConfig) Build() (*proxyman.SniffingConfig, error) {
 		case "fakedns", "fakedns+others":
 			protocols = append(protocols, "fakedns")
 		default:
-			return nil, errors.New("unknown protocol: ", protocol)
+			return nil, withField("destOverride", errors.New("unknown protocol: ", protocol))
 		}
 	}
 
 	domains, err := geodata.ParseDomainRules(c.DomainsExcluded, geodata.Domain_Substr)
 	if err != nil {
-		return nil, err
+		return nil, withField("domainsExcluded", err)
 	}
 
 	ips, err := geodata.ParseIPRules(c.IPsExcluded)
 	if err != nil {
-		return nil, err
+		return nil, withField("ipsExcluded", err)
 	}
 
 	return &proxyman.SniffingConfig{
@@ -113,7 +114,7 @@ func (m *MuxConfig) Build() (*proxyman.MultiplexingConfig, error) {
 		m.XudpProxyUDP443 = "reject"
 	case "reject", "allow", "skip":
 	default:
-		return nil, errors.New(`unknown "xudpProxyUDP443": `, m.XudpProxyUDP443)
+		return nil, withField("xudpProxyUDP443", errors.New(`unknown "xudpProxyUDP443": `, m.XudpProxyUDP443))
 	}
 	return &proxyman.MultiplexingConfig{
 		Enabled:         m.Enabled,
@@ -135,6 +136,27 @@ type InboundDetourConfig struct {
 
 // Build implements Buildable.
 func (c *InboundDetourConfig) Build() (*core.InboundHandlerConfig, error) {
+	receiverSettings, err := c.buildReceiverSettings()
+	if err != nil {
+		return nil, err
+	}
+	rawConfig, ts, err := c.buildProxySettings()
+	if err != nil {
+		return nil, err
+	}
+	if dokodemoConfig, ok := rawConfig.(*DokodemoConfig); ok {
+		receiverSettings.ReceiveOriginalDestination = dokodemoConfig.FollowRedirect
+	}
+
+	return &core.InboundHandlerConfig{
+		Tag:              c.Tag,
+		ReceiverSettings: serial.ToTypedMessage(receiverSettings),
+		ProxySettings:    serial.ToTypedMessage(ts),
+	}, nil
+}
+
+// buildReceiverSettings builds all settings of c but the protocol ones.
+func (c *InboundDetourConfig) buildReceiverSettings() (*proxyman.ReceiverConfig, error) {
 	receiverSettings := &proxyman.ReceiverConfig{}
 
 	// TUN inbound doesn't need port configuration as it uses network interface instead
@@ -143,7 +165,7 @@ func (c *InboundDetourConfig) Build() (*core.InboundHandlerConfig, error) {
 	} else if c.ListenOn == nil {
 		// Listen on anyip, must set PortList
 		if c.PortList == nil {
-			return nil, errors.New("Listen on AnyIP but no Port(s) set in InboundDetour.")
+			return nil, withField("port", errors.New("Listen on AnyIP but no Port(s) set in InboundDetour."))
 		}
 		receiverSettings.PortList = c.PortList.Build()
 	} else {
@@ -154,7 +176,7 @@ func (c *InboundDetourConfig) Build() (*core.InboundHandlerConfig, error) {
 		if listenIP {
 			// Listen on specific IP, must set PortList
 			if c.PortList == nil {
-				return nil, errors.New("Listen on specific ip without port in InboundDetour.")
+				return nil, withField("port", errors.New("Listen on specific ip without port in InboundDetour."))
 			}
 			// Listen on IP:Port
 			receiverSettings.PortList = c.PortList.Build()
@@ -164,14 +186,14 @@ func (c *InboundDetourConfig) Build() (*core.InboundHandlerConfig, error) {
 				receiverSettings.PortList = nil
 			}
 		} else {
-			return nil, errors.New("unable to listen on domain address: ", c.ListenOn.Domain())
+			return nil, withField("listen", errors.New("unable to listen on domain address: ", c.ListenOn.Domain()))
 		}
 	}
 
 	if c.StreamSetting != nil {
 		ss, err := c.StreamSetting.Build()
 		if err != nil {
-			return nil, err
+			return nil, withField("streamSettings", err)
 		}
 		receiverSettings.StreamSettings = ss
 		if strings.Contains(ss.SecurityType, "reality") && (receiverSettings.PortList == nil ||
@@ -182,32 +204,28 @@ func (c *InboundDetourConfig) Build() (*core.InboundHandlerConfig, error) {
 	if c.SniffingConfig != nil {
 		s, err := c.SniffingConfig.Build()
 		if err != nil {
-			return nil, errors.New("failed to build sniffing config").Base(err)
+			return nil, errors.New("failed to build sniffing config").Base(withField("sniffing", err))
 		}
 		receiverSettings.SniffingSettings = s
 	}
+	return receiverSettings, nil
+}
 
+// buildProxySettings loads and builds the protocol settings of c.
+func (c *InboundDetourConfig) buildProxySettings() (interface{}, proto.Message, error) {
 	settings := []byte("{}")
 	if c.Settings != nil {
 		settings = ([]byte)(*c.Settings)
 	}
 	rawConfig, err := inboundConfigLoader.LoadWithID(settings, c.Protocol)
 	if err != nil {
-		return nil, errors.New("failed to load inbound detour config for protocol ", c.Protocol).Base(err)
-	}
-	if dokodemoConfig, ok := rawConfig.(*DokodemoConfig); ok {
-		receiverSettings.ReceiveOriginalDestination = dokodemoConfig.FollowRedirect
+		return nil, nil, errors.New("failed to load inbound detour config for protocol ", c.Protocol).Base(err)
 	}
 	ts, err := rawConfig.(Buildable).Build()
 	if err != nil {
-		return nil, errors.New("failed to build inbound handler for protocol ", c.Protocol).Base(err)
+		return nil, nil, errors.New("failed to build inbound handler for protocol ", c.Protocol).Base(withField("settings", err))
 	}
-
-	return &core.InboundHandlerConfig{
-		Tag:              c.Tag,
-		ReceiverSettings: serial.ToTypedMessage(receiverSettings),
-		ProxySettings:    serial.ToTypedMessage(ts),
-	}, nil
+	return rawConfig, ts, nil
 }
 
 type OutboundDetourConfig struct {
@@ -226,7 +244,7 @@ func (c *OutboundDetourConfig) checkChainProxyConfig() error {
 		return nil
 	}
 	if len(c.ProxySettings.Tag) > 0 && len(c.StreamSetting.SocketSettings.DialerProxy) > 0 {
-		return errors.New("proxySettings.tag is conflicted with sockopt.dialerProxy").AtWarning()
+		return withField("proxySettings.tag", errors.New("proxySettings.tag is conflicted with sockopt.dialerProxy").AtWarning())
 	}
 	return nil
 }
@@ -267,6 +285,27 @@ func validateOutboundTransportSecurity(rawConfig interface{}, senderSettings *pr
 
 // Build implements Buildable.
 func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
+	senderSettings, err := c.buildSenderSettings()
+	if err != nil {
+		return nil, err
+	}
+	rawConfig, ts, err := c.buildProxySettings()
+	if err != nil {
+		return nil, err
+	}
+	if err := validateOutboundTransportSecurity(rawConfig, senderSettings); err != nil {
+		return nil, err
+	}
+
+	return &core.OutboundHandlerConfig{
+		SenderSettings: serial.ToTypedMessage(senderSettings),
+		Tag:            c.Tag,
+		ProxySettings:  serial.ToTypedMessage(ts),
+	}, nil
+}
+
+// buildSenderSettings builds all settings of c but the protocol ones.
+func (c *OutboundDetourConfig) buildSenderSettings() (*proxyman.SenderConfig, error) {
 	senderSettings := &proxyman.SenderConfig{}
 	switch strings.ToLower(c.TargetStrategy) {
 	case "asis", "":
@@ -292,7 +331,7 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 	case "forceipv6v4":
 		senderSettings.TargetStrategy = internet.DomainStrategy_FORCE_IP64
 	default:
-		return nil, errors.New("unsupported target domain strategy: ", c.TargetStrategy)
+		return nil, withField("targetStrategy", errors.New("unsupported target domain strategy: ", c.TargetStrategy))
 	}
 	if err := c.checkChainProxyConfig(); err != nil {
 		return nil, err
@@ -307,7 +346,7 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 			if address.Family().IsDomain() {
 				domain := address.Address.Domain()
 				if domain != "origin" && domain != "srcip" {
-					return nil, errors.New("unable to send through: " + address.String())
+					return nil, withField("sendThrough", errors.New("unable to send through: "+address.String()))
 				}
 			}
 		}
@@ -317,7 +356,7 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 	if c.StreamSetting != nil {
 		ss, err := c.StreamSetting.Build()
 		if err != nil {
-			return nil, errors.New("failed to build stream settings for outbound detour").Base(err)
+			return nil, errors.New("failed to build stream settings for outbound detour").Base(withField("streamSettings", err))
 		}
 		senderSettings.StreamSettings = ss
 	}
@@ -325,7 +364,7 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 	if c.ProxySettings != nil {
 		ps, err := c.ProxySettings.Build()
 		if err != nil {
-			return nil, errors.New("invalid outbound detour proxy settings").Base(err)
+			return nil, errors.New("invalid outbound detour proxy settings").Base(withField("proxySettings", err))
 		}
 		if ps.TransportLayerProxy {
 			if senderSettings.StreamSettings != nil {
@@ -345,39 +384,54 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 	if c.MuxSettings != nil {
 		ms, err := c.MuxSettings.Build()
 		if err != nil {
-			return nil, errors.New("failed to build Mux config").Base(err)
+			return nil, errors.New("failed to build Mux config").Base(withField("mux", err))
 		}
 		senderSettings.MultiplexSettings = ms
 	}
+	return senderSettings, nil
+}
 
+// buildProxySettings loads and builds the protocol settings of c.
+func (c *OutboundDetourConfig) buildProxySettings() (interface{}, proto.Message, error) {
 	settings := []byte("{}")
 	if c.Settings != nil {
 		settings = ([]byte)(*c.Settings)
 	}
 	rawConfig, err := outboundConfigLoader.LoadWithID(settings, c.Protocol)
 	if err != nil {
-		return nil, errors.New("failed to load outbound detour config for protocol ", c.Protocol).Base(err)
-	}
-	if err := validateOutboundTransportSecurity(rawConfig, senderSettings); err != nil {
-		return nil, err
+		return nil, nil, errors.New("failed to load outbound detour config for protocol ", c.Protocol).Base(err)
 	}
 	ts, err := rawConfig.(Buildable).Build()
 	if err != nil {
-		return nil, errors.New("failed to build outbound handler for protocol ", c.Protocol).Base(err)
+		return nil, nil, errors.New("failed to build outbound handler for protocol ", c.Protocol).Base(withField("settings", err))
 	}
+	return rawConfig, ts, nil
+}
 
-	return &core.OutboundHandlerConfig{
-		SenderSettings: serial.ToTypedMessage(senderSettings),
-		Tag:            c.Tag,
-		ProxySettings:  serial.ToTypedMessage(ts),
-	}, nil
+type StatsPersistenceConfig struct {
+	File     string   `json:"file"`
+	Patterns []string `json:"patterns"`
+	Interval uint32   `json:"interval"`
 }
 
-type StatsConfig struct{}
+type StatsConfig struct {
+	Persistence *StatsPersistenceConfig `json:"persistence"`
+}
 
 // Build implements Buildable.
 func (c *StatsConfig) Build() (*stats.Config, error) {
-	return &stats.Config{}, nil
+	config := &stats.Config{}
+	if c.Persistence != nil {
+		if c.Persistence.File == "" {
+			return nil, errors.New("empty file of stats persistence")
+		}
+		config.Persistence = &stats.Persistence{
+			File:     c.Persistence.File,
+			Patterns: c.Persistence.Patterns,
+			Interval: c.Persistence.Interval,
+		}
+	}
+	return config, nil
 }
 
 type EnvConfig map[string]string
@@ -409,6 +463,9 @@ type Config struct {
 	BurstObservatory *BurstObservatoryConfig `json:"burstObservatory"`
 	Version          *VersionConfig          `json:"version"`
 	Geodata          *GeodataConfig          `json:"geodata"`
+	Subscription     *SubscriptionConfig     `json:"subscription"`
+	Quota            *QuotaConfig            `json:"quota"`
+	Tracing          *TracingConfig          `json:"tracing"`
 }
 
 func (c *Config) findInboundTag(tag string) int {
@@ -491,6 +548,18 @@ func (c *Config) Override(o *Config, fn string) {
 		c.Geodata = o.Geodata
 	}
 
+	if o.Subscription != nil {
+		c.Subscription = o.Subscription
+	}
+
+	if o.Quota != nil {
+		c.Quota = o.Quota
+	}
+
+	if o.Tracing != nil {
+		c.Tracing = o.Tracing
+	}
+
 	// update the Inbound in slice if the only one in override config has same tag
 	if len(o.InboundConfigs) > 0 {
 		for i := range o.InboundConfigs {
@@ -572,7 +641,11 @@ func (c *Config) Build() (*core.Config, error) {
 
 	var logConfMsg *serial.TypedMessage
 	if c.LogConfig != nil {
-		logConfMsg = serial.ToTypedMessage(c.LogConfig.Build())
+		logConf, err := c.LogConfig.Build()
+		if err != nil {
+			return nil, errors.New("failed to build log configuration").Base(err)
+		}
+		logConfMsg = serial.ToTypedMessage(logConf)
 	} else {
 		logConfMsg = serial.ToTypedMessage(DefaultLogConfig())
 	}
@@ -653,6 +726,30 @@ func (c *Config) Build() (*core.Config, error) {
 		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
+	if c.Subscription != nil {
+		r, err := c.Subscription.Build()
+		if err != nil {
+			return nil, errors.New("failed to build subscription configuration").Base(err)
+		}
+		config.App = append(config.App, serial.ToTypedMessage(r))
+	}
+
+	if c.Quota != nil {
+		r, err := c.Quota.Build()
+		if err != nil {
+			return nil, errors.New("failed to build quota configuration").Base(err)
+		}
+		config.App = append(config.App, serial.ToTypedMessage(r))
+	}
+
+	if c.Tracing != nil {
+		r, err := c.Tracing.Build()
+		if err != nil {
+			return nil, errors.New("failed to build tracing configuration").Base(err)
+		}
+		config.App = append(config.App, serial.ToTypedMessage(r))
+	}
+
 	var inbounds []InboundDetourConfig
 
 	if len(c.InboundConfigs) > 0 {
@@ -671,6 +768,12 @@ func (c *Config) Build() (*core.Config, error) {
 		config.Inbound = append(config.Inbound, ic)
 	}
 
+	if c.Quota != nil {
+		if err := c.Quota.checkStats(c, config.Inbound); err != nil {
+			return nil, errors.New("failed to build quota configuration").Base(err)
+		}
+	}
+
 	var outbounds []OutboundDetourConfig
 
 	if len(c.OutboundConfigs) > 0 {
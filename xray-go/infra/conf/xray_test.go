@@ -10,6 +10,7 @@ import (
 	"github.com/xtls/xray-core/app/log"
 	"github.com/xtls/xray-core/app/proxyman"
 	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/app/stats"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/geodata"
 	clog "github.com/xtls/xray-core/common/log"
@@ -267,6 +268,36 @@ func TestMuxConfig_Build(t *testing.T) {
 	}
 }
 
+func TestStatsConfig_Build(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields string
+		want   *stats.Config
+	}{
+		{"empty", `{}`, &stats.Config{}},
+		{"persistence", `{"persistence": {"file": "stats.json", "patterns": ["user>>>"], "interval": 300}}`, &stats.Config{
+			Persistence: &stats.Persistence{
+				File:     "stats.json",
+				Patterns: []string{"user>>>"},
+				Interval: 300,
+			},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &StatsConfig{}
+			common.Must(json.Unmarshal([]byte(tt.fields), c))
+			if got, _ := c.Build(); !proto.Equal(got, tt.want) {
+				t.Errorf("StatsConfig.Build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (&StatsConfig{Persistence: &StatsPersistenceConfig{}}).Build(); err == nil {
+		t.Error("expected error for empty file of stats persistence")
+	}
+}
+
 func TestConfig_Override(t *testing.T) {
 	tests := []struct {
 		name string
@@ -0,0 +1,54 @@
+package main
+
+import "C"
+import (
+	"context"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/main/commands/all/api"
+)
+
+//export callAPI
+func callAPI(handle int64, method string, request string) (result *C.char, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	server := getInstance(handle)
+	if server == nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("instance not found: ", handle))
+		return
+	}
+
+	services, err := server.getServices()
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, err)
+		return
+	}
+
+	response, err := services.Call(context.Background(), strings.Clone(method), strings.Clone(request))
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, err)
+		return
+	}
+	result = C.CString(response)
+	return
+}
+
+//export queryInstanceStats
+func queryInstanceStats(handle int64, pattern string, reset bool) (result *C.char, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	server := getInstance(handle)
+	if server == nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("instance not found: ", handle))
+		return
+	}
+
+	stats, err := api.QueryInstanceStats(server.Instance, strings.Clone(pattern), reset)
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, err)
+		return
+	}
+	result = C.CString(stats)
+	return
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	logService "github.com/xtls/xray-core/app/log/command"
+	handlerService "github.com/xtls/xray-core/app/proxyman/command"
+	routerService "github.com/xtls/xray-core/app/router/command"
+	statsService "github.com/xtls/xray-core/app/stats/command"
+	"github.com/xtls/xray-core/common/errors"
+	cserial "github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+	"github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Services bundles the management services of an in-process Xray instance, so
+// that the API can be called directly without an api inbound or a gRPC
+// connection.
+type Services struct {
+	Handler handlerService.HandlerServiceServer
+	Routing routerService.RoutingServiceServer
+	Stats   statsService.StatsServiceServer
+	Logger  logService.LoggerServiceServer
+}
+
+// NewServices creates the management services for a running instance.
+func NewServices(v *core.Instance) (*Services, error) {
+	router, ok := v.GetFeature(routing.RouterType()).(routing.Router)
+	if !ok {
+		return nil, errors.New("router is not available")
+	}
+	statsManager, ok := v.GetFeature(stats.ManagerType()).(stats.Manager)
+	if !ok {
+		return nil, errors.New("stats manager is not available")
+	}
+	return &Services{
+		Handler: handlerService.NewHandlerServer(v),
+		Routing: routerService.NewRoutingServer(router, nil),
+		Stats:   statsService.NewStatsServer(statsManager),
+		Logger:  &logService.LoggerServer{V: v},
+	}, nil
+}
+
+type serviceMethod func(ctx context.Context, request []byte) (proto.Message, error)
+
+// protoMethod adapts a service method whose request is given as the protobuf
+// JSON encoding of its request message.
+func protoMethod[Req any, PReq interface {
+	*Req
+	proto.Message
+}, Resp proto.Message](call func(context.Context, PReq) (Resp, error)) serviceMethod {
+	return func(ctx context.Context, request []byte) (proto.Message, error) {
+		r := PReq(new(Req))
+		if err := protojson.Unmarshal(request, r); err != nil {
+			return nil, errors.New("failed to decode request").Base(err)
+		}
+		return call(ctx, r)
+	}
+}
+
+func (s *Services) methods() map[string]serviceMethod {
+	return map[string]serviceMethod{
+		"AddInbound":             s.addInbounds,
+		"RemoveInbound":          protoMethod(s.Handler.RemoveInbound),
+		"ListInbounds":           protoMethod(s.Handler.ListInbounds),
+		"AddUsers":               s.addInboundUsers,
+		"RemoveUsers":            s.removeInboundUsers,
+		"GetInboundUsers":        protoMethod(s.Handler.GetInboundUsers),
+		"GetInboundUsersCount":   protoMethod(s.Handler.GetInboundUsersCount),
+		"AddOutbound":            s.addOutbounds,
+		"RemoveOutbound":         protoMethod(s.Handler.RemoveOutbound),
+		"ListOutbounds":          protoMethod(s.Handler.ListOutbounds),
+		"AddRule":                s.addRules,
+		"RemoveRule":             protoMethod(s.Routing.RemoveRule),
+		"ListRule":               protoMethod(s.Routing.ListRule),
+		"SourceIpBlock":          s.sourceIpBlock,
+		"TestRoute":              protoMethod(s.Routing.TestRoute),
+		"GetBalancerInfo":        protoMethod(s.Routing.GetBalancerInfo),
+		"OverrideBalancerTarget": protoMethod(s.Routing.OverrideBalancerTarget),
+		"GetStats":               protoMethod(s.Stats.GetStats),
+		"GetStatsOnline":         protoMethod(s.Stats.GetStatsOnline),
+		"GetStatsOnlineIpList":   protoMethod(s.Stats.GetStatsOnlineIpList),
+		"GetAllOnlineUsers":      protoMethod(s.Stats.GetAllOnlineUsers),
+		"GetUsersStats":          protoMethod(s.Stats.GetUsersStats),
+		"QueryStats":             protoMethod(s.Stats.QueryStats),
+		"GetSysStats":            protoMethod(s.Stats.GetSysStats),
+		"RestartLogger":          protoMethod(s.Logger.RestartLogger),
+	}
+}
+
+// Call invokes the named API method with a JSON request and returns the JSON
+// response. Method names are those of the gRPC services.
+//
+// Methods that take Xray configuration (AddInbound, AddOutbound, AddUsers and
+// AddRule) accept it in the Xray JSON config format, the same as the files
+// given to the corresponding api commands; AddRule also reads "shouldAppend".
+// RemoveUsers takes {"tag", "emails"} and SourceIpBlock takes {"inbound",
+// "outbound", "ruleTag", "reset", "ips"}. All other methods take the protobuf
+// JSON encoding of their gRPC request. An empty request is the same as "{}".
+func (s *Services) Call(ctx context.Context, method string, request string) (string, error) {
+	call, found := s.methods()[method]
+	if !found {
+		return "", errors.New("unknown API method: ", method)
+	}
+	if strings.TrimSpace(request) == "" {
+		request = "{}"
+	}
+	resp, err := call(ctx, []byte(request))
+	if err != nil {
+		return "", errors.New("failed to call ", method).Base(err)
+	}
+	return getJSONResponse(resp)
+}
+
+func decodeConfig(request []byte) (*conf.Config, error) {
+	c, err := serial.DecodeJSONConfig(bytes.NewReader(request))
+	if err != nil {
+		return nil, errors.New("failed to decode config").Base(err)
+	}
+	return c, nil
+}
+
+func (s *Services) addInbounds(ctx context.Context, request []byte) (proto.Message, error) {
+	c, err := decodeConfig(request)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.InboundConfigs) == 0 {
+		return nil, errors.New("no valid inbound found")
+	}
+	for _, in := range c.InboundConfigs {
+		i, err := in.Build()
+		if err != nil {
+			return nil, errors.New("failed to build inbound ", in.Tag).Base(err)
+		}
+		if _, err := s.Handler.AddInbound(ctx, &handlerService.AddInboundRequest{Inbound: i}); err != nil {
+			return nil, errors.New("failed to add inbound ", in.Tag).Base(err)
+		}
+	}
+	return &handlerService.AddInboundResponse{}, nil
+}
+
+func (s *Services) addOutbounds(ctx context.Context, request []byte) (proto.Message, error) {
+	c, err := decodeConfig(request)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.OutboundConfigs) == 0 {
+		return nil, errors.New("no valid outbound found")
+	}
+	for _, out := range c.OutboundConfigs {
+		o, err := out.Build()
+		if err != nil {
+			return nil, errors.New("failed to build outbound ", out.Tag).Base(err)
+		}
+		if _, err := s.Handler.AddOutbound(ctx, &handlerService.AddOutboundRequest{Outbound: o}); err != nil {
+			return nil, errors.New("failed to add outbound ", out.Tag).Base(err)
+		}
+	}
+	return &handlerService.AddOutboundResponse{}, nil
+}
+
+func (s *Services) addInboundUsers(ctx context.Context, request []byte) (proto.Message, error) {
+	c, err := decodeConfig(request)
+	if err != nil {
+		return nil, err
+	}
+	for _, in := range c.InboundConfigs {
+		if len(in.Tag) < 1 {
+			continue
+		}
+		built, err := in.Build()
+		if err != nil {
+			return nil, errors.New("failed to build inbound ", in.Tag).Base(err)
+		}
+		for _, user := range extractInboundUsers(built) {
+			if len(user.Email) < 1 {
+				continue
+			}
+			_, err := s.Handler.AlterInbound(ctx, &handlerService.AlterInboundRequest{
+				Tag: in.Tag,
+				Operation: cserial.ToTypedMessage(
+					&handlerService.AddUserOperation{
+						User: user,
+					},
+				),
+			})
+			if err != nil {
+				return nil, errors.New("failed to add user ", user.Email, " to ", in.Tag).Base(err)
+			}
+		}
+	}
+	return &handlerService.AlterInboundResponse{}, nil
+}
+
+func (s *Services) removeInboundUsers(ctx context.Context, request []byte) (proto.Message, error) {
+	var r struct {
+		Tag    string   `json:"tag"`
+		Emails []string `json:"emails"`
+	}
+	if err := json.Unmarshal(request, &r); err != nil {
+		return nil, errors.New("failed to decode request").Base(err)
+	}
+	if len(r.Tag) < 1 {
+		return nil, errors.New("inbound tag not specified")
+	}
+	for _, email := range r.Emails {
+		_, err := s.Handler.AlterInbound(ctx, &handlerService.AlterInboundRequest{
+			Tag: r.Tag,
+			Operation: cserial.ToTypedMessage(
+				&handlerService.RemoveUserOperation{
+					Email: email,
+				},
+			),
+		})
+		if err != nil {
+			return nil, errors.New("failed to remove user ", email, " from ", r.Tag).Base(err)
+		}
+	}
+	return &handlerService.AlterInboundResponse{}, nil
+}
+
+func (s *Services) addRules(ctx context.Context, request []byte) (proto.Message, error) {
+	var r struct {
+		ShouldAppend bool `json:"shouldAppend"`
+	}
+	c, err := decodeConfig(request)
+	if err != nil {
+		return nil, err
+	}
+	if c.RouterConfig == nil {
+		return nil, errors.New(`config did not have "routing" field`)
+	}
+	if err := json.Unmarshal(request, &r); err != nil {
+		return nil, errors.New("failed to decode request").Base(err)
+	}
+	return s.addRouterConfig(ctx, c.RouterConfig, r.ShouldAppend)
+}
+
+func (s *Services) addRouterConfig(ctx context.Context, rc *conf.RouterConfig, shouldAppend bool) (proto.Message, error) {
+	config, err := rc.Build()
+	if err != nil {
+		return nil, errors.New("failed to build conf").Base(err)
+	}
+	tmsg := cserial.ToTypedMessage(config)
+	if tmsg == nil {
+		return nil, errors.New("failed to format config to TypedMessage")
+	}
+	return s.Routing.AddRule(ctx, &routerService.AddRuleRequest{
+		Config:       tmsg,
+		ShouldAppend: shouldAppend,
+	})
+}
+
+func (s *Services) sourceIpBlock(ctx context.Context, request []byte) (proto.Message, error) {
+	var r struct {
+		Inbound  string   `json:"inbound"`
+		Outbound string   `json:"outbound"`
+		RuleTag  string   `json:"ruleTag"`
+		Reset    bool     `json:"reset"`
+		IPs      []string `json:"ips"`
+	}
+	if err := json.Unmarshal(request, &r); err != nil {
+		return nil, errors.New("failed to decode request").Base(err)
+	}
+	if r.RuleTag == "" {
+		r.RuleTag = "sourceIpBlock"
+	}
+
+	rc, err := sourceIpBlockRule(r.Inbound, r.Outbound, r.RuleTag, r.IPs)
+	if err != nil {
+		return nil, errors.New("failed to decode rule").Base(err)
+	}
+
+	if r.Reset {
+		if _, err := s.Routing.RemoveRule(ctx, &routerService.RemoveRuleRequest{RuleTag: r.RuleTag}); err != nil {
+			return nil, errors.New("failed to perform RemoveRule").Base(err)
+		}
+	}
+	return s.addRouterConfig(ctx, rc, true)
+}
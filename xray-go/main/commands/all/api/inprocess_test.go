@@ -0,0 +1,137 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/proxyman"
+	_ "github.com/xtls/xray-core/app/proxyman/inbound"
+	_ "github.com/xtls/xray-core/app/proxyman/outbound"
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	feature_stats "github.com/xtls/xray-core/features/stats"
+	. "github.com/xtls/xray-core/main/commands/all/api"
+	"github.com/xtls/xray-core/proxy/freedom"
+)
+
+func newServices(t *testing.T) (*core.Instance, *Services) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&router.Config{}),
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				Tag:           "direct",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	t.Cleanup(func() { v.Close() })
+
+	services, err := NewServices(v)
+	common.Must(err)
+	return v, services
+}
+
+func call(t *testing.T, s *Services, method string, request string, response any) {
+	t.Helper()
+	resp, err := s.Call(context.Background(), method, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response != nil {
+		common.Must(json.Unmarshal([]byte(resp), response))
+	}
+}
+
+func TestServicesCallHandler(t *testing.T) {
+	_, s := newServices(t)
+
+	call(t, s, "AddOutbound", `{"outbounds": [{"tag": "blocked", "protocol": "blackhole"}]}`, nil)
+
+	var resp struct {
+		Outbounds []struct {
+			Tag string `json:"tag"`
+		} `json:"outbounds"`
+	}
+	call(t, s, "ListOutbounds", "", &resp)
+	var tags []string
+	for _, o := range resp.Outbounds {
+		tags = append(tags, o.Tag)
+	}
+	if r := cmp.Diff(tags, []string{"direct", "blocked"}); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestServicesCallStats(t *testing.T) {
+	v, s := newServices(t)
+
+	manager := v.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	c, err := manager.RegisterCounter("test_counter")
+	common.Must(err)
+	c.Set(3)
+
+	var resp struct {
+		Stat struct {
+			Name  string `json:"name"`
+			Value int64  `json:"value"`
+		} `json:"stat"`
+	}
+	call(t, s, "GetStats", `{"name": "test_counter", "reset": true}`, &resp)
+	if resp.Stat.Name != "test_counter" || resp.Stat.Value != 3 {
+		t.Error("unexpected stats ", resp.Stat)
+	}
+	if c.Value() != 0 {
+		t.Error("expected counter reset, but got ", c.Value())
+	}
+}
+
+func TestServicesCallRouting(t *testing.T) {
+	_, s := newServices(t)
+
+	type rule struct {
+		Tag     string `json:"tag"`
+		RuleTag string `json:"ruleTag"`
+	}
+	var resp struct {
+		Rules []rule `json:"rules"`
+	}
+
+	// The rule is replaced on reset, instead of being added again.
+	call(t, s, "SourceIpBlock", `{"outbound": "direct", "ips": ["1.2.3.4"]}`, nil)
+	call(t, s, "SourceIpBlock", `{"outbound": "direct", "ips": ["5.6.7.8"], "reset": true}`, nil)
+	call(t, s, "ListRule", "", &resp)
+	if r := cmp.Diff(resp.Rules, []rule{{Tag: "direct", RuleTag: "sourceIpBlock"}}); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestServicesCallError(t *testing.T) {
+	_, s := newServices(t)
+
+	if _, err := s.Call(context.Background(), "Unknown", ""); err == nil || !strings.Contains(err.Error(), "unknown API method") {
+		t.Error("expected unknown method, but got ", err)
+	}
+	if _, err := s.Call(context.Background(), "GetStats", "{"); err == nil {
+		t.Error("expected error of invalid request")
+	}
+	if _, err := s.Call(context.Background(), "GetStats", `{"name": "notexist"}`); err == nil {
+		t.Error("expected error of counter not found")
+	}
+}
@@ -38,12 +38,12 @@ func setSharedFlags(cmd *base.Command) {
 	cmd.Flag.BoolVar(&apiJSON, "json", false, "")
 }
 
-func dialAPIServerTarget(serverAddr string, timeout int) (conn *grpc.ClientConn, ctx context.Context, close func()) {
+func dialAPIServerTarget(serverAddr string, timeout int) (conn *grpc.ClientConn, ctx context.Context, close func(), err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
-	conn, err := grpc.DialContext(ctx, serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	conn, err = grpc.DialContext(ctx, serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
 	if err != nil {
 		cancel()
-		return nil, nil, nil
+		return nil, nil, nil, fmt.Errorf("failed to dial %s: %w", serverAddr, err)
 	}
 	close = func() {
 		cancel()
@@ -122,15 +122,14 @@ func fetchHTTPContent(target string) ([]byte, error) {
 	return content, nil
 }
 
-func getJSONResponse(m proto.Message) string {
+func getJSONResponse(m proto.Message) (string, error) {
 	if isNil(m) {
-		return "Failed to get proto"
+		return "", errors.New("failed to get proto")
 	}
 	if j, ok := creflect.MarshalToJson(m, true); ok {
-		return j
-	} else {
-		return "Failed to encode proto"
+		return j, nil
 	}
+	return "", errors.New("failed to encode proto")
 }
 
 func showJSONResponse(m proto.Message) {
@@ -1,12 +1,13 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	routerService "github.com/xtls/xray-core/app/router/command"
 	cserial "github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/infra/conf"
 	"github.com/xtls/xray-core/infra/conf/serial"
 	"github.com/xtls/xray-core/main/commands/base"
 )
@@ -71,41 +72,10 @@ func executeSourceIpBlock(cmd *base.Command, args []string) {
 
 	client := routerService.NewRoutingServiceClient(conn)
 
-	jsonIps, err := json.Marshal(unnamedArgs)
-	if err != nil {
-		fmt.Println("Error marshaling JSON:", err)
-		return
-	}
-
-	jsonInbound, err := json.Marshal([]string{inbound})
-	if inbound == "" {
-		jsonInbound, err = json.Marshal([]string{})
-	}
-	if err != nil {
-		fmt.Println("Error marshaling JSON:", err)
-		return
-	}
-	stringConfig := fmt.Sprintf(`
-	{
-		"routing": {
-			"rules": [
-			  {
-				"ruleTag" : "%s",
-				"inboundTag": %s,		
-				"outboundTag": "%s",
-				"source": %s
-			  }
-			]
-		  }
-	  }
-	  
-	`, ruletag, string(jsonInbound), outbound, string(jsonIps))
-
-	conf, err := serial.DecodeJSONConfig(strings.NewReader(stringConfig))
+	rc, err := sourceIpBlockRule(inbound, outbound, ruletag, unnamedArgs)
 	if err != nil {
 		base.Fatalf("failed to decode : %s", err)
 	}
-	rc := *conf.RouterConfig
 
 	config, err := rc.Build()
 	if err != nil {
@@ -137,3 +107,33 @@ func executeSourceIpBlock(cmd *base.Command, args []string) {
 	}
 	showJSONResponse(resp)
 }
+
+// sourceIpBlockRule builds the routing config of a rule tagged ruleTag, which
+// routes the connections from ips through inbound, or any inbound if it is
+// empty, to outbound.
+func sourceIpBlockRule(inbound, outbound, ruleTag string, ips []string) (*conf.RouterConfig, error) {
+	inboundTags := []string{}
+	if inbound != "" {
+		inboundTags = []string{inbound}
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		"routing": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"ruleTag":     ruleTag,
+					"inboundTag":  inboundTags,
+					"outboundTag": outbound,
+					"source":      ips,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	c, err := serial.DecodeJSONConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return c.RouterConfig, nil
+}
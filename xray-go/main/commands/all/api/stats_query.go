@@ -1,8 +1,18 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	statsService "github.com/xtls/xray-core/app/stats/command"
+	"github.com/xtls/xray-core/common/errors"
+	creflect "github.com/xtls/xray-core/common/reflect"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/main/commands/base"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 var cmdQueryStats = &base.Command{
@@ -33,12 +43,10 @@ Example:
 	Run: executeQueryStats,
 }
 
-func QueryStats(serverAddr string, timeout int, pattern string, reset bool) string {
-	conn, ctx, close := dialAPIServerTarget(serverAddr, timeout)
-
-	if conn == nil {
-		// Failed to dial API server
-		return "Failed to dial API server"
+func QueryStats(serverAddr string, timeout int, pattern string, reset bool) (string, error) {
+	conn, ctx, close, err := dialAPIServerTarget(serverAddr, timeout)
+	if err != nil {
+		return "", err
 	}
 	defer close()
 
@@ -49,12 +57,70 @@ func QueryStats(serverAddr string, timeout int, pattern string, reset bool) stri
 	}
 	resp, err := client.QueryStats(ctx, r)
 	if err != nil {
-		// Failed to query stats
-		return "Failed to query stats"
+		return "", fmt.Errorf("failed to query stats: %w", err)
 	}
 	return getJSONResponse(resp)
 }
 
+type onlineStat struct {
+	Name  string           `json:"name"`
+	Count int              `json:"count"`
+	Ips   map[string]int64 `json:"ips,omitempty"`
+}
+
+type instanceStats struct {
+	Stat   []json.RawMessage `json:"stat,omitempty"`
+	Online []*onlineStat     `json:"online,omitempty"`
+}
+
+// QueryInstanceStats reads the counters and online maps of a running instance
+// directly from its stats manager, so no api inbound is required. Names are
+// filtered and counters are reset the same way as QueryStatsRequest.
+func QueryInstanceStats(v *core.Instance, pattern string, reset bool) (string, error) {
+	manager, ok := v.GetFeature(stats.ManagerType()).(stats.Manager)
+	if !ok {
+		return "", errors.New("stats manager is not available")
+	}
+
+	resp, err := statsService.NewStatsServer(manager).QueryStats(context.Background(), &statsService.QueryStatsRequest{
+		Pattern: pattern,
+		Reset_:  reset,
+	})
+	if err != nil {
+		return "", errors.New("failed to query stats").Base(err)
+	}
+
+	response := &instanceStats{}
+	for _, stat := range resp.Stat {
+		b, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(stat)
+		if err != nil {
+			return "", errors.New("failed to encode stats").Base(err)
+		}
+		response.Stat = append(response.Stat, b)
+	}
+	manager.VisitOnlineMaps(func(name string, om stats.OnlineMap) bool {
+		if strings.Contains(name, pattern) {
+			online := &onlineStat{
+				Name:  name,
+				Count: om.Count(),
+				Ips:   make(map[string]int64),
+			}
+			om.ForEach(func(ip string, lastSeen int64) bool {
+				online.Ips[ip] = lastSeen
+				return true
+			})
+			response.Online = append(response.Online, online)
+		}
+		return true
+	})
+
+	b, err := creflect.JSONMarshalWithoutEscape(response)
+	if err != nil {
+		return "", errors.New("failed to encode stats").Base(err)
+	}
+	return string(b), nil
+}
+
 func executeQueryStats(cmd *base.Command, args []string) {
 	setSharedFlags(cmd)
 	pattern := cmd.Flag.String("pattern", "", "")
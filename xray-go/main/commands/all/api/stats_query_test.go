@@ -0,0 +1,56 @@
+package api_test
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	feature_stats "github.com/xtls/xray-core/features/stats"
+	. "github.com/xtls/xray-core/main/commands/all/api"
+)
+
+func TestQueryInstanceStats(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&stats.Config{}),
+		},
+	})
+	common.Must(err)
+	defer v.Close()
+
+	manager := v.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	c, err := manager.RegisterCounter("test_counter")
+	common.Must(err)
+	c.Set(2)
+	_, err = manager.RegisterCounter("test_zero")
+	common.Must(err)
+	_, err = manager.RegisterCounter("other")
+	common.Must(err)
+
+	out, err := QueryInstanceStats(v, "test_", true)
+	common.Must(err)
+	var resp struct {
+		Stat []map[string]string `json:"stat"`
+	}
+	common.Must(json.Unmarshal([]byte(out), &resp))
+	slices.SortFunc(resp.Stat, func(a, b map[string]string) int {
+		return strings.Compare(a["name"], b["name"])
+	})
+	// The counters at 0 are encoded with their values.
+	expected := []map[string]string{
+		{"name": "test_counter", "value": "2"},
+		{"name": "test_zero", "value": "0"},
+	}
+	if r := cmp.Diff(resp.Stat, expected); r != "" {
+		t.Error(r)
+	}
+	if c.Value() != 0 {
+		t.Error("expected counter reset, but got ", c.Value())
+	}
+}
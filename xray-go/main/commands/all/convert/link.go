@@ -0,0 +1,97 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"github.com/xtls/xray-core/infra/conf/sharelink"
+	"github.com/xtls/xray-core/main/commands/base"
+	"github.com/xtls/xray-core/main/confloader"
+)
+
+var cmdLink = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} convert link [-r] [stdin:] [link or json file]...",
+	Short:       "Convert share links to outbounds and back",
+	Long: `
+Convert share links, such as vless://, vmess://, trojan://, ss://,
+hysteria2://, socks://, http(s):// and wireguard:// links, to outbounds in
+JSON, or outbounds back to share links.
+
+The name of a link becomes the tag of its outbound. Only the settings a link
+can carry are converted: the server and its credentials, the transport with
+its host, path and mode, and TLS or REALITY with their client settings.
+
+Arguments:
+
+	-r, -reverse
+		Convert outbounds to share links, one per line. Each file holds
+		an outbound, an array of them, or a config with "outbounds".
+
+Examples:
+
+    {{.Exec}} convert link "vless://..."
+    {{.Exec}} convert link -r config.json
+	`,
+	Run: executeLink,
+}
+
+func executeLink(cmd *base.Command, args []string) {
+	var reverse bool
+	cmd.Flag.BoolVar(&reverse, "r", false, "")
+	cmd.Flag.BoolVar(&reverse, "reverse", false, "")
+	cmd.Flag.Parse(args)
+
+	if cmd.Flag.NArg() < 1 {
+		base.Fatalf("empty input list")
+	}
+
+	if reverse {
+		for _, arg := range cmd.Flag.Args() {
+			reader, err := confloader.LoadConfig(arg)
+			if err != nil {
+				base.Fatalf("failed to load config: %s", err)
+			}
+			b, err := io.ReadAll(reader)
+			if err != nil {
+				base.Fatalf("failed to read config: %s", err)
+			}
+			outbounds, err := serial.DecodeOutboundsFromJSONString(string(b))
+			if err != nil {
+				base.Fatalf("failed to decode outbounds: %s", err)
+			}
+			for i := range outbounds {
+				link, err := sharelink.Format(&outbounds[i])
+				if err != nil {
+					base.Fatalf("failed to convert outbound %d of %s: %s", i, arg, err)
+				}
+				fmt.Println(link)
+			}
+		}
+		return
+	}
+
+	outbounds := make([]json.RawMessage, 0, cmd.Flag.NArg())
+	for _, arg := range cmd.Flag.Args() {
+		outbound, err := sharelink.ParseJSON(arg)
+		if err != nil {
+			base.Fatalf("failed to convert link: %s", err)
+		}
+		outbounds = append(outbounds, outbound)
+	}
+	var b []byte
+	var err error
+	if len(outbounds) == 1 {
+		b = outbounds[0]
+	} else if b, err = json.Marshal(outbounds); err != nil {
+		base.Fatalf("failed to encode outbounds: %s", err)
+	}
+	out := new(bytes.Buffer)
+	if err := json.Indent(out, b, "", "  "); err != nil {
+		base.Fatalf("failed to encode outbounds: %s", err)
+	}
+	fmt.Println(out.String())
+}
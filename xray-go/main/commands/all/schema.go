@@ -0,0 +1,43 @@
+package all
+
+import (
+	"fmt"
+
+	creflect "github.com/xtls/xray-core/common/reflect"
+	"github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdSchema = &base.Command{
+	UsageLine: `{{.Exec}} schema`,
+	Short:     `Print JSON Schema of the config`,
+	Long: `
+Print the JSON Schema (draft 2020-12) of the JSON config, for use by editors
+and other tools to check or complete configs.
+
+The schema is generated from the config parser of this build, so it covers
+exactly the protocols, transports and finalmasks it supports. Settings that
+are only checked when the config is built, such as users, are left free-form;
+use "{{.Exec}} run -validate" to check them.
+
+Example:
+
+    {{.Exec}} schema > xray.schema.json
+`,
+}
+
+func init() {
+	cmdSchema.Run = executeSchema // break init loop
+}
+
+func executeSchema(cmd *base.Command, args []string) {
+	schema, err := conf.JSONSchema()
+	if err != nil {
+		base.Fatalf("failed to generate schema: %s", err)
+	}
+	b, err := creflect.JSONMarshalWithoutEscape(schema)
+	if err != nil {
+		base.Fatalf("failed to encode schema: %s", err)
+	}
+	fmt.Print(string(b))
+}
@@ -24,13 +24,19 @@ import (
 	_ "github.com/xtls/xray-core/app/log"
 	_ "github.com/xtls/xray-core/app/metrics"
 	_ "github.com/xtls/xray-core/app/policy"
+	_ "github.com/xtls/xray-core/app/quota"
 	_ "github.com/xtls/xray-core/app/reverse"
 	_ "github.com/xtls/xray-core/app/router"
 	_ "github.com/xtls/xray-core/app/stats"
+	_ "github.com/xtls/xray-core/app/subscription"
+	_ "github.com/xtls/xray-core/app/tracing"
 
 	// Fix dependency cycle caused by core import in internet package
 	_ "github.com/xtls/xray-core/transport/internet/tagged/taggedimpl"
 
+	// Fix dependency cycle caused by config parsers in subscription decoder
+	_ "github.com/xtls/xray-core/app/subscription/subscriptionimpl"
+
 	// Developer preview features
 	_ "github.com/xtls/xray-core/app/observatory"
 
@@ -0,0 +1,35 @@
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"github.com/xtls/xray-core/common/errors"
+)
+
+// Error classes reported through the C ABI together with the error message,
+// so the embedding side can raise a distinct exception for each of them.
+const (
+	errorClassNone = iota
+	errorClassConfig
+	errorClassStart
+	errorClassRuntime
+)
+
+// toCError converts err into the error class and message pair returned by
+// exported functions. The message must be released with freeCString.
+func toCError(class int, err error) (int, *C.char) {
+	if err == nil {
+		return errorClassNone, nil
+	}
+	return class, C.CString(err.Error())
+}
+
+// recoverCError turns a panic in an exported function into a runtime error,
+// since an unrecovered panic would take down the host process.
+func recoverCError(errorClass *int, errorMessage **C.char) {
+	if r := recover(); r != nil {
+		*errorClass, *errorMessage = toCError(errorClassRuntime, errors.New("panic: ", r))
+	}
+}
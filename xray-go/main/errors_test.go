@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+// bindingException returns the exception which the binding in src/xray.cpp
+// raises for an error class, or "" if it raises none.
+func bindingException(t *testing.T) func(class int) string {
+	src, err := os.ReadFile(filepath.Join("..", "..", "src", "xray.cpp"))
+	if err != nil {
+		t.Skip("binding source not found: ", err)
+	}
+	values := make(map[string]int)
+	for _, m := range regexp.MustCompile(`(ErrorClass\w+) = (\d+),`).FindAllStringSubmatch(string(src), -1) {
+		values[m[1]], _ = strconv.Atoi(m[2])
+	}
+	exceptions := make(map[int]string)
+	for _, m := range regexp.MustCompile(`case (ErrorClass\w+):\s+throw (\w+)\(`).FindAllStringSubmatch(string(src), -1) {
+		exceptions[values[m[1]]] = m[2]
+	}
+	none, found := values["ErrorClassNone"]
+	if !found || len(exceptions) == 0 {
+		t.Fatal("error classes not found in the binding")
+	}
+	return func(class int) string {
+		if class == none {
+			return ""
+		}
+		if e, found := exceptions[class]; found {
+			return e
+		}
+		return "XrayRuntimeError"
+	}
+}
+
+func TestBindingErrorClasses(t *testing.T) {
+	exceptionOf := bindingException(t)
+
+	_, class, msg := startInstanceFromJSON("{")
+	defer freeCString(msg)
+	if e := exceptionOf(class); e != "XrayConfigError" {
+		t.Error("expected XrayConfigError of invalid JSON, but got ", e)
+	}
+	if e := exceptionOf(errorClassStart); e != "XrayStartError" {
+		t.Error("expected XrayStartError of start error, but got ", e)
+	}
+	if e := exceptionOf(errorClassRuntime); e != "XrayRuntimeError" {
+		t.Error("expected XrayRuntimeError of runtime error, but got ", e)
+	}
+	if e := exceptionOf(errorClassNone); e != "" {
+		t.Error("expected no exception without error, but got ", e)
+	}
+}
+
+func TestToCError(t *testing.T) {
+	if class, msg := toCError(errorClassConfig, nil); class != errorClassNone || msg != nil {
+		t.Error("expected no error, but got error class ", class)
+	}
+	class, msg := toCError(errorClassStart, errors.New("test"))
+	defer freeCString(msg)
+	if class != errorClassStart || msg == nil {
+		t.Error("expected start error, but got error class ", class)
+	}
+}
+
+func TestRecoverCError(t *testing.T) {
+	class, msg := toCError(errorClassNone, nil)
+	func() {
+		defer recoverCError(&class, &msg)
+		panic("test")
+	}()
+	defer freeCString(msg)
+	if class != errorClassRuntime || msg == nil {
+		t.Error("expected runtime error of panic, but got error class ", class)
+	}
+}
+
+func TestStartErrorClasses(t *testing.T) {
+	_, class, msg := startInstanceFromJSON("{")
+	defer freeCString(msg)
+	if class != errorClassConfig || msg == nil {
+		t.Error("expected config error of invalid JSON, but got error class ", class)
+	}
+
+	// The inbound cannot listen on a port in use.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+	_, class, msg = startInstanceFromJSON(`{
+		"log": {"loglevel": "none"},
+		"inbounds": [{"protocol": "socks", "listen": "127.0.0.1", "port": ` + strconv.Itoa(port) + `}],
+		"outbounds": [{"protocol": "freedom"}]
+	}`)
+	defer freeCString(msg)
+	if class != errorClassStart || msg == nil {
+		t.Error("expected start error of port in use, but got error class ", class)
+	}
+
+	handle, class, msg := startInstanceFromJSON(testConfig)
+	defer freeCString(msg)
+	if class != errorClassNone {
+		t.Fatal("failed to start instance, error class ", class)
+	}
+	defer stopInstance(handle)
+	class, msg = reloadInstanceFromJSON(handle, "{")
+	defer freeCString(msg)
+	if class != errorClassConfig || msg == nil {
+		t.Error("expected config error of reload, but got error class ", class)
+	}
+	result, class, msg := callAPI(handle, "Unknown", "")
+	defer freeCString(msg)
+	if result != nil || class != errorClassRuntime || msg == nil {
+		t.Error("expected runtime error of unknown API method, but got error class ", class)
+	}
+}
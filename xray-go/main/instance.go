@@ -0,0 +1,124 @@
+package main
+
+import "C"
+import (
+	"strings"
+	"sync"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/main/commands/all/api"
+)
+
+// instance is an Xray instance started through the binding, with the API
+// services created on its first API call.
+type instance struct {
+	*core.Instance
+
+	servicesOnce sync.Once
+	services     *api.Services
+	servicesErr  error
+}
+
+// getServices returns the API services of the instance. They are created once,
+// as the features they serve are kept on reload.
+func (i *instance) getServices() (*api.Services, error) {
+	i.servicesOnce.Do(func() {
+		i.services, i.servicesErr = api.NewServices(i.Instance)
+	})
+	return i.services, i.servicesErr
+}
+
+// instances keeps every Xray instance started through the binding, keyed by
+// the opaque handle returned to the caller. Handles are never reused.
+var instances = struct {
+	sync.Mutex
+	next    int64
+	running map[int64]*instance
+}{
+	running: make(map[int64]*instance),
+}
+
+func addInstance(server *core.Instance) int64 {
+	instances.Lock()
+	defer instances.Unlock()
+
+	instances.next++
+	instances.running[instances.next] = &instance{Instance: server}
+	return instances.next
+}
+
+func getInstance(handle int64) *instance {
+	instances.Lock()
+	defer instances.Unlock()
+
+	return instances.running[handle]
+}
+
+func removeInstance(handle int64) *instance {
+	instances.Lock()
+	defer instances.Unlock()
+
+	server := instances.running[handle]
+	delete(instances.running, handle)
+	return server
+}
+
+//export startInstanceFromJSON
+func startInstanceFromJSON(jsonString string) (handle int64, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	// The string is backed by memory owned by the caller, so it must be copied
+	// before anything can keep a reference to it.
+	server, err := startXrayFromJSON(strings.Clone(jsonString))
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassConfig, err)
+		return
+	}
+
+	if err := server.Start(); err != nil {
+		server.Close()
+		errorClass, errorMessage = toCError(errorClassStart, errors.New("failed to start").Base(err))
+		return
+	}
+
+	handle = addInstance(server)
+	return
+}
+
+//export stopInstance
+func stopInstance(handle int64) (stopped bool, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	server := removeInstance(handle)
+	if server == nil {
+		return
+	}
+
+	stopped = true
+	errorClass, errorMessage = toCError(errorClassRuntime, server.Close())
+	return
+}
+
+//export reloadInstanceFromJSON
+func reloadInstanceFromJSON(handle int64, jsonString string) (errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	server := getInstance(handle)
+	if server == nil {
+		return toCError(errorClassRuntime, errors.New("instance not found: ", handle))
+	}
+
+	c, err := core.ConfigBuilderForJson(strings.Clone(jsonString))
+	if err != nil {
+		return toCError(errorClassConfig, errors.New("failed to load config from JSON string").Base(err))
+	}
+
+	return toCError(errorClassRuntime, server.Reload(c))
+}
+
+//export isRunning
+func isRunning(handle int64) bool {
+	server := getInstance(handle)
+	return server != nil && server.IsRunning()
+}
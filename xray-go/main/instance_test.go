@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+)
+
+const testConfig = `{
+	"log": {"loglevel": "none"},
+	"outbounds": [{"protocol": "freedom"}]
+}`
+
+func TestInstanceLifecycle(t *testing.T) {
+	handle, class, msg := startInstanceFromJSON(testConfig)
+	defer freeCString(msg)
+	if class != errorClassNone || msg != nil {
+		t.Fatal("failed to start instance, error class ", class)
+	}
+	if !isRunning(handle) {
+		t.Error("expected instance running")
+	}
+
+	// Handles are not reused by other instances.
+	other, class, msg := startInstanceFromJSON(testConfig)
+	defer freeCString(msg)
+	if class != errorClassNone || other == handle {
+		t.Error("unexpected handle ", other, " with error class ", class)
+	}
+	stopInstance(other)
+
+	if class, msg := reloadInstanceFromJSON(handle, testConfig); class != errorClassNone {
+		freeCString(msg)
+		t.Error("failed to reload instance, error class ", class)
+	}
+
+	stopped, class, msg := stopInstance(handle)
+	defer freeCString(msg)
+	if !stopped || class != errorClassNone {
+		t.Error("failed to stop instance, error class ", class)
+	}
+	if isRunning(handle) {
+		t.Error("expected instance stopped")
+	}
+
+	// Stopping it again is not an error, but reports that nothing is stopped.
+	stopped, class, msg = stopInstance(handle)
+	defer freeCString(msg)
+	if stopped || class != errorClassNone {
+		t.Error("unexpected second stop: ", stopped, " with error class ", class)
+	}
+}
+
+func TestInvalidHandle(t *testing.T) {
+	const handle = -1
+
+	if isRunning(handle) {
+		t.Error("expected invalid handle not running")
+	}
+	if stopped, class, _ := stopInstance(handle); stopped || class != errorClassNone {
+		t.Error("unexpected stop of invalid handle: ", stopped, " with error class ", class)
+	}
+	class, msg := reloadInstanceFromJSON(handle, testConfig)
+	defer freeCString(msg)
+	if class != errorClassRuntime || msg == nil {
+		t.Error("expected runtime error of reload, but got error class ", class)
+	}
+	result, class, msg := callAPI(handle, "ListOutbounds", "")
+	defer freeCString(msg)
+	if result != nil || class != errorClassRuntime {
+		t.Error("expected runtime error of API call, but got error class ", class)
+	}
+	result, class, msg = queryInstanceStats(handle, "", false)
+	defer freeCString(msg)
+	if result != nil || class != errorClassRuntime {
+		t.Error("expected runtime error of stats query, but got error class ", class)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import "C"
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"github.com/xtls/xray-core/infra/conf/sharelink"
+)
+
+//export outboundFromLink
+func outboundFromLink(link string) (result *C.char, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	b, err := sharelink.ParseJSON(strings.Clone(link))
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassConfig, err)
+		return
+	}
+	result = C.CString(string(b))
+	return
+}
+
+//export outboundsToLinks
+func outboundsToLinks(jsonString string) (result *C.char, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	outbounds, err := serial.DecodeOutboundsFromJSONString(strings.Clone(jsonString))
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassConfig, err)
+		return
+	}
+
+	links := make([]string, 0, len(outbounds))
+	for i := range outbounds {
+		link, err := sharelink.Format(&outbounds[i])
+		if err != nil {
+			errorClass, errorMessage = toCError(errorClassConfig, errors.New("failed to convert outbound ", i).Base(err))
+			return
+		}
+		links = append(links, link)
+	}
+
+	b, err := json.Marshal(links)
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("failed to encode links").Base(err))
+		return
+	}
+	result = C.CString(string(b))
+	return
+}
@@ -0,0 +1,53 @@
+package main
+
+import "C"
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/xtls/xray-core/app/log"
+	"github.com/xtls/xray-core/common/errors"
+)
+
+// logEvents buffers the messages of every log configured as "event", shared
+// by all instances since the log handler is process wide.
+var logEvents struct {
+	sync.Mutex
+	buffer *log.EventBuffer
+}
+
+//export enableLogEvents
+func enableLogEvents(capacity int) {
+	logEvents.Lock()
+	defer logEvents.Unlock()
+
+	if capacity <= 0 {
+		logEvents.buffer = nil
+		log.SetEventHandler(nil)
+		return
+	}
+	logEvents.buffer = log.NewEventBuffer(capacity)
+	log.SetEventHandler(logEvents.buffer)
+}
+
+//export pollLogEvents
+func pollLogEvents(max int) (result *C.char, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	logEvents.Lock()
+	buffer := logEvents.buffer
+	logEvents.Unlock()
+
+	events := []*log.Event{}
+	if buffer != nil {
+		events = buffer.Poll(max)
+	}
+
+	b, err := json.Marshal(events)
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("failed to encode log events").Base(err))
+		return
+	}
+	result = C.CString(string(b))
+	return
+}
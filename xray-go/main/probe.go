@@ -0,0 +1,37 @@
+package main
+
+import "C"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/app/observatory"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/infra/conf/serial"
+)
+
+//export probeOutboundsFromJSON
+func probeOutboundsFromJSON(jsonString string, probeURL string, timeout int) (result *C.char, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	outbounds, err := serial.BuildOutboundsFromJSONString(strings.Clone(jsonString))
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassConfig, err)
+		return
+	}
+
+	results, err := observatory.ProbeOutbounds(outbounds, strings.Clone(probeURL), time.Duration(timeout)*time.Second)
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassStart, err)
+		return
+	}
+
+	b, err := json.Marshal(results)
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("failed to encode probe results").Base(err))
+		return
+	}
+	result = C.CString(string(b))
+	return
+}
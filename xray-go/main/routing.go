@@ -0,0 +1,94 @@
+package main
+
+import "C"
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// routingSubscriptions keeps the routing event queues subscribed through the
+// binding, keyed by the opaque handle returned to the caller. Handles are never
+// reused.
+var routingSubscriptions = struct {
+	sync.Mutex
+	next   int64
+	queues map[int64]*router.RouteEventQueue
+}{
+	queues: make(map[int64]*router.RouteEventQueue),
+}
+
+//export subscribeRoutingEvents
+func subscribeRoutingEvents(handle int64, capacity int, dropOldest bool) (subscription int64, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	server := getInstance(handle)
+	if server == nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("instance not found: ", handle))
+		return
+	}
+
+	sm := server.GetFeature(stats.ManagerType()).(stats.Manager)
+	channel, err := sm.GetOrRegisterChannel(routing.StatsChannel)
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("routing events need stats enabled in config").Base(err))
+		return
+	}
+	queue, err := router.SubscribeRouteEvents(channel, capacity, dropOldest)
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("failed to subscribe to routing events").Base(err))
+		return
+	}
+
+	routingSubscriptions.Lock()
+	defer routingSubscriptions.Unlock()
+
+	routingSubscriptions.next++
+	routingSubscriptions.queues[routingSubscriptions.next] = queue
+	subscription = routingSubscriptions.next
+	return
+}
+
+//export pollRoutingEvents
+func pollRoutingEvents(subscription int64, max int) (result *C.char, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	routingSubscriptions.Lock()
+	queue := routingSubscriptions.queues[subscription]
+	routingSubscriptions.Unlock()
+
+	if queue == nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("routing event subscription not found: ", subscription))
+		return
+	}
+
+	b, err := json.Marshal(queue.Poll(max))
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("failed to encode routing events").Base(err))
+		return
+	}
+	result = C.CString(string(b))
+	return
+}
+
+//export unsubscribeRoutingEvents
+func unsubscribeRoutingEvents(subscription int64) (unsubscribed bool, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	routingSubscriptions.Lock()
+	queue := routingSubscriptions.queues[subscription]
+	delete(routingSubscriptions.queues, subscription)
+	routingSubscriptions.Unlock()
+
+	if queue == nil {
+		return
+	}
+
+	unsubscribed = true
+	errorClass, errorMessage = toCError(errorClassRuntime, queue.Close())
+	return
+}
@@ -32,7 +32,7 @@ import (
 )
 
 var cmdRun = &base.Command{
-	UsageLine: "{{.Exec}} run [-c config.json] [-confdir dir]",
+	UsageLine: "{{.Exec}} run [-c config.json] [-confdir dir] [-test] [-validate] [-dump]",
 	Short:     "Run Xray with config, the default command",
 	Long: `
 Run Xray with config, the default command.
@@ -52,7 +52,16 @@ in the process environment before Xray starts.
 The -test flag tells Xray to test config files only,
 without launching the server.
 
+The -validate flag tells Xray to check config files for all
+problems instead of stopping at the first one, and print them
+as a JSON array of {"path", "severity", "message"} objects,
+without launching the server.
+
 The -dump flag tells Xray to print the merged config.
+
+Sending SIGHUP to a running Xray reloads the config files in place.
+Only the inbounds, outbounds, routing and DNS settings that changed
+are replaced, the rest keep serving.
 	`,
 }
 
@@ -66,6 +75,7 @@ var (
 	configDir   string
 	dump        = cmdRun.Flag.Bool("dump", false, "Dump merged config only, without launching Xray server.")
 	test        = cmdRun.Flag.Bool("test", false, "Test config file only, without launching Xray server.")
+	validate    = cmdRun.Flag.Bool("validate", false, "Validate config files and print all problems as JSON, without launching Xray server.")
 	format      = cmdRun.Flag.String("format", "auto", "Format of input file.")
 
 	/* We have to do this here because Golang's Test will also need to parse flag, before
@@ -87,8 +97,14 @@ func executeRun(cmd *base.Command, args []string) {
 		os.Exit(errCode)
 	}
 
+	if *validate {
+		clog.ReplaceWithSeverityLogger(clog.Severity_Warning)
+		os.Exit(validateConfig())
+	}
+
 	printVersion()
-	server, err := startXray()
+	configFiles := getConfigFilePath(true)
+	server, err := startXray(configFiles)
 	if err != nil {
 		fmt.Println("Failed to start:", err)
 		// Configuration error. Exit with a special value to prevent systemd from restarting.
@@ -112,8 +128,15 @@ func executeRun(cmd *base.Command, args []string) {
 
 	{
 		osSignals := make(chan os.Signal, 1)
-		signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM)
-		<-osSignals
+		signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+		for sig := range osSignals {
+			if sig != syscall.SIGHUP {
+				break
+			}
+			if err := reloadXray(server, configFiles); err != nil {
+				fmt.Println("Failed to reload:", err)
+			}
+		}
 	}
 }
 
@@ -123,23 +146,32 @@ func freeCString(ptr *C.char) {
 }
 
 //export queryStats
-func queryStats(serverAddr string, timeout int, pattern string, reset bool) *C.char {
-	return C.CString(api.QueryStats(serverAddr, timeout, pattern, reset))
+func queryStats(serverAddr string, timeout int, pattern string, reset bool) (result *C.char, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	stats, err := api.QueryStats(serverAddr, timeout, pattern, reset)
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, err)
+		return
+	}
+	result = C.CString(stats)
+	return
 }
 
 //export startFromJSON
-func startFromJSON(jsonString string) {
+func startFromJSON(jsonString string) (errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
 	// printVersion()
-	server, err := startXrayFromJSON(jsonString)
+	server, err := startXrayFromJSON(strings.Clone(jsonString))
 	if err != nil {
-		// fmt.Println("Failed to start:", err)
-		// Configuration error. Exit with a special value to prevent systemd from restarting.
-		os.Exit(23)
+		// Configuration error. Report it to the caller instead of exiting the host process.
+		return toCError(errorClassConfig, err)
 	}
 
 	if err := server.Start(); err != nil {
-		// fmt.Println("Failed to start:", err)
-		os.Exit(-1)
+		server.Close()
+		return toCError(errorClassStart, errors.New("failed to start").Base(err))
 	}
 	defer server.Close()
 
@@ -158,6 +190,7 @@ func startFromJSON(jsonString string) {
 		signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM)
 		<-osSignals
 	}
+	return
 }
 
 func dumpConfig() int {
@@ -265,7 +298,7 @@ func getConfigFormat() string {
 	return f
 }
 
-func startXrayFromJSON(jsonString string) (core.Server, error) {
+func startXrayFromJSON(jsonString string) (*core.Instance, error) {
 	c, err := core.ConfigBuilderForJson(jsonString)
 	if err != nil {
 		return nil, errors.New("failed to load config from JSON string").Base(err)
@@ -279,9 +312,7 @@ func startXrayFromJSON(jsonString string) (core.Server, error) {
 	return server, nil
 }
 
-func startXray() (core.Server, error) {
-	configFiles := getConfigFilePath(true)
-
+func startXray(configFiles cmdarg.Arg) (*core.Instance, error) {
 	c, err := core.LoadConfig(getConfigFormat(), configFiles)
 	if err != nil {
 		return nil, errors.New("failed to load config files: [", configFiles.String(), "]").Base(err)
@@ -294,3 +325,12 @@ func startXray() (core.Server, error) {
 
 	return server, nil
 }
+
+func reloadXray(server *core.Instance, configFiles cmdarg.Arg) error {
+	c, err := core.LoadConfig(getConfigFormat(), configFiles)
+	if err != nil {
+		return errors.New("failed to load config files: [", configFiles.String(), "]").Base(err)
+	}
+
+	return server.Reload(c)
+}
@@ -0,0 +1,73 @@
+package main
+
+import "C"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	creflect "github.com/xtls/xray-core/common/reflect"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/infra/conf/serial"
+)
+
+func validateConfigFiles(files []string) []*conf.ConfigIssue {
+	sources := make([]*core.ConfigSource, 0, len(files))
+	for _, file := range files {
+		format := getConfigFormat()
+		if format == "auto" {
+			format = "json"
+			if file != "stdin:" {
+				format = core.GetFormat(file)
+			}
+		}
+		if _, found := serial.ReaderDecoderByFormat[format]; !found {
+			return []*conf.ConfigIssue{conf.NewErrorIssue(errors.New("unable to validate config in format ", format, ": ", file))}
+		}
+		sources = append(sources, &core.ConfigSource{
+			Name:   file,
+			Format: format,
+		})
+	}
+	return serial.ValidateConfigFiles(sources)
+}
+
+func encodeConfigIssues(issues []*conf.ConfigIssue) ([]byte, error) {
+	if issues == nil {
+		issues = []*conf.ConfigIssue{}
+	}
+	return creflect.JSONMarshalWithoutEscape(issues)
+}
+
+// validateConfig prints every problem found in the config files as a JSON
+// array. Like a config that fails to load, any problem of severity error
+// makes it return 23.
+func validateConfig() int {
+	issues := validateConfigFiles(getConfigFilePath(false))
+	b, err := encodeConfigIssues(issues)
+	if err != nil {
+		fmt.Println(err)
+		return 23
+	}
+	fmt.Print(string(b))
+	for _, issue := range issues {
+		if issue.Severity == conf.IssueSeverityError {
+			return 23
+		}
+	}
+	return 0
+}
+
+//export validateConfigJSON
+func validateConfigJSON(jsonString string) (result *C.char, errorClass int, errorMessage *C.char) {
+	defer recoverCError(&errorClass, &errorMessage)
+
+	b, err := encodeConfigIssues(serial.ValidateJSONString(strings.Clone(jsonString)))
+	if err != nil {
+		errorClass, errorMessage = toCError(errorClassRuntime, errors.New("failed to encode config issues").Base(err))
+		return
+	}
+	result = C.CString(string(b))
+	return
+}
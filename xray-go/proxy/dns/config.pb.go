@@ -76,11 +76,13 @@ func (RuleAction) EnumDescriptor() ([]byte, []int) {
 }
 
 type DNSRuleConfig struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Action        RuleAction             `protobuf:"varint,1,opt,name=action,proto3,enum=xray.proxy.dns.RuleAction" json:"action,omitempty"`
-	QType         []int32                `protobuf:"varint,2,rep,packed,name=q_type,json=qType,proto3" json:"q_type,omitempty"`
-	Domain        []*geodata.DomainRule  `protobuf:"bytes,3,rep,name=domain,proto3" json:"domain,omitempty"`
-	RCode         uint32                 `protobuf:"varint,4,opt,name=r_code,json=rCode,proto3" json:"r_code,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Action RuleAction             `protobuf:"varint,1,opt,name=action,proto3,enum=xray.proxy.dns.RuleAction" json:"action,omitempty"`
+	QType  []int32                `protobuf:"varint,2,rep,packed,name=q_type,json=qType,proto3" json:"q_type,omitempty"`
+	Domain []*geodata.DomainRule  `protobuf:"bytes,3,rep,name=domain,proto3" json:"domain,omitempty"`
+	RCode  uint32                 `protobuf:"varint,4,opt,name=r_code,json=rCode,proto3" json:"r_code,omitempty"`
+	// SvcParamKeys removed from the HTTPS and SVCB records answered by Hijack.
+	StripSvcParam []uint32 `protobuf:"varint,5,rep,packed,name=strip_svc_param,json=stripSvcParam,proto3" json:"strip_svc_param,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -143,6 +145,13 @@ func (x *DNSRuleConfig) GetRCode() uint32 {
 	return 0
 }
 
+func (x *DNSRuleConfig) GetStripSvcParam() []uint32 {
+	if x != nil {
+		return x.StripSvcParam
+	}
+	return nil
+}
+
 type Config struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserLevel     uint32                 `protobuf:"varint,1,opt,name=user_level,json=userLevel,proto3" json:"user_level,omitempty"`
@@ -207,12 +216,13 @@ var File_proxy_dns_config_proto protoreflect.FileDescriptor
 
 const file_proxy_dns_config_proto_rawDesc = "" +
 	"\n" +
-	"\x16proxy/dns/config.proto\x12\x0exray.proxy.dns\x1a\x1ccommon/net/destination.proto\x1a\x1bcommon/geodata/geodat.proto\"\xaa\x01\n" +
+	"\x16proxy/dns/config.proto\x12\x0exray.proxy.dns\x1a\x1ccommon/net/destination.proto\x1a\x1bcommon/geodata/geodat.proto\"\xd2\x01\n" +
 	"\rDNSRuleConfig\x122\n" +
 	"\x06action\x18\x01 \x01(\x0e2\x1a.xray.proxy.dns.RuleActionR\x06action\x12\x15\n" +
 	"\x06q_type\x18\x02 \x03(\x05R\x05qType\x127\n" +
 	"\x06domain\x18\x03 \x03(\v2\x1f.xray.common.geodata.DomainRuleR\x06domain\x12\x15\n" +
-	"\x06r_code\x18\x04 \x01(\rR\x05rCode\"\x9c\x01\n" +
+	"\x06r_code\x18\x04 \x01(\rR\x05rCode\x12&\n" +
+	"\x0fstrip_svc_param\x18\x05 \x03(\rR\rstripSvcParam\"\x9c\x01\n" +
 	"\x06Config\x12\x1d\n" +
 	"\n" +
 	"user_level\x18\x01 \x01(\rR\tuserLevel\x121\n" +
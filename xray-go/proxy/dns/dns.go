@@ -2,8 +2,10 @@ package dns
 
 import (
 	"context"
+	"encoding/binary"
 	go_errors "errors"
 	"io"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -42,10 +44,11 @@ func init() {
 }
 
 type DNSRule struct {
-	action  RuleAction
-	qTypes  []uint16
-	domains geodata.DomainMatcher
-	rCode   dnsmessage.RCode
+	action      RuleAction
+	qTypes      []uint16
+	domains     geodata.DomainMatcher
+	rCode       dnsmessage.RCode
+	stripParams []dnsmessage.SVCParamKey
 }
 
 func (r *DNSRule) matchQType(qType uint16) bool {
@@ -73,6 +76,7 @@ type ownLinkVerifier interface {
 
 type Handler struct {
 	client          dns.Client
+	recordClient    dns.RecordClient
 	fdns            dns.FakeDNSEngine
 	ownLinkVerifier ownLinkVerifier
 	rewriteServer   net.Destination
@@ -88,6 +92,10 @@ func (h *Handler) Init(config *Config, dnsClient dns.Client, policyManager polic
 		h.ownLinkVerifier = v
 	}
 
+	if v, ok := dnsClient.(dns.RecordClient); ok {
+		h.recordClient = v
+	}
+
 	if config.RewriteServer != nil {
 		h.rewriteServer = config.RewriteServer.AsDestination()
 	}
@@ -102,6 +110,12 @@ func (h *Handler) Init(config *Config, dnsClient dns.Client, policyManager polic
 		for _, t := range r.QType {
 			rule.qTypes = append(rule.qTypes, uint16(t))
 		}
+		for _, k := range r.StripSvcParam {
+			if k > 65535 {
+				return errors.New("SvcParamKey out of range: ", k)
+			}
+			rule.stripParams = append(rule.stripParams, dnsmessage.SVCParamKey(k))
+		}
 		if len(r.Domain) > 0 {
 			m, err := geodata.DomainReg.BuildDomainMatcher(r.Domain)
 			if err != nil {
@@ -138,17 +152,17 @@ func parseQuery(b []byte) (id uint16, qType dnsmessage.Type, domain string, ok b
 	return
 }
 
-func (h *Handler) applyRules(qType dnsmessage.Type, domain string) (RuleAction, dnsmessage.RCode) {
+func (h *Handler) applyRules(qType dnsmessage.Type, domain string) (RuleAction, dnsmessage.RCode, []dnsmessage.SVCParamKey) {
 	qCode := uint16(qType)
 	for _, r := range h.rules {
 		if r.Apply(qCode, domain) {
-			return r.action, r.rCode
+			return r.action, r.rCode, r.stripParams
 		}
 	}
 	if qType == dnsmessage.TypeA || qType == dnsmessage.TypeAAAA {
-		return RuleAction_Hijack, dnsmessage.RCodeSuccess
+		return RuleAction_Hijack, dnsmessage.RCodeSuccess, nil
 	}
-	return RuleAction_Return, dnsmessage.RCodeSuccess
+	return RuleAction_Return, dnsmessage.RCodeSuccess, nil
 }
 
 // Process implements proxy.Outbound.
@@ -252,7 +266,7 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, d internet.
 				continue
 			}
 
-			action, rCode := h.applyRules(qType, domain)
+			action, rCode, stripParams := h.applyRules(qType, domain)
 			switch action {
 			case RuleAction_Drop:
 				b.Release()
@@ -265,13 +279,16 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, d internet.
 				}
 			case RuleAction_Hijack:
 				b.Release()
-				if qType != dnsmessage.TypeA && qType != dnsmessage.TypeAAAA {
+				switch {
+				case qType == dnsmessage.TypeA || qType == dnsmessage.TypeAAAA:
+					go h.handleIPQuery(id, qType, domain, writer, timer)
+				case h.recordClient != nil:
+					go h.handleRecordQuery(id, qType, domain, stripParams, writer, timer)
+				default:
 					errors.LogError(ctx, "can only hijack A/AAAA records")
 					if err := h.rejectNonIPQuery(id, qType, domain, writer, rCode); err != nil {
 						return err
 					}
-				} else {
-					go h.handleIPQuery(id, qType, domain, writer, timer)
 				}
 			case RuleAction_Direct:
 				if err := connWriter.WriteMessage(b); err != nil {
@@ -390,6 +407,90 @@ func (h *Handler) handleIPQuery(id uint16, qType dnsmessage.Type, domain string,
 	}
 }
 
+func (h *Handler) handleRecordQuery(id uint16, qType dnsmessage.Type, domain string, stripParams []dnsmessage.SVCParamKey, writer dns_proto.MessageWriter, timer *signal.ActivityTimer) {
+	answers, ttl, err := h.recordClient.LookupRecords(domain, qType)
+
+	rCode := dnsmessage.RCode(dns.RCodeFromError(err))
+	if rCode == dnsmessage.RCodeSuccess && len(answers) == 0 && !go_errors.Is(err, dns.ErrEmptyResponse) {
+		// The name servers failed, and the client is told so at once.
+		errors.LogInfoInner(context.Background(), err, "record query")
+		rCode = dnsmessage.RCodeServerFailure
+	}
+
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 id,
+			RCode:              rCode,
+			RecursionAvailable: true,
+			RecursionDesired:   true,
+			Response:           true,
+			Authoritative:      true,
+		},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName(domain),
+			Class: dnsmessage.ClassINET,
+			Type:  qType,
+		}},
+		Answers: make([]dnsmessage.Resource, 0, len(answers)),
+	}
+	for _, ans := range answers {
+		ans.Header.TTL = ttl
+		switch body := ans.Body.(type) {
+		case *dnsmessage.HTTPSResource:
+			ans.Body = &dnsmessage.HTTPSResource{SVCBResource: stripSVCParams(body.SVCBResource, stripParams)}
+		case *dnsmessage.SVCBResource:
+			svcb := stripSVCParams(*body, stripParams)
+			ans.Body = &svcb
+		}
+		msg.Answers = append(msg.Answers, ans)
+	}
+
+	b := buf.New()
+	rawBytes := b.Extend(buf.Size)
+	msgBytes, err := msg.AppendPack(rawBytes[:0])
+	if err == nil && len(msgBytes) > len(rawBytes) {
+		err = errors.New("message too large: ", len(msgBytes))
+	}
+	if err != nil {
+		errors.LogInfoInner(context.Background(), err, "pack message")
+		b.Release()
+		timer.SetTimeout(0)
+		return
+	}
+	b.Resize(0, int32(len(msgBytes)))
+
+	if err := writer.WriteMessage(b); err != nil {
+		errors.LogInfoInner(context.Background(), err, "write record answer")
+		timer.SetTimeout(0)
+	}
+}
+
+// stripSVCParams returns a copy of r without the params of keys, which are
+// also removed from its mandatory keys.
+func stripSVCParams(r dnsmessage.SVCBResource, keys []dnsmessage.SVCParamKey) dnsmessage.SVCBResource {
+	if len(keys) == 0 {
+		return r
+	}
+	r.Params = slices.Clone(r.Params)
+	for _, key := range keys {
+		r.DeleteParam(key)
+	}
+	if mandatory, found := r.GetParam(dnsmessage.SVCParamMandatory); found {
+		kept := make([]byte, 0, len(mandatory))
+		for i := 0; i+2 <= len(mandatory); i += 2 {
+			if !slices.Contains(keys, dnsmessage.SVCParamKey(binary.BigEndian.Uint16(mandatory[i:]))) {
+				kept = append(kept, mandatory[i:i+2]...)
+			}
+		}
+		if len(kept) == 0 {
+			r.DeleteParam(dnsmessage.SVCParamMandatory)
+		} else {
+			r.SetParam(dnsmessage.SVCParamMandatory, kept)
+		}
+	}
+	return r
+}
+
 func (h *Handler) rejectNonIPQuery(id uint16, qType dnsmessage.Type, domain string, writer dns_proto.MessageWriter, rCode dnsmessage.RCode) error {
 	domainT := strings.TrimSuffix(domain, ".")
 	if domainT == "" {
@@ -292,7 +292,10 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 			if destination.Network == net.Network_UDP && origTargetAddr != nil && outGateway == nil {
 				strategy = strategy.GetDynamicStrategy(origTargetAddr.Family())
 			}
+			_, span := session.StartSpan(ctx, "dns")
+			span.SetAttribute("domain", dialDest.Address.Domain())
 			ips, err := internet.LookupForIP(dialDest.Address.Domain(), strategy, outGateway)
+			span.End(err)
 			if err != nil {
 				errors.LogInfoInner(ctx, err, "failed to get IP address for domain ", dialDest.Address.Domain())
 				if h.config.DomainStrategy.ForceIP() || h.shouldResolveDomainBeforeFinalRules(dialDest, defaultRule) {
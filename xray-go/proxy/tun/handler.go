@@ -84,7 +84,7 @@ func (t *Handler) Init(ctx context.Context, pm policy.Manager, dispatcher routin
 
 func (t *Handler) Start() error {
 	tunName := t.config.Name
-	tunInterface, err := NewTun(t.config)
+	tunInterface, err := newTun(t.config)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,197 @@
+package tun
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xtls/xray-core/common/errors"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+const (
+	memoryTunDefaultMTU = 1500
+	memoryTunQueueSize  = 256
+)
+
+var memoryTuns = struct {
+	sync.Mutex
+	byName map[string]*MemoryTun
+}{byName: make(map[string]*MemoryTun)}
+
+// MemoryTun is a packet device in memory, which takes the place of the kernel
+// device for the tun inbound with its name. Raw IP packets injected into it are
+// handled by the inbound as if they arrived on a real interface, and the
+// packets the inbound sends back are received from it. It needs no privileges,
+// so it can drive the inbound in tests or in programs embedding Xray.
+type MemoryTun struct {
+	name string
+	mtu  uint32
+
+	incoming chan []byte
+	outgoing chan []byte
+	ready    chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// MemoryTun implements Tun
+var _ Tun = (*MemoryTun)(nil)
+
+// NewMemoryTun creates a MemoryTun used by the tun inbound with the given name.
+// It is closed with the inbound, and only one may exist for a name at a time.
+func NewMemoryTun(name string) (*MemoryTun, error) {
+	memoryTuns.Lock()
+	defer memoryTuns.Unlock()
+
+	if _, found := memoryTuns.byName[name]; found {
+		return nil, errors.New("memory tun ", name, " already exists")
+	}
+	t := &MemoryTun{
+		name:     name,
+		mtu:      memoryTunDefaultMTU,
+		incoming: make(chan []byte, memoryTunQueueSize),
+		outgoing: make(chan []byte, memoryTunQueueSize),
+		ready:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	memoryTuns.byName[name] = t
+	return t, nil
+}
+
+// newTun returns the MemoryTun with the name of the inbound if there is one,
+// or builds a tun interface of the platform otherwise.
+func newTun(options *Config) (Tun, error) {
+	memoryTuns.Lock()
+	t := memoryTuns.byName[options.Name]
+	memoryTuns.Unlock()
+
+	if t == nil {
+		return NewTun(options)
+	}
+	if options.MTU > 0 {
+		t.mtu = options.MTU
+	}
+	return t, nil
+}
+
+// Inject passes a raw IP packet to the inbound. It blocks while the queue of
+// the device is full.
+func (t *MemoryTun) Inject(packet []byte) error {
+	if len(packet) == 0 {
+		return errors.New("empty packet")
+	}
+	if err := t.checkClosed(); err != nil {
+		return err
+	}
+	select {
+	case t.incoming <- append([]byte(nil), packet...):
+	case <-t.done:
+		return errors.New("memory tun ", t.name, " is closed")
+	}
+	select {
+	case t.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Receive returns the next raw IP packet sent by the inbound. Packets are
+// dropped, as on a real link, if they are not received before the queue of
+// the device is full.
+func (t *MemoryTun) Receive(ctx context.Context) ([]byte, error) {
+	select {
+	case packet := <-t.outgoing:
+		return packet, nil
+	case <-t.done:
+		return nil, errors.New("memory tun ", t.name, " is closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *MemoryTun) checkClosed() error {
+	select {
+	case <-t.done:
+		return errors.New("memory tun ", t.name, " is closed")
+	default:
+		return nil
+	}
+}
+
+func (t *MemoryTun) Start() error {
+	return t.checkClosed()
+}
+
+func (t *MemoryTun) Close() error {
+	t.once.Do(func() {
+		close(t.done)
+
+		memoryTuns.Lock()
+		if memoryTuns.byName[t.name] == t {
+			delete(memoryTuns.byName, t.name)
+		}
+		memoryTuns.Unlock()
+	})
+	return nil
+}
+
+func (t *MemoryTun) Name() (string, error) {
+	return t.name, nil
+}
+
+func (t *MemoryTun) Index() (int, error) {
+	return 0, errors.New("memory tun ", t.name, " has no interface index")
+}
+
+func (t *MemoryTun) newEndpoint() (stack.LinkEndpoint, error) {
+	return &LinkEndpoint{deviceMTU: t.mtu, device: (*memoryDevice)(t)}, nil
+}
+
+// memoryDevice is the side of MemoryTun facing the gVisor stack.
+type memoryDevice MemoryTun
+
+// memoryDevice implements GVisorDevice
+var _ GVisorDevice = (*memoryDevice)(nil)
+
+// WritePacket implements GVisorDevice method to queue one packet for Receive
+func (d *memoryDevice) WritePacket(packet *stack.PacketBuffer) tcpip.Error {
+	b := make([]byte, 0, packet.Size())
+	for _, packetElement := range packet.AsSlices() {
+		b = append(b, packetElement...)
+	}
+	select {
+	case <-d.done:
+		return &tcpip.ErrClosedForSend{}
+	default:
+	}
+	select {
+	case d.outgoing <- b:
+	default:
+	}
+	return nil
+}
+
+// ReadPacket implements GVisorDevice method to read one injected packet
+func (d *memoryDevice) ReadPacket() (byte, *stack.PacketBuffer, error) {
+	select {
+	case packet := <-d.incoming:
+		return packet[0] >> 4, stack.NewPacketBuffer(stack.PacketBufferOptions{
+			Payload:           buffer.MakeWithData(packet),
+			IsForwardedPacket: true,
+		}), nil
+	case <-d.done:
+		return 0, nil, errors.New("memory tun ", d.name, " is closed")
+	default:
+		return 0, nil, ErrQueueEmpty
+	}
+}
+
+// Wait until a packet is injected or the device is closed
+func (d *memoryDevice) Wait() {
+	select {
+	case <-d.ready:
+	case <-d.done:
+	}
+}
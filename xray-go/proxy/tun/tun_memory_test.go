@@ -0,0 +1,212 @@
+package tun
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/features/policy"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/checksum"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+)
+
+var (
+	testClientAddr = tcpip.AddrFrom4([4]byte{10, 0, 0, 2})
+	testRemoteAddr = tcpip.AddrFrom4([4]byte{1, 1, 1, 1})
+)
+
+// startMemoryTun starts a tun inbound on a MemoryTun, which is closed with the
+// test.
+func startMemoryTun(t *testing.T, name string, dispatcher *testDispatcher) *MemoryTun {
+	t.Helper()
+	device, err := NewMemoryTun(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := &Handler{
+		ctx:           context.Background(),
+		config:        &Config{Name: name, MTU: 1500},
+		policyManager: policy.DefaultManager{},
+		dispatcher:    dispatcher,
+	}
+	if err := handler.Start(); err != nil {
+		device.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		handler.Close()
+	})
+	return device
+}
+
+// newClientStack creates a gVisor stack behind device, as a host sending its
+// traffic to the tun interface.
+func newClientStack(ctx context.Context, t *testing.T, device *MemoryTun) *stack.Stack {
+	t.Helper()
+	s := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{tcp.NewProtocol, udp.NewProtocol},
+	})
+	endpoint := channel.New(memoryTunQueueSize, 1500, "")
+	if err := s.CreateNIC(1, endpoint); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddProtocolAddress(1, tcpip.ProtocolAddress{
+		Protocol:          ipv4.ProtocolNumber,
+		AddressWithPrefix: testClientAddr.WithPrefix(),
+	}, stack.AddressProperties{}); err != nil {
+		t.Fatal(err)
+	}
+	s.SetRouteTable([]tcpip.Route{{Destination: header.IPv4EmptySubnet, NIC: 1}})
+	t.Cleanup(s.Close)
+
+	go func() {
+		for {
+			packet := endpoint.ReadContext(ctx)
+			if packet == nil {
+				return
+			}
+			b := packet.ToView().AsSlice()
+			err := device.Inject(b)
+			packet.DecRef()
+			if err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			b, err := device.Receive(ctx)
+			if err != nil {
+				return
+			}
+			packet := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(b)})
+			endpoint.InjectInbound(ipv4.ProtocolNumber, packet)
+			packet.DecRef()
+		}
+	}()
+	return s
+}
+
+func TestMemoryTunTCP(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dispatcher := &testDispatcher{writePayload: []byte("downlink")}
+	device := startMemoryTun(t, "memtest-tcp", dispatcher)
+	s := newClientStack(ctx, t, device)
+
+	conn, err := gonet.DialContextTCP(ctx, s, tcpip.FullAddress{NIC: 1, Addr: testRemoteAddr, Port: 80}, ipv4.ProtocolNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := conn.Write([]byte("uplink")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "downlink" {
+		t.Fatalf("connection read mismatch: got %q, want %q", b, "downlink")
+	}
+}
+
+func TestMemoryTunUDP(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dispatcher := &testDispatcher{writePayload: []byte("downlink")}
+	device := startMemoryTun(t, "memtest-udp", dispatcher)
+	s := newClientStack(ctx, t, device)
+
+	conn, err := gonet.DialUDP(s, nil, &tcpip.FullAddress{NIC: 1, Addr: testRemoteAddr, Port: 53}, ipv4.ProtocolNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := conn.Write([]byte("uplink")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 64)
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "downlink" {
+		t.Fatalf("packet read mismatch: got %q, want %q", b[:n], "downlink")
+	}
+}
+
+func TestMemoryTunICMPEcho(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	device := startMemoryTun(t, "memtest-icmp", &testDispatcher{})
+
+	request := make([]byte, header.IPv4MinimumSize+header.ICMPv4MinimumSize+4)
+	ip := header.IPv4(request)
+	ip.Encode(&header.IPv4Fields{
+		TotalLength: uint16(len(request)),
+		TTL:         64,
+		Protocol:    uint8(header.ICMPv4ProtocolNumber),
+		SrcAddr:     testClientAddr,
+		DstAddr:     testRemoteAddr,
+	})
+	ip.SetChecksum(^ip.CalculateChecksum())
+	icmp := header.ICMPv4(ip.Payload())
+	icmp.SetType(header.ICMPv4Echo)
+	icmp.SetIdent(1)
+	icmp.SetSequence(2)
+	copy(icmp.Payload(), "ping")
+	icmp.SetChecksum(^checksum.Checksum(icmp, 0))
+
+	if err := device.Inject(request); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := device.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip = header.IPv4(reply)
+	if !ip.IsValid(len(reply)) || ip.SourceAddress() != testRemoteAddr || ip.DestinationAddress() != testClientAddr {
+		t.Fatalf("unexpected reply packet: %x", reply)
+	}
+	icmp = header.ICMPv4(ip.Payload())
+	if icmp.Type() != header.ICMPv4EchoReply || icmp.Ident() != 1 || icmp.Sequence() != 2 || string(icmp.Payload()) != "ping" {
+		t.Fatalf("unexpected echo reply: %x", icmp)
+	}
+}
+
+func TestMemoryTunName(t *testing.T) {
+	device, err := NewMemoryTun("memtest-name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMemoryTun("memtest-name"); err == nil {
+		t.Fatal("expected error for existing name")
+	}
+	device.Close()
+	if err := device.Inject([]byte{0x45}); err == nil {
+		t.Fatal("expected error for closed device")
+	}
+	device, err = NewMemoryTun("memtest-name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	device.Close()
+}
@@ -253,7 +253,10 @@ func DialSystem(ctx context.Context, dest net.Destination, sockopt *SocketConfig
 		if outboundName == "freedom" && dest.Network == net.Network_UDP && origTargetAddr != nil && src == nil {
 			finalStrategy = finalStrategy.GetDynamicStrategy(origTargetAddr.Family())
 		}
+		_, span := session.StartSpan(ctx, "dns")
+		span.SetAttribute("domain", dest.Address.Domain())
 		ips, err := LookupForIP(dest.Address.Domain(), finalStrategy, src)
+		span.End(err)
 		if err != nil {
 			errors.LogErrorInner(ctx, err, "failed to resolve ip")
 			if sockopt.DomainStrategy.ForceIP() {
@@ -73,6 +73,8 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 				tlsConfig.NextProtos = []string{"h2", "http/1.1"}
 			}
 		}
+		_, span := session.StartSpan(ctx, "tls")
+		span.SetAttribute("server_name", tlsConfig.ServerName)
 		if fingerprint := tls.GetFingerprint(config.Fingerprint); fingerprint != nil {
 			conn = tls.UClient(conn, tlsConfig, fingerprint)
 			if len(tlsConfig.NextProtos) == 1 && tlsConfig.NextProtos[0] == "http/1.1" { // allow manually specify
@@ -84,6 +86,7 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 			conn = tls.Client(conn, tlsConfig)
 			err = conn.(*tls.Conn).HandshakeContext(ctx)
 		}
+		span.End(err)
 		if err != nil {
 			if isFromMitmVerify {
 				return nil, errors.New("MITM freedom RAW TLS: failed to verify Domain Fronting certificate from " + mitmServerName).Base(err).AtWarning()
@@ -96,7 +99,11 @@ func Dial(ctx context.Context, dest net.Destination, streamSettings *internet.Me
 			return nil, errors.New("MITM freedom RAW TLS: unexpected Negotiated Protocol (" + negotiatedProtocol + ") with " + mitmServerName).AtWarning()
 		}
 	} else if config := reality.ConfigFromStreamSettings(streamSettings); config != nil {
-		if conn, err = reality.UClient(conn, config, ctx, dest); err != nil {
+		_, span := session.StartSpan(ctx, "reality")
+		span.SetAttribute("server_name", config.ServerName)
+		conn, err = reality.UClient(conn, config, ctx, dest)
+		span.End(err)
+		if err != nil {
 			return nil, err
 		}
 	}